@@ -0,0 +1,596 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Node is implemented by every node of the abstract syntax tree returned by Parse
+type Node interface {
+	// Pos returns the byte offset of the node in the parsed query
+	Pos() int
+	// String returns the SOQL text of the node
+	String() string
+	writeTo(buff *strings.Builder)
+}
+
+// SelectItem is an item in the select list of a query. It is one of *FieldRef, *FunctionCall, *SubqueryItem or
+// *TypeOfItem
+type SelectItem interface {
+	Node
+	selectItem()
+}
+
+// FieldExpr is a field used in a condition, order by or group by clause. It is either *FieldRef or *FunctionCall
+type FieldExpr interface {
+	Node
+	fieldExpr()
+}
+
+// Expr is a condition in where or having clause. It is one of *LogicalExpr, *NotExpr, *ParenExpr or *ComparisonExpr
+type Expr interface {
+	Node
+	expr()
+}
+
+// Value is the right hand side of a comparison. It is one of *StringLiteral, *NumberLiteral,
+// *BooleanLiteral, *NullLiteral, *DateTimeLiteral, *RelativeDateLiteral, *ListValue or *SubqueryValue
+type Value interface {
+	Node
+	value()
+}
+
+// Query is the root node of a parsed SOQL query. It is also used for child relationship subqueries
+// in select list and semi-join subqueries in where clause.
+type Query struct {
+	Position int
+	// Fields is the select list
+	Fields []SelectItem
+	// From is the object (or child relationship name for subqueries in select list) to query
+	From *FieldRef
+	// Scope is the filter scope of the using scope clause, e.g. mine, and is empty when the query has no using
+	// scope clause
+	Scope string
+	// Where is nil when the query has no where clause
+	Where Expr
+	// With is the security mode of the with clause, e.g. SECURITY_ENFORCED, and is empty when the query has
+	// no with clause
+	With string
+	// GroupBy is nil when the query has no group by clause
+	GroupBy *GroupBy
+	// Having is nil when the query has no having clause
+	Having  Expr
+	OrderBy []*OrderItem
+	// Limit and Offset are nil when the clause is not present
+	Limit  *int
+	Offset *int
+	// For is the mode of the for clause, e.g. UPDATE, and is empty when the query has no for clause
+	For string
+}
+
+// FieldRef is a reference to a field or an object. Name can be a dotted relationship path like Role__r.Name
+type FieldRef struct {
+	Position int
+	Name     string
+}
+
+// FunctionCall is a function like COUNT(Id) or CALENDAR_YEAR(CreatedDate). Alias is empty if the function
+// has no alias in select list.
+type FunctionCall struct {
+	Position int
+	Name     string
+	Args     []FieldExpr
+	Alias    string
+}
+
+// SubqueryItem is a child relationship subquery in select list
+type SubqueryItem struct {
+	Position int
+	Query    *Query
+}
+
+// TypeOfItem is a TYPEOF expression in select list, which selects fields of polymorphic relationship Field
+// depending on the sObject of the related record
+type TypeOfItem struct {
+	Position int
+	Field    *FieldRef
+	Whens    []*TypeOfWhen
+	// Else is nil when the expression has no ELSE
+	Else []*FieldRef
+}
+
+// TypeOfWhen is a WHEN of TYPEOF expression selecting Fields for records of sObject Type
+type TypeOfWhen struct {
+	Position int
+	Type     string
+	Fields   []*FieldRef
+}
+
+// OrderItem is a single column of order by clause
+type OrderItem struct {
+	Position int
+	Field    FieldExpr
+	// Direction is ASC, DESC or empty if not specified
+	Direction string
+	// Nulls is FIRST, LAST or empty if not specified
+	Nulls string
+}
+
+// GroupBy is the group by clause of a query
+type GroupBy struct {
+	Position int
+	// Type is ROLLUP, CUBE or empty for plain group by
+	Type   string
+	Fields []FieldExpr
+}
+
+// LogicalExpr is a sequence of conditions combined with the same logical Operator, AND or OR.
+// SOQL requires parentheses when both are mixed, so every LogicalExpr has a single operator.
+type LogicalExpr struct {
+	Position int
+	Operator string
+	Operands []Expr
+}
+
+// NotExpr negates its Operand
+type NotExpr struct {
+	Position int
+	Operand  Expr
+}
+
+// ParenExpr is a condition wrapped in parentheses
+type ParenExpr struct {
+	Position int
+	Inner    Expr
+}
+
+// ComparisonExpr compares Field with Value using Operator, which is one of =, !=, <>, <, <=, >, >=,
+// LIKE, IN, NOT IN, INCLUDES or EXCLUDES
+type ComparisonExpr struct {
+	Position int
+	Field    FieldExpr
+	Operator string
+	Value    Value
+}
+
+// StringLiteral is a quoted string. Raw is the text between the quotes exactly as it appears in the query,
+// i.e. with the escape sequences.
+type StringLiteral struct {
+	Position int
+	Raw      string
+}
+
+// NumberLiteral is an integer or decimal number
+type NumberLiteral struct {
+	Position int
+	Raw      string
+}
+
+// BooleanLiteral is true or false
+type BooleanLiteral struct {
+	Position int
+	Value    bool
+}
+
+// NullLiteral is null
+type NullLiteral struct {
+	Position int
+}
+
+// DateTimeLiteral is a date (2006-01-02) or dateTime (2006-01-02T15:04:05.000-0700) value
+type DateTimeLiteral struct {
+	Position int
+	Raw      string
+}
+
+// RelativeDateLiteral is a SOQL date literal like TODAY or NEXT_N_DAYS:5. N is nil for the
+// literals that do not take a parameter.
+type RelativeDateLiteral struct {
+	Position int
+	Name     string
+	N        *int
+}
+
+// ListValue is a parenthesised list of values used with IN, NOT IN, INCLUDES and EXCLUDES
+type ListValue struct {
+	Position int
+	Values   []Value
+}
+
+// SubqueryValue is a semi-join (or anti-join) subquery used with IN and NOT IN
+type SubqueryValue struct {
+	Position int
+	Query    *Query
+}
+
+func (f *FieldRef) selectItem()       {}
+func (f *FunctionCall) selectItem()   {}
+func (f *FieldRef) fieldExpr()        {}
+func (f *FunctionCall) fieldExpr()    {}
+func (s *SubqueryItem) selectItem()   {}
+func (t *TypeOfItem) selectItem()     {}
+func (e *LogicalExpr) expr()          {}
+func (e *NotExpr) expr()              {}
+func (e *ParenExpr) expr()            {}
+func (e *ComparisonExpr) expr()       {}
+func (v *StringLiteral) value()       {}
+func (v *NumberLiteral) value()       {}
+func (v *BooleanLiteral) value()      {}
+func (v *NullLiteral) value()         {}
+func (v *DateTimeLiteral) value()     {}
+func (v *RelativeDateLiteral) value() {}
+func (v *ListValue) value()           {}
+func (v *SubqueryValue) value()       {}
+
+// Pos returns the byte offset of the node in the parsed query
+func (q *Query) Pos() int { return q.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (f *FieldRef) Pos() int { return f.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (f *FunctionCall) Pos() int { return f.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (s *SubqueryItem) Pos() int { return s.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (t *TypeOfItem) Pos() int { return t.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (w *TypeOfWhen) Pos() int { return w.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (o *OrderItem) Pos() int { return o.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (g *GroupBy) Pos() int { return g.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (e *LogicalExpr) Pos() int { return e.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (e *NotExpr) Pos() int { return e.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (e *ParenExpr) Pos() int { return e.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (e *ComparisonExpr) Pos() int { return e.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *StringLiteral) Pos() int { return v.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *NumberLiteral) Pos() int { return v.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *BooleanLiteral) Pos() int { return v.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *NullLiteral) Pos() int { return v.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *DateTimeLiteral) Pos() int { return v.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *RelativeDateLiteral) Pos() int { return v.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *ListValue) Pos() int { return v.Position }
+
+// Pos returns the byte offset of the node in the parsed query
+func (v *SubqueryValue) Pos() int { return v.Position }
+
+func nodeString(n Node) string {
+	var buff strings.Builder
+	n.writeTo(&buff)
+	return buff.String()
+}
+
+// String returns the SOQL text of the query. Queries generated by Marshal are returned unchanged
+// after being parsed.
+func (q *Query) String() string { return nodeString(q) }
+
+// String returns the SOQL text of the node
+func (f *FieldRef) String() string { return nodeString(f) }
+
+// String returns the SOQL text of the node
+func (f *FunctionCall) String() string { return nodeString(f) }
+
+// String returns the SOQL text of the node
+func (s *SubqueryItem) String() string { return nodeString(s) }
+
+// String returns the SOQL text of the node
+func (t *TypeOfItem) String() string { return nodeString(t) }
+
+// String returns the SOQL text of the node
+func (w *TypeOfWhen) String() string { return nodeString(w) }
+
+// String returns the SOQL text of the node
+func (o *OrderItem) String() string { return nodeString(o) }
+
+// String returns the SOQL text of the node
+func (g *GroupBy) String() string { return nodeString(g) }
+
+// String returns the SOQL text of the node
+func (e *LogicalExpr) String() string { return nodeString(e) }
+
+// String returns the SOQL text of the node
+func (e *NotExpr) String() string { return nodeString(e) }
+
+// String returns the SOQL text of the node
+func (e *ParenExpr) String() string { return nodeString(e) }
+
+// String returns the SOQL text of the node
+func (e *ComparisonExpr) String() string { return nodeString(e) }
+
+// String returns the SOQL text of the node
+func (v *StringLiteral) String() string { return nodeString(v) }
+
+// String returns the SOQL text of the node
+func (v *NumberLiteral) String() string { return nodeString(v) }
+
+// String returns the SOQL text of the node
+func (v *BooleanLiteral) String() string { return nodeString(v) }
+
+// String returns the SOQL text of the node
+func (v *NullLiteral) String() string { return nodeString(v) }
+
+// String returns the SOQL text of the node
+func (v *DateTimeLiteral) String() string { return nodeString(v) }
+
+// String returns the SOQL text of the node
+func (v *RelativeDateLiteral) String() string { return nodeString(v) }
+
+// String returns the SOQL text of the node
+func (v *ListValue) String() string { return nodeString(v) }
+
+// String returns the SOQL text of the node
+func (v *SubqueryValue) String() string { return nodeString(v) }
+
+func (q *Query) writeTo(buff *strings.Builder) {
+	buff.WriteString(selectKeyword)
+	for indx, item := range q.Fields {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		item.writeTo(buff)
+	}
+	buff.WriteString(fromKeyword)
+	q.From.writeTo(buff)
+	if q.Scope != "" {
+		buff.WriteString(usingScopeKeyword)
+		buff.WriteString(q.Scope)
+	}
+	if q.Where != nil {
+		buff.WriteString(whereKeyword)
+		q.Where.writeTo(buff)
+	}
+	if q.With != "" {
+		buff.WriteString(withKeyword)
+		buff.WriteString(q.With)
+	}
+	if q.GroupBy != nil {
+		buff.WriteString(groupByKeyword)
+		q.GroupBy.writeTo(buff)
+	}
+	if q.Having != nil {
+		buff.WriteString(havingKeyword)
+		q.Having.writeTo(buff)
+	}
+	if len(q.OrderBy) > 0 {
+		buff.WriteString(orderByKeyword)
+		for indx, item := range q.OrderBy {
+			if indx > 0 {
+				buff.WriteString(comma)
+			}
+			item.writeTo(buff)
+		}
+	}
+	if q.Limit != nil {
+		buff.WriteString(limitKeyword)
+		buff.WriteString(strconv.Itoa(*q.Limit))
+	}
+	if q.Offset != nil {
+		buff.WriteString(offsetKeyword)
+		buff.WriteString(strconv.Itoa(*q.Offset))
+	}
+	if q.For != "" {
+		buff.WriteString(forKeyword)
+		buff.WriteString(q.For)
+	}
+}
+
+func (f *FieldRef) writeTo(buff *strings.Builder) {
+	buff.WriteString(f.Name)
+}
+
+func (f *FunctionCall) writeTo(buff *strings.Builder) {
+	buff.WriteString(f.Name)
+	buff.WriteString(openBrace)
+	for indx, arg := range f.Args {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		arg.writeTo(buff)
+	}
+	buff.WriteString(closeBrace)
+	if f.Alias != "" {
+		buff.WriteString(space)
+		buff.WriteString(f.Alias)
+	}
+}
+
+func (s *SubqueryItem) writeTo(buff *strings.Builder) {
+	buff.WriteString(openBrace)
+	s.Query.writeTo(buff)
+	buff.WriteString(closeBrace)
+}
+
+func (t *TypeOfItem) writeTo(buff *strings.Builder) {
+	buff.WriteString(typeOfKeyword)
+	t.Field.writeTo(buff)
+	for _, when := range t.Whens {
+		buff.WriteString(space)
+		when.writeTo(buff)
+	}
+	if t.Else != nil {
+		buff.WriteString(elseKeyword)
+		writeFieldRefs(buff, t.Else)
+	}
+	buff.WriteString(endKeyword)
+}
+
+func (w *TypeOfWhen) writeTo(buff *strings.Builder) {
+	buff.WriteString(whenKeyword)
+	buff.WriteString(w.Type)
+	buff.WriteString(thenKeyword)
+	writeFieldRefs(buff, w.Fields)
+}
+
+func writeFieldRefs(buff *strings.Builder, fields []*FieldRef) {
+	for indx, field := range fields {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		field.writeTo(buff)
+	}
+}
+
+func (o *OrderItem) writeTo(buff *strings.Builder) {
+	o.Field.writeTo(buff)
+	if o.Direction != "" {
+		buff.WriteString(" ")
+		buff.WriteString(o.Direction)
+	}
+	if o.Nulls != "" {
+		buff.WriteString(" NULLS ")
+		buff.WriteString(o.Nulls)
+	}
+}
+
+func (g *GroupBy) writeTo(buff *strings.Builder) {
+	if g.Type != "" {
+		buff.WriteString(g.Type)
+		buff.WriteString(openBrace)
+	}
+	for indx, field := range g.Fields {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		field.writeTo(buff)
+	}
+	if g.Type != "" {
+		buff.WriteString(closeBrace)
+	}
+}
+
+func (e *LogicalExpr) writeTo(buff *strings.Builder) {
+	for indx, operand := range e.Operands {
+		if indx > 0 {
+			buff.WriteString(" ")
+			buff.WriteString(e.Operator)
+			buff.WriteString(" ")
+		}
+		operand.writeTo(buff)
+	}
+}
+
+func (e *NotExpr) writeTo(buff *strings.Builder) {
+	buff.WriteString(notOperator)
+	e.Operand.writeTo(buff)
+}
+
+func (e *ParenExpr) writeTo(buff *strings.Builder) {
+	buff.WriteString(openBrace)
+	e.Inner.writeTo(buff)
+	buff.WriteString(closeBrace)
+}
+
+func (e *ComparisonExpr) writeTo(buff *strings.Builder) {
+	e.Field.writeTo(buff)
+	buff.WriteString(" ")
+	buff.WriteString(e.Operator)
+	buff.WriteString(" ")
+	e.Value.writeTo(buff)
+}
+
+func (v *StringLiteral) writeTo(buff *strings.Builder) {
+	buff.WriteString(singleQuote)
+	buff.WriteString(v.Raw)
+	buff.WriteString(singleQuote)
+}
+
+func (v *NumberLiteral) writeTo(buff *strings.Builder) {
+	buff.WriteString(v.Raw)
+}
+
+func (v *BooleanLiteral) writeTo(buff *strings.Builder) {
+	buff.WriteString(strconv.FormatBool(v.Value))
+}
+
+func (v *NullLiteral) writeTo(buff *strings.Builder) {
+	buff.WriteString(null)
+}
+
+func (v *DateTimeLiteral) writeTo(buff *strings.Builder) {
+	buff.WriteString(v.Raw)
+}
+
+func (v *RelativeDateLiteral) writeTo(buff *strings.Builder) {
+	buff.WriteString(v.Name)
+	if v.N != nil {
+		buff.WriteString(":")
+		buff.WriteString(strconv.Itoa(*v.N))
+	}
+}
+
+func (v *ListValue) writeTo(buff *strings.Builder) {
+	buff.WriteString(openBrace)
+	for indx, item := range v.Values {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		item.writeTo(buff)
+	}
+	buff.WriteString(closeBrace)
+}
+
+func (v *SubqueryValue) writeTo(buff *strings.Builder) {
+	buff.WriteString(openBrace)
+	v.Query.writeTo(buff)
+	buff.WriteString(closeBrace)
+}
+
+var unescapeReplacer = strings.NewReplacer(
+	safeSingleQuote, singleQuote,
+	safeDoubleQuote, doubleQuote,
+	safeBackslash, backslash,
+	safeNewLine, newLine,
+	safeCarriageReturn, carriageReturn,
+	safeTab, tab,
+	safeBell, bell,
+	safeFormFeed, formFeed,
+)
+
+// Value returns the string with escape sequences resolved. The LIKE wildcard escapes \_ and \% are
+// left as is since their meaning depends on the operator the string is used with.
+func (v *StringLiteral) Value() string {
+	return unescapeReplacer.Replace(v.Raw)
+}
+
+// Time parses the literal as dateTime, or as date if it has no time part
+func (v *DateTimeLiteral) Time() (time.Time, error) {
+	if len(v.Raw) == len(dateFormat) {
+		return time.Parse(dateFormat, v.Raw)
+	}
+	return parseTime(v.Raw, nil)
+}
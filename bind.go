@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnknownBindParameter error is returned by Bind when there is no value for a placeholder in the query
+	ErrUnknownBindParameter = errors.New("ErrUnknownBindParameter")
+
+	// ErrUnusedBindParameter error is returned by Bind when a value is not used by any placeholder in the query
+	ErrUnusedBindParameter = errors.New("ErrUnusedBindParameter")
+
+	// ErrInvalidBindParameter error is returned by Bind when a value has unsupported type or is an empty slice
+	ErrInvalidBindParameter = errors.New("ErrInvalidBindParameter")
+)
+
+// BindError is returned by Bind when a parameter is unknown, unused or has invalid value
+type BindError struct {
+	// Name is the name of the parameter without colon
+	Name string
+	// Offset is the byte offset of the placeholder in the query. It is -1 for unused parameters
+	Offset int
+	// Err is one of ErrUnknownBindParameter, ErrUnusedBindParameter, ErrInvalidBindParameter and
+	// ErrInvalidDateLiteral
+	Err error
+}
+
+func (e *BindError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("%s: %s", e.Err, e.Name)
+	}
+	return fmt.Sprintf("%s: %s at offset %d", e.Err, e.Name, e.Offset)
+}
+
+// Unwrap returns the sentinel error so that BindError can be used with errors.Is
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// Bind replaces the named placeholders in query, e.g. :accountIds, with the values of params. Values are
+// written the same way as by Marshal: strings are quoted and escaped, time.Time is formatted with
+// DateTimeFormat, DateLiteral is written as is and nil pointers as null. Slices supported by inOperator are
+// written as lists, so the placeholder should follow IN or NOT IN.
+// Colons within string literals and date literals like LAST_N_DAYS:5 are not placeholders. Every placeholder
+// must have a value and every value must be used, otherwise *BindError is returned. *SyntaxError is returned
+// if the query cannot be tokenized.
+// query, err := Bind("SELECT Id FROM Account WHERE Id IN :accountIds AND CreatedDate > :since", map[string]interface{}{
+// 	"accountIds": []string{"001D000000IqhSL", "001D000000IqhSM"},
+// 	"since":      time.Date(2019, 3, 1, 17, 43, 53, 0, time.UTC),
+// })
+// if err != nil {
+//		log.Warn("Error in binding query")
+// }
+// fmt.Println(query)
+// This will print query as:
+// SELECT Id FROM Account WHERE Id IN ('001D000000IqhSL','001D000000IqhSM') AND CreatedDate > 2019-03-01T17:43:53.000+0000
+func Bind(query string, params map[string]interface{}) (string, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return "", err
+	}
+	var buff strings.Builder
+	used := make(map[string]bool)
+	last := 0
+	for indx := 0; indx+1 < len(tokens); indx++ {
+		colon, name := tokens[indx], tokens[indx+1]
+		// Placeholder is a colon directly followed by the name. Colons of date literals like LAST_N_DAYS:5
+		// are followed by numbers.
+		if colon.kind != tokenColon || name.kind != tokenIdent || name.pos != colon.pos+1 {
+			continue
+		}
+		value, ok := params[name.text]
+		if !ok {
+			return "", &BindError{Name: name.text, Offset: colon.pos, Err: ErrUnknownBindParameter}
+		}
+		literal, err := formatBindValue(value)
+		if err != nil {
+			return "", &BindError{Name: name.text, Offset: colon.pos, Err: err}
+		}
+		used[name.text] = true
+		buff.WriteString(query[last:colon.pos])
+		buff.WriteString(literal)
+		last = name.pos + len(name.text)
+		indx++
+	}
+	buff.WriteString(query[last:])
+
+	var unused []string
+	for name := range params {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		// Report the same parameter regardless of the order of iteration over params
+		sort.Strings(unused)
+		return "", &BindError{Name: unused[0], Offset: -1, Err: ErrUnusedBindParameter}
+	}
+	return buff.String(), nil
+}
+
+// formatBindValue returns value as it is written in place of a placeholder
+func formatBindValue(value interface{}) (string, error) {
+	items, useSingleQuotes, err := formatContainsValues(value, nil)
+	if err == nil {
+		if len(items) == 0 {
+			return "", ErrInvalidBindParameter
+		}
+		var buff strings.Builder
+		buff.WriteString(openBrace)
+		for indx, item := range items {
+			if indx > 0 {
+				buff.WriteString(comma)
+			}
+			buff.WriteString(quoteValue(item, useSingleQuotes))
+		}
+		buff.WriteString(closeBrace)
+		return buff.String(), nil
+	}
+	if err != ErrInvalidTag {
+		return "", err
+	}
+	literal, useSingleQuotes, err := formatComparisonValue(value, nil)
+	if err == ErrInvalidTag {
+		return "", ErrInvalidBindParameter
+	}
+	if err != nil {
+		return "", err
+	}
+	if literal == "" && !useSingleQuotes {
+		return null, nil
+	}
+	return quoteValue(literal, useSingleQuotes), nil
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var _ = Describe("Bind", func() {
+	var (
+		query       string
+		params      map[string]interface{}
+		actualQuery string
+		err         error
+	)
+
+	JustBeforeEach(func() {
+		actualQuery, err = soql.Bind(query, params)
+	})
+
+	Context("when all placeholders have values", func() {
+		BeforeEach(func() {
+			var retiredDate *time.Time
+			numOfCPUCores := 16
+			query = "SELECT Id FROM SM_Logical_Host__c WHERE Id IN :ids AND (Name = :name OR Num_of_CPU_Cores__c >= :cores) AND CreatedDate > :since AND Retired_Date__c = :retired AND Tier__c NOT IN :tiers AND LastModifiedDate = :modified"
+			params = map[string]interface{}{
+				"ids":      []string{"a01", "a0'2"},
+				"name":     "db\\01",
+				"cores":    &numOfCPUCores,
+				"since":    time.Date(2019, 3, 1, 17, 43, 53, 0, time.UTC),
+				"retired":  retiredDate,
+				"tiers":    []int{1, 2},
+				"modified": soql.LastNDays.N(5),
+			}
+		})
+
+		It("does not modify the values", func() {
+			Expect(params["ids"]).To(Equal([]string{"a01", "a0'2"}))
+		})
+
+		It("replaces them with values written the same way as by Marshal", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal("SELECT Id FROM SM_Logical_Host__c WHERE Id IN ('a01','a0\\'2') AND (Name = 'db\\\\01' OR Num_of_CPU_Cores__c >= 16) AND CreatedDate > 2019-03-01T17:43:53.000+0000 AND Retired_Date__c = null AND Tier__c NOT IN (1,2) AND LastModifiedDate = LAST_N_DAYS:5"))
+		})
+	})
+
+	Context("when values are of custom types", func() {
+		BeforeEach(func() {
+			var maxAmount *Amount
+			query = "SELECT Id FROM Opportunity WHERE Host__c IN :hosts AND Priority = :priority AND Amount < :max AND Region__c = :region AND CloseDate = :day"
+			params = map[string]interface{}{
+				"hosts":    []HostID{"a01", "a02"},
+				"priority": Priority(1),
+				"max":      maxAmount,
+				"region":   Region{Code: "emea"},
+				"day":      Day{Year: 2020, Month: time.March, Day: 5},
+			}
+		})
+
+		It("replaces them with values written the same way as by Marshal", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal("SELECT Id FROM Opportunity WHERE Host__c IN ('a01','a02') AND Priority = 'Medium' AND Amount < null AND Region__c = 'EMEA' AND CloseDate = 2020-03-05"))
+		})
+	})
+
+	Context("when query has colons that are not placeholders", func() {
+		BeforeEach(func() {
+			query = "SELECT Id FROM Account WHERE Name = ':name' AND CreatedDate = LAST_N_DAYS:5 AND LastModifiedDate > 2019-03-01T17:43:53Z AND Owner.Name=:owner"
+			params = map[string]interface{}{"owner": "it's me"}
+		})
+
+		It("only replaces the placeholders", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal("SELECT Id FROM Account WHERE Name = ':name' AND CreatedDate = LAST_N_DAYS:5 AND LastModifiedDate > 2019-03-01T17:43:53Z AND Owner.Name='it\\'s me'"))
+		})
+	})
+
+	Context("when placeholder has no value", func() {
+		BeforeEach(func() {
+			query = "SELECT Id FROM Account WHERE Id = :id AND Name = :name"
+			params = map[string]interface{}{"id": "001"}
+		})
+
+		It("returns ErrUnknownBindParameter error", func() {
+			Expect(errors.Is(err, soql.ErrUnknownBindParameter)).To(BeTrue())
+			Expect(err).To(Equal(&soql.BindError{Name: "name", Offset: 49, Err: soql.ErrUnknownBindParameter}))
+		})
+	})
+
+	Context("when value is not used", func() {
+		BeforeEach(func() {
+			query = "SELECT Id FROM Account WHERE Id = :id"
+			params = map[string]interface{}{"id": "001", "name": "Acme", "active": true}
+		})
+
+		It("returns ErrUnusedBindParameter error", func() {
+			Expect(err).To(Equal(&soql.BindError{Name: "active", Offset: -1, Err: soql.ErrUnusedBindParameter}))
+			Expect(err.Error()).To(Equal("ErrUnusedBindParameter: active"))
+		})
+	})
+
+	Context("when value has unsupported type", func() {
+		BeforeEach(func() {
+			query = "SELECT Id FROM Account WHERE Id = :id"
+			params = map[string]interface{}{"id": map[string]string{}}
+		})
+
+		It("returns ErrInvalidBindParameter error", func() {
+			Expect(errors.Is(err, soql.ErrInvalidBindParameter)).To(BeTrue())
+		})
+	})
+
+	Context("when slice is empty", func() {
+		BeforeEach(func() {
+			query = "SELECT Id FROM Account WHERE Id IN :ids"
+			params = map[string]interface{}{"ids": []string{}}
+		})
+
+		It("returns ErrInvalidBindParameter error", func() {
+			Expect(errors.Is(err, soql.ErrInvalidBindParameter)).To(BeTrue())
+		})
+	})
+
+	Context("when date literal is invalid", func() {
+		BeforeEach(func() {
+			query = "SELECT Id FROM Account WHERE CreatedDate = :created"
+			params = map[string]interface{}{"created": soql.DateLiteral("SOMEDAY")}
+		})
+
+		It("returns ErrInvalidDateLiteral error", func() {
+			Expect(errors.Is(err, soql.ErrInvalidDateLiteral)).To(BeTrue())
+		})
+	})
+
+	Context("when query has unterminated string", func() {
+		BeforeEach(func() {
+			query = "SELECT Id FROM Account WHERE Name = 'Acme"
+			params = nil
+		})
+
+		It("returns SyntaxError", func() {
+			var syntaxErr *soql.SyntaxError
+			Expect(errors.As(err, &syntaxErr)).To(BeTrue())
+		})
+	})
+})
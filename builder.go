@@ -0,0 +1,373 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"strings"
+)
+
+// Condition is a condition in where clause of a query built with Select. Conditions are created with
+// Eq, In, Like and the other operator functions and combined with And, Or and Not.
+type Condition interface {
+	marshal() (string, error)
+}
+
+// FieldCondition is a condition that compares a field with a value using one of the operators supported
+// in whereClause structs
+type FieldCondition struct {
+	operator  string
+	fieldName string
+	value     interface{}
+	tags      map[string]string
+}
+
+// WithFormat sets the format used for time.Time values, similar to format parameter of soql tag
+func (c *FieldCondition) WithFormat(format string) *FieldCondition {
+	c.tags[Format] = format
+	return c
+}
+
+func (c *FieldCondition) marshal() (string, error) {
+	fn, ok := clauseBuilderMap[c.operator]
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	return fn(c.value, c.fieldName, c.tags)
+}
+
+// Operator returns condition for fieldName using operator, which is any of the operator tags that can be used
+// in whereClause structs like EqualsOperator or GreaterNextNDaysOperator. value should be of the type that the
+// operator tag accepts.
+func Operator(operator string, fieldName string, value interface{}) *FieldCondition {
+	return &FieldCondition{
+		operator:  operator,
+		fieldName: fieldName,
+		value:     value,
+		tags:      map[string]string{},
+	}
+}
+
+// Like returns condition matching fieldName with any of the patterns, same as likeOperator tag
+func Like(fieldName string, patterns ...string) *FieldCondition {
+	return Operator(LikeOperator, fieldName, patterns)
+}
+
+// NotLike returns condition matching fieldName with none of the patterns, same as notLikeOperator tag
+func NotLike(fieldName string, patterns ...string) *FieldCondition {
+	return Operator(NotLikeOperator, fieldName, patterns)
+}
+
+// In returns condition for fieldName being one of values, same as inOperator tag. values should be a slice.
+func In(fieldName string, values interface{}) *FieldCondition {
+	return Operator(InOperator, fieldName, values)
+}
+
+// NotIn returns condition for fieldName not being any of values, same as notInOperator tag. values should be a slice.
+func NotIn(fieldName string, values interface{}) *FieldCondition {
+	return Operator(NotInOperator, fieldName, values)
+}
+
+// Includes returns condition for multi-select picklist fieldName including any of values, same as
+// includesOperator tag. values should be []string or [][]string.
+func Includes(fieldName string, values interface{}) *FieldCondition {
+	return Operator(IncludesOperator, fieldName, values)
+}
+
+// Excludes returns condition for multi-select picklist fieldName excluding all of values, same as
+// excludesOperator tag. values should be []string or [][]string.
+func Excludes(fieldName string, values interface{}) *FieldCondition {
+	return Operator(ExcludesOperator, fieldName, values)
+}
+
+// Eq returns condition for fieldName being equal to value, same as equalsOperator tag
+func Eq(fieldName string, value interface{}) *FieldCondition {
+	return Operator(EqualsOperator, fieldName, value)
+}
+
+// NotEq returns condition for fieldName not being equal to value, same as notEqualsOperator tag
+func NotEq(fieldName string, value interface{}) *FieldCondition {
+	return Operator(NotEqualsOperator, fieldName, value)
+}
+
+// Gt returns condition for fieldName being greater than value, same as greaterThanOperator tag
+func Gt(fieldName string, value interface{}) *FieldCondition {
+	return Operator(GreaterThanOperator, fieldName, value)
+}
+
+// Gte returns condition for fieldName being greater than or equal to value, same as greaterThanOrEqualsToOperator tag
+func Gte(fieldName string, value interface{}) *FieldCondition {
+	return Operator(GreaterThanOrEqualsToOperator, fieldName, value)
+}
+
+// Lt returns condition for fieldName being less than value, same as lessThanOperator tag
+func Lt(fieldName string, value interface{}) *FieldCondition {
+	return Operator(LessThanOperator, fieldName, value)
+}
+
+// Lte returns condition for fieldName being less than or equal to value, same as lessThanOrEqualsToOperator tag
+func Lte(fieldName string, value interface{}) *FieldCondition {
+	return Operator(LessThanOrEqualsToOperator, fieldName, value)
+}
+
+// IsNull returns condition for fieldName being null, same as nullOperator tag with true value
+func IsNull(fieldName string) *FieldCondition {
+	return Operator(NullOperator, fieldName, true)
+}
+
+// IsNotNull returns condition for fieldName not being null, same as nullOperator tag with false value
+func IsNotNull(fieldName string) *FieldCondition {
+	return Operator(NullOperator, fieldName, false)
+}
+
+type queryCondition struct {
+	fieldName string
+	operator  string
+	query     *QueryBuilder
+}
+
+func (c *queryCondition) marshal() (string, error) {
+	if c.fieldName == "" || c.query == nil {
+		return "", ErrInvalidTag
+	}
+	partialJoinQuery, err := c.query.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return c.fieldName + c.operator + openBrace + partialJoinQuery + closeBrace, nil
+}
+
+// InQuery returns condition for fieldName being one of the values returned by query, same as subquery tag
+// with joiner=in
+func InQuery(fieldName string, query *QueryBuilder) Condition {
+	return &queryCondition{fieldName: fieldName, operator: inOperator, query: query}
+}
+
+// NotInQuery returns condition for fieldName not being any of the values returned by query, same as
+// subquery tag with joiner=not in
+func NotInQuery(fieldName string, query *QueryBuilder) Condition {
+	return &queryCondition{fieldName: fieldName, operator: notInOperator, query: query}
+}
+
+type groupCondition struct {
+	joiner     string
+	conditions []Condition
+}
+
+func (c *groupCondition) marshal() (string, error) {
+	var buff strings.Builder
+	previousConditionExists := false
+	for _, condition := range c.conditions {
+		if condition == nil {
+			continue
+		}
+		partialClause, err := condition.marshal()
+		if err != nil {
+			return "", err
+		}
+		if partialClause == "" {
+			continue
+		}
+		if _, ok := condition.(*groupCondition); ok {
+			partialClause = openBrace + partialClause + closeBrace
+		}
+		if previousConditionExists {
+			buff.WriteString(c.joiner)
+		}
+		buff.WriteString(partialClause)
+		previousConditionExists = true
+	}
+	return buff.String(), nil
+}
+
+// And returns condition combining conditions using AND logical operator. Nested And and Or conditions are
+// wrapped in parentheses, same as subquery tag.
+func And(conditions ...Condition) Condition {
+	return &groupCondition{joiner: andCondition, conditions: conditions}
+}
+
+// Or returns condition combining conditions using OR logical operator. Nested And and Or conditions are
+// wrapped in parentheses, same as subquery tag.
+func Or(conditions ...Condition) Condition {
+	return &groupCondition{joiner: orCondition, conditions: conditions}
+}
+
+type notCondition struct {
+	condition Condition
+}
+
+func (c *notCondition) marshal() (string, error) {
+	if c.condition == nil {
+		return "", nil
+	}
+	partialClause, err := c.condition.marshal()
+	if err != nil || partialClause == "" {
+		return partialClause, err
+	}
+	if _, ok := c.condition.(*groupCondition); ok {
+		partialClause = openBrace + partialClause + closeBrace
+	}
+	return openBrace + notOperator + partialClause + closeBrace, nil
+}
+
+// Not returns condition negating condition
+func Not(condition Condition) Condition {
+	return &notCondition{condition: condition}
+}
+
+// QueryBuilder builds SOQL query programmatically. It is created using Select and is useful for queries
+// assembled at runtime that cannot be expressed as a fixed struct for Marshal. Values are escaped the
+// same way as by Marshal.
+type QueryBuilder struct {
+	columns   []builderColumn
+	tableName string
+	where     Condition
+	orderBy   []Order
+	limit     *int
+	offset    *int
+}
+
+// Select starts building a query selecting columns.
+// query, err := Select("Id", "Name__c").
+// 	From("SM_Logical_Host__c").
+// 	Where(And(Like("Host_Name__c", "-db", "-dbmgmt"), In("Role__r.Name", []string{"db"}))).
+// 	OrderBy(Order{Field: "Name__c", IsDesc: true}).
+// 	Limit(5).
+// 	Marshal()
+// if err != nil {
+//		log.Warn("Error in building soql")
+// }
+// fmt.Println(query)
+// This will print soql query as:
+// SELECT Id,Name__c FROM SM_Logical_Host__c WHERE (Host_Name__c LIKE '%-db%' OR Host_Name__c LIKE '%-dbmgmt%') AND Role__r.Name IN ('db') ORDER BY Name__c DESC LIMIT 5
+func Select(columns ...string) *QueryBuilder {
+	return (&QueryBuilder{}).Columns(columns...)
+}
+
+// builderColumn is either a column or a child relationship subquery in select clause
+type builderColumn struct {
+	name  string
+	child *QueryBuilder
+}
+
+// Columns adds columns to the select clause
+func (b *QueryBuilder) Columns(columns ...string) *QueryBuilder {
+	for _, column := range columns {
+		b.columns = append(b.columns, builderColumn{name: column})
+	}
+	return b
+}
+
+// SelectChild adds a child relationship subquery to the select clause. Table passed to From of child
+// should be the name of the child relationship, e.g. Application_Versions__r.
+func (b *QueryBuilder) SelectChild(child *QueryBuilder) *QueryBuilder {
+	b.columns = append(b.columns, builderColumn{child: child})
+	return b
+}
+
+// From sets the name of the table (Salesforce object) to query
+func (b *QueryBuilder) From(tableName string) *QueryBuilder {
+	b.tableName = tableName
+	return b
+}
+
+// Where sets the condition of where clause
+func (b *QueryBuilder) Where(condition Condition) *QueryBuilder {
+	b.where = condition
+	return b
+}
+
+// OrderBy adds columns to order by clause. Field of Order is the name of the column.
+func (b *QueryBuilder) OrderBy(orders ...Order) *QueryBuilder {
+	b.orderBy = append(b.orderBy, orders...)
+	return b
+}
+
+// Limit sets the limit of the query
+func (b *QueryBuilder) Limit(limit int) *QueryBuilder {
+	b.limit = &limit
+	return b
+}
+
+// Offset sets the offset of the query
+func (b *QueryBuilder) Offset(offset int) *QueryBuilder {
+	b.offset = &offset
+	return b
+}
+
+// Marshal returns the SOQL query. It returns ErrNoSelectClause if there are no columns or table name,
+// ErrInvalidTag for invalid conditions and the same errors as Marshal for invalid order by, limit and offset.
+func (b *QueryBuilder) Marshal() (string, error) {
+	if len(b.columns) == 0 || b.tableName == "" {
+		return "", ErrNoSelectClause
+	}
+	var buff strings.Builder
+	buff.WriteString(selectKeyword)
+	for indx, column := range b.columns {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		if column.child == nil {
+			if column.name == "" {
+				return "", ErrInvalidTag
+			}
+			buff.WriteString(column.name)
+			continue
+		}
+		subStr, err := column.child.Marshal()
+		if err != nil {
+			return "", err
+		}
+		buff.WriteString(openBrace)
+		buff.WriteString(subStr)
+		buff.WriteString(closeBrace)
+	}
+	buff.WriteString(fromKeyword)
+	buff.WriteString(b.tableName)
+	if b.where != nil {
+		subStr, err := b.where.marshal()
+		if err != nil {
+			return "", err
+		}
+		if subStr != "" {
+			buff.WriteString(whereKeyword)
+			buff.WriteString(subStr)
+		}
+	}
+	for indx, order := range b.orderBy {
+		if strings.TrimSpace(order.Field) == "" {
+			return "", ErrInvalidOrderByClause
+		}
+		if indx == 0 {
+			buff.WriteString(orderByKeyword)
+		} else {
+			buff.WriteString(comma)
+		}
+		buff.WriteString(order.Field)
+		if order.IsDesc {
+			buff.WriteString(descKeyword)
+		} else {
+			buff.WriteString(ascKeyword)
+		}
+	}
+	if b.limit != nil {
+		subStr, err := marshalLimitClause(b.limit)
+		if err != nil {
+			return "", err
+		}
+		buff.WriteString(limitKeyword)
+		buff.WriteString(subStr)
+	}
+	if b.offset != nil {
+		subStr, err := marshalOffsetClause(b.offset)
+		if err != nil {
+			return "", err
+		}
+		buff.WriteString(offsetKeyword)
+		buff.WriteString(subStr)
+	}
+	return buff.String(), nil
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var _ = Describe("Builder", func() {
+	var (
+		builder     *soql.QueryBuilder
+		actualQuery string
+		err         error
+	)
+
+	JustBeforeEach(func() {
+		actualQuery, err = builder.Marshal()
+	})
+
+	Context("when query is equivalent to a soql struct", func() {
+		var expectedQuery string
+
+		BeforeEach(func() {
+			allowNull := false
+			limit := 15
+			offset := 5
+			expectedQuery, err = soql.Marshal(TestSoqlLimitAndOffsetStruct{
+				WhereClause: TestQueryCriteria{
+					IncludeNamePattern:          []string{"-db", "-dbm_gmt%"},
+					Roles:                       []string{"db", "db'mgmt"},
+					ExcludeNamePattern:          []string{"-core", "-drp"},
+					AssetType:                   "SER\\VER",
+					Status:                      "InActive",
+					AllowNullLastDiscoveredDate: &allowNull,
+					ExcludeIDs:                  []string{"123", "456"},
+				},
+				Limit:  &limit,
+				Offset: &offset,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			builder = soql.Select("Id", "Name__c", "NonNestedStruct__r.Name", "NonNestedStruct__r.SomeValue__c").
+				From("SM_Logical_Host__c").
+				Where(soql.And(
+					soql.Like("Host_Name__c", "-db", "-dbm_gmt%"),
+					soql.In("Role__r.Name", []string{"db", "db'mgmt"}),
+					soql.NotLike("Host_Name__c", "-core", "-drp"),
+					soql.Eq("Tech_Asset__r.Asset_Type_Asset_Type__c", "SER\\VER"),
+					soql.NotEq("Status__c", "InActive"),
+					soql.IsNotNull("Last_Discovered_Date__c"),
+					soql.NotIn("id", []string{"123", "456"}),
+				)).
+				Limit(limit).
+				Offset(offset)
+		})
+
+		It("returns the same query as Marshal", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal(expectedQuery))
+		})
+	})
+
+	Context("when conditions are nested", func() {
+		var expectedQuery string
+
+		BeforeEach(func() {
+			expectedQuery, err = soql.Marshal(soqlSubQueryTestStruct{
+				WhereClause: queryCriteria{
+					Position: positionCriteria{
+						Title: "Purchasing Manager",
+						DepartmentManager: deptManagerCriteria{
+							Department: "Accounting",
+							Title:      []string{"Manager"},
+						},
+					},
+					Contactable: contactableCriteria{
+						EmailOK: emailCheck{},
+						PhoneOK: phoneCheck{},
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			builder = soql.Select("Name", "Email", "Phone").
+				From("Contact").
+				Where(soql.And(
+					soql.Or(
+						soql.Eq("Title", "Purchasing Manager"),
+						soql.And(soql.Eq("Department", "Accounting"), soql.Like("Title", "Manager")),
+					),
+					soql.Or(
+						soql.And(soql.IsNotNull("Email"), soql.Eq("HasOptedOutOfEmail", false)),
+						soql.And(soql.IsNotNull("Phone"), soql.Eq("DoNotCall", false)),
+					),
+				))
+		})
+
+		It("wraps nested groups in parentheses the same way as subquery tag", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal(expectedQuery))
+		})
+	})
+
+	Context("when there are semi join conditions and child relationships", func() {
+		BeforeEach(func() {
+			builder = soql.Select("Id").
+				SelectChild(soql.Select("Version__c").From("Application_Versions__r").OrderBy(soql.Order{Field: "Version__c", IsDesc: true}).Limit(1)).
+				From("SM_Logical_Host__c").
+				Where(soql.Or(
+					soql.InQuery("Id", soql.Select("Host__c").From("Fraud").Where(soql.Eq("isFraud", true))),
+					soql.NotInQuery("Id", soql.Select("Host__c").From("Calls")),
+				)).
+				OrderBy(soql.Order{Field: "Id"})
+		})
+
+		It("returns properly constructed soql query", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal("SELECT Id,(SELECT Version__c FROM Application_Versions__r ORDER BY Version__c DESC LIMIT 1) FROM SM_Logical_Host__c WHERE Id IN (SELECT Host__c FROM Fraud WHERE isFraud = true) OR Id NOT IN (SELECT Host__c FROM Calls) ORDER BY Id ASC"))
+		})
+	})
+
+	Context("when conditions have no values", func() {
+		BeforeEach(func() {
+			var numOfCPUCores *int
+			builder = soql.Select("Id").
+				From("SM_Logical_Host__c").
+				Where(soql.And(soql.In("Role__r.Name", []string{}), soql.Or(soql.Gt("Num_of_CPU_Cores__c", numOfCPUCores)), soql.Not(soql.Like("Name"))))
+		})
+
+		It("omits where clause", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal("SELECT Id FROM SM_Logical_Host__c"))
+		})
+	})
+
+	Context("when conditions are negated and use other operators", func() {
+		BeforeEach(func() {
+			createdDate := time.Date(2019, 3, 1, 17, 43, 53, 0, time.UTC)
+			builder = soql.Select("Id").
+				From("SM_Logical_Host__c").
+				Where(soql.And(
+					soql.Not(soql.Or(soql.Lt("Num_of_CPU_Cores__c", 4), soql.Gte("Num_of_CPU_Cores__c", 64))),
+					soql.Lte("CreatedDate", createdDate).WithFormat("2006-01-02"),
+					soql.Gt("LastModifiedDate", createdDate),
+					soql.Operator(soql.GreaterNextNDaysOperator, "ClosedDate", 5),
+					soql.IsNull("Retired_Date__c"),
+					soql.Includes("Roles__c", [][]string{{"db", "app"}}),
+					soql.Excludes("Roles__c", []string{"web"}),
+				))
+		})
+
+		It("returns properly constructed soql query", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQuery).To(Equal("SELECT Id FROM SM_Logical_Host__c WHERE (NOT (Num_of_CPU_Cores__c < 4 OR Num_of_CPU_Cores__c >= 64)) AND CreatedDate <= 2019-03-01 AND LastModifiedDate > 2019-03-01T17:43:53.000+0000 AND ClosedDate > NEXT_N_DAYS:5 AND Retired_Date__c = null AND Roles__c INCLUDES ('db;app') AND Roles__c EXCLUDES ('web')"))
+		})
+	})
+
+	Context("when value has invalid type for operator", func() {
+		BeforeEach(func() {
+			builder = soql.Select("Id").From("SM_Logical_Host__c").Where(soql.In("Role__r.Name", "db"))
+		})
+
+		It("returns ErrInvalidTag error", func() {
+			Expect(err).To(Equal(soql.ErrInvalidTag))
+		})
+	})
+
+	Context("when operator is unknown", func() {
+		BeforeEach(func() {
+			builder = soql.Select("Id").From("SM_Logical_Host__c").Where(soql.Operator("approximatelyOperator", "Name", "db"))
+		})
+
+		It("returns ErrInvalidTag error", func() {
+			Expect(err).To(Equal(soql.ErrInvalidTag))
+		})
+	})
+
+	Context("when table name is missing", func() {
+		BeforeEach(func() {
+			builder = soql.Select("Id")
+		})
+
+		It("returns ErrNoSelectClause error", func() {
+			Expect(err).To(Equal(soql.ErrNoSelectClause))
+		})
+	})
+
+	Context("when limit is negative", func() {
+		BeforeEach(func() {
+			builder = soql.Select("Id").From("SM_Logical_Host__c").Limit(-1)
+		})
+
+		It("returns ErrInvalidLimitClause error", func() {
+			Expect(err).To(Equal(soql.ErrInvalidLimitClause))
+		})
+	})
+
+	Context("when order by field is empty", func() {
+		BeforeEach(func() {
+			builder = soql.Select("Id").From("SM_Logical_Host__c").OrderBy(soql.Order{})
+		})
+
+		It("returns ErrInvalidOrderByClause error", func() {
+			Expect(err).To(Equal(soql.ErrInvalidOrderByClause))
+		})
+	})
+})
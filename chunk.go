@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// MaxQueryLength is the maximum length of SOQL query accepted by Salesforce
+const MaxQueryLength = 100000
+
+// ErrQueryTooLong error is returned by MarshalChunked when the query cannot be split into queries that fit
+// into the length budget
+var ErrQueryTooLong = errors.New("ErrQueryTooLong")
+
+// inList is a member tagged with inOperator in where clause that can be split across queries
+type inList struct {
+	// path is the index sequence of the member starting from the struct passed to MarshalChunked
+	path   []int
+	values reflect.Value
+	// items are the values as they are written in the query
+	items []string
+}
+
+// MarshalChunked constructs the SOQL query like Marshal, but when the query is longer than maxLength it splits
+// the longest list of inOperator values in where clause into several queries, each of which is at most
+// maxLength long. Running all of them and merging their records returns the same records as the single query.
+// If maxLength is not positive, MaxQueryLength is used.
+// Only inOperator lists of whereClause struct and of its subquery groups are split. notInOperator lists and
+// the lists of groups with not option cannot be split, because records of such queries would have to be
+// intersected instead of merged. Lists of groups in slices are not split either. LIMIT, OFFSET,
+// ORDER BY and aggregate functions apply to each query separately. ErrQueryTooLong error is returned if the
+// query is still too long when the list has one value per query.
+// type TestQueryCriteria struct {
+// 	IDs []string `soql:"inOperator,fieldName=Id"`
+// }
+// queries, err := MarshalChunked(soqlStruct, 100000)
+// if err != nil {
+//		log.Warn("Error in marshaling soql")
+// }
+// for _, query := range queries {
+// 	// execute query and merge records
+// }
+func MarshalChunked(v interface{}, maxLength int) ([]string, error) {
+	if maxLength <= 0 {
+		maxLength = MaxQueryLength
+	}
+	query, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) <= maxLength {
+		return []string{query}, nil
+	}
+	reflectedValue, reflectedType, _ := getReflectedValueAndType(v)
+	tooLongErr := &MarshalError{Type: reflectedType, Err: ErrQueryTooLong}
+	list, ok := findLongestInList(reflectedValue, reflectedType)
+	if !ok {
+		return nil, tooLongErr
+	}
+
+	// Length of the query without the items of the list and commas between them
+	listLength := len(list.items) - 1
+	for _, item := range list.items {
+		listLength += len(item)
+	}
+	budget := maxLength - (len(query) - listLength)
+
+	var queries []string
+	start := 0
+	for start < len(list.items) {
+		end := start
+		chunkLength := -1
+		for end < len(list.items) && chunkLength+1+len(list.items[end]) <= budget {
+			chunkLength += 1 + len(list.items[end])
+			end++
+		}
+		if end == start {
+			return nil, tooLongErr
+		}
+		chunk := reflect.New(reflectedType).Elem()
+		chunk.Set(reflectedValue)
+		setFieldByPath(chunk, list.path, list.values.Slice(start, end))
+		chunkQuery, err := marshal(chunk, reflectedType, "")
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, chunkQuery)
+		start = end
+	}
+	return queries, nil
+}
+
+// findLongestInList returns the inOperator list with the longest representation in the where clause of soql
+// struct. It returns false if there is no list with at least two values.
+func findLongestInList(reflectedValue reflect.Value, reflectedType reflect.Type) (inList, bool) {
+	index, ok := getQueryPlan(reflectedType).indexes[WhereClause]
+	if !ok {
+		return inList{}, false
+	}
+	var longest inList
+	longestLength := 0
+	var walk func(val reflect.Value, path []int)
+	walk = func(val reflect.Value, path []int) {
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return
+			}
+			val = val.Elem()
+		}
+		for _, f := range getWherePlan(val.Type()) {
+			field := val.Field(f.index)
+			fieldPath := append(path[:len(path):len(path)], f.index)
+			if f.clauseKey == Subquery {
+				// Splitting lists of negated groups would return the records the query excludes, while
+				// lists of groups in slices are not reachable by the path of members
+				if !f.not && !f.isSlice && f.joiner != inOperator && f.joiner != notInOperator {
+					walk(field, fieldPath)
+				}
+				continue
+			}
+			if f.clauseKey != InOperator || field.Kind() != reflect.Slice || field.Len() < 2 {
+				continue
+			}
+			values, useSingleQuotes, err := formatContainsValues(field.Interface(), f.tags)
+			if err != nil {
+				continue
+			}
+			items := make([]string, 0, len(values))
+			length := 0
+			for _, value := range values {
+				item := quoteValue(value, useSingleQuotes)
+				items = append(items, item)
+				length += len(item)
+			}
+			if length > longestLength {
+				longest = inList{path: fieldPath, values: field, items: items}
+				longestLength = length
+			}
+		}
+	}
+	walk(reflectedValue.Field(index), []int{index})
+	return longest, longestLength > 0
+}
+
+// setFieldByPath sets the member at path of root, which must be addressable, to value. Structs referenced by
+// pointers along the path are copied, so that the value passed to MarshalChunked is not modified.
+func setFieldByPath(root reflect.Value, path []int, value reflect.Value) {
+	current := root
+	for _, index := range path[:len(path)-1] {
+		field := current.Field(index)
+		if field.Kind() == reflect.Ptr {
+			copied := reflect.New(field.Type().Elem())
+			copied.Elem().Set(field.Elem())
+			field.Set(copied)
+			field = copied.Elem()
+		}
+		current = field
+	}
+	current.Field(path[len(path)-1]).Set(value)
+}
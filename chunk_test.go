@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var _ = Describe("MarshalChunked", func() {
+	var (
+		soqlStruct    interface{}
+		maxLength     int
+		actualQueries []string
+		err           error
+	)
+
+	JustBeforeEach(func() {
+		actualQueries, err = soql.MarshalChunked(soqlStruct, maxLength)
+	})
+
+	Context("when query fits into max length", func() {
+		BeforeEach(func() {
+			soqlStruct = TestSoqlStruct{WhereClause: TestQueryCriteria{Roles: []string{"db", "app"}}}
+			maxLength = 0
+		})
+
+		It("returns the same query as Marshal", func() {
+			expectedQuery, _ := soql.Marshal(soqlStruct)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQueries).To(Equal([]string{expectedQuery}))
+		})
+	})
+
+	Context("when in list makes the query too long", func() {
+		var criteria *chunkedHostCriteria
+
+		BeforeEach(func() {
+			criteria = &chunkedHostCriteria{
+				IDs:   []string{"a01", "a02", "a03", "a04", "a05"},
+				Names: []string{"db-1", "db-2"},
+			}
+			soqlStruct = chunkedSoqlStruct{
+				WhereClause: chunkedCriteria{Status: "UP", Hosts: criteria},
+			}
+			query, _ := soql.Marshal(soqlStruct)
+			// room for two of the ids
+			maxLength = len(query) - len("'a03','a04','a05',")
+		})
+
+		It("splits the longest list into queries that fit", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualQueries).To(Equal([]string{
+				"SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c WHERE Status__c = 'UP' AND (Id IN ('a01','a02') OR Name IN ('db-1','db-2'))",
+				"SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c WHERE Status__c = 'UP' AND (Id IN ('a03','a04') OR Name IN ('db-1','db-2'))",
+				"SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c WHERE Status__c = 'UP' AND (Id IN ('a05') OR Name IN ('db-1','db-2'))",
+			}))
+			for _, query := range actualQueries {
+				Expect(len(query)).To(BeNumerically("<=", maxLength))
+			}
+		})
+
+		It("does not modify the struct", func() {
+			Expect(criteria.IDs).To(Equal([]string{"a01", "a02", "a03", "a04", "a05"}))
+			Expect(soqlStruct.(chunkedSoqlStruct).WhereClause.Hosts).To(BeIdenticalTo(criteria))
+		})
+	})
+
+	Context("when only not in list is too long", func() {
+		BeforeEach(func() {
+			soqlStruct = chunkedSoqlStruct{
+				WhereClause: chunkedCriteria{Hosts: &chunkedHostCriteria{
+					IDs:        []string{"a01"},
+					ExcludeIDs: []string{"a02", "a03", "a04", "a05"},
+				}},
+			}
+			query, _ := soql.Marshal(soqlStruct)
+			maxLength = len(query) - 1
+		})
+
+		It("returns ErrQueryTooLong error", func() {
+			Expect(errors.Is(err, soql.ErrQueryTooLong)).To(BeTrue())
+		})
+	})
+
+	Context("when only in list of not group is too long", func() {
+		BeforeEach(func() {
+			soqlStruct = chunkedSoqlStruct{
+				WhereClause: chunkedCriteria{Excluded: &chunkedHostCriteria{
+					IDs: []string{"a01", "a02", "a03", "a04", "a05"},
+				}},
+			}
+			query, _ := soql.Marshal(soqlStruct)
+			maxLength = len(query) - 1
+		})
+
+		It("returns ErrQueryTooLong error", func() {
+			Expect(errors.Is(err, soql.ErrQueryTooLong)).To(BeTrue())
+		})
+	})
+
+	Context("when a single value does not fit", func() {
+		BeforeEach(func() {
+			soqlStruct = TestSoqlStruct{WhereClause: TestQueryCriteria{Roles: []string{"db", "app"}}}
+			maxLength = 20
+		})
+
+		It("returns ErrQueryTooLong error", func() {
+			Expect(errors.Is(err, soql.ErrQueryTooLong)).To(BeTrue())
+		})
+	})
+
+	Context("when struct is invalid", func() {
+		BeforeEach(func() {
+			soqlStruct = OnlyWhereClause{}
+			maxLength = 0
+		})
+
+		It("returns the error of Marshal", func() {
+			Expect(err).To(matchMarshalError(soql.ErrNoSelectClause))
+		})
+	})
+})
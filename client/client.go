@@ -0,0 +1,286 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package client executes SOQL queries generated by soql.Marshal against Salesforce REST API and decodes
+// the records of all pages of the response with soql.Unmarshal.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+const (
+	// DefaultAPIVersion is the version of Salesforce REST API used by clients created with New
+	DefaultAPIVersion = "v58.0"
+
+	queryResource    = "query"
+	queryAllResource = "queryAll"
+)
+
+// TokenSource supplies the access token sent with every request. It is called once per request, so it can
+// refresh expired tokens.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same access token
+type StaticToken string
+
+// Token returns the access token
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// Error is returned when Salesforce responds with an error, e.g. MALFORMED_QUERY or INVALID_FIELD
+type Error struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// ErrorCode is the error code returned by Salesforce. It is empty if the response was not a Salesforce error
+	ErrorCode string `json:"errorCode"`
+	// Message is the error message returned by Salesforce or the response body if it was not a Salesforce error
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.ErrorCode == "" {
+		return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.ErrorCode, e.Message)
+}
+
+// Client executes SOQL queries using Salesforce REST API
+type Client struct {
+	httpClient  *http.Client
+	instanceURL string
+	tokenSource TokenSource
+	// APIVersion is the version of REST API used in the URLs of requests, e.g. v58.0
+	APIVersion string
+}
+
+// New returns the client sending requests to instanceURL, e.g. https://mydomain.my.salesforce.com, with
+// access token from tokenSource. If httpClient is nil then http.DefaultClient is used.
+func New(httpClient *http.Client, instanceURL string, tokenSource TokenSource) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		httpClient:  httpClient,
+		instanceURL: strings.TrimRight(instanceURL, "/"),
+		tokenSource: tokenSource,
+		APIVersion:  DefaultAPIVersion,
+	}
+}
+
+// Query executes query and decodes the records of all pages of the response into records.
+// query is either the soql struct passed to soql.Marshal or the SOQL query as string.
+// records must be a non nil pointer to a slice of structs tagged with selectColumn, or to a soql struct whose
+// member tagged with selectClause is such a slice. The pointer can be the same as query, e.g.
+// err := c.Query(ctx, &soqlStruct, &soqlStruct)
+// The slice is replaced with the records of the response.
+func (c *Client) Query(ctx context.Context, query interface{}, records interface{}) error {
+	return c.query(ctx, queryResource, query, records)
+}
+
+// QueryAll is like Query but uses queryAll resource, so records that were deleted or archived are returned as well
+func (c *Client) QueryAll(ctx context.Context, query interface{}, records interface{}) error {
+	return c.query(ctx, queryAllResource, query, records)
+}
+
+func (c *Client) query(ctx context.Context, resource string, query interface{}, records interface{}) error {
+	target, err := getRecordsSlice(records)
+	if err != nil {
+		return err
+	}
+	it, err := c.iterate(ctx, resource, query)
+	if err != nil {
+		return err
+	}
+	result := reflect.MakeSlice(target.Type(), 0, 0)
+	for it.Next() {
+		page := reflect.New(target.Type())
+		if err := it.Decode(page.Interface()); err != nil {
+			return err
+		}
+		result = reflect.AppendSlice(result, page.Elem())
+	}
+	if it.Err() != nil {
+		return it.Err()
+	}
+	target.Set(result)
+	return nil
+}
+
+// getRecordsSlice returns the slice records points to, either directly or as the member of soql struct
+// tagged with selectClause
+func getRecordsSlice(records interface{}) (reflect.Value, error) {
+	reflectedValue := reflect.ValueOf(records)
+	if reflectedValue.Kind() != reflect.Ptr {
+		return reflect.Value{}, soql.ErrInvalidUnmarshalTarget
+	}
+	if reflectedValue.IsNil() {
+		return reflect.Value{}, soql.ErrNilValue
+	}
+	reflectedValue = reflectedValue.Elem()
+	if reflectedValue.Kind() == reflect.Struct {
+		reflectedType := reflectedValue.Type()
+		for i := 0; i < reflectedType.NumField(); i++ {
+			clauseTag := reflectedType.Field(i).Tag.Get(soql.SoqlTag)
+			if strings.Split(clauseTag, ",")[0] == soql.SelectClause {
+				reflectedValue = reflectedValue.Field(i)
+				break
+			}
+		}
+	}
+	if reflectedValue.Kind() != reflect.Slice {
+		return reflect.Value{}, soql.ErrInvalidUnmarshalTarget
+	}
+	return reflectedValue, nil
+}
+
+// Iterate executes query and returns the iterator over the pages of the response. Pages are requested as
+// Next is called, so large results can be processed without holding all records in memory.
+// query is either the soql struct passed to soql.Marshal or the SOQL query as string.
+// it, err := c.Iterate(ctx, soqlStruct)
+// if err != nil {
+//		return err
+// }
+// for it.Next() {
+// 	var hosts []Host
+// 	if err := it.Decode(&hosts); err != nil {
+// 		return err
+// 	}
+// 	// process hosts
+// }
+// if err := it.Err(); err != nil {
+// 	return err
+// }
+func (c *Client) Iterate(ctx context.Context, query interface{}) (*Iterator, error) {
+	return c.iterate(ctx, queryResource, query)
+}
+
+// IterateAll is like Iterate but uses queryAll resource, so records that were deleted or archived are
+// returned as well
+func (c *Client) IterateAll(ctx context.Context, query interface{}) (*Iterator, error) {
+	return c.iterate(ctx, queryAllResource, query)
+}
+
+func (c *Client) iterate(ctx context.Context, resource string, query interface{}) (*Iterator, error) {
+	soqlQuery, ok := query.(string)
+	if !ok {
+		var err error
+		soqlQuery, err = soql.Marshal(query)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Iterator{
+		client:  c,
+		ctx:     ctx,
+		nextURL: "/services/data/" + c.APIVersion + "/" + resource + "?q=" + url.QueryEscape(soqlQuery),
+	}, nil
+}
+
+// Iterator iterates over the pages of the response of a query
+type Iterator struct {
+	client  *Client
+	ctx     context.Context
+	nextURL string
+	page    []byte
+	// TotalSize is the total number of records returned by the query. It is set after the first call to Next
+	TotalSize int
+	err       error
+}
+
+// pageInfo is the part of the response of query resource needed to fetch the next page
+type pageInfo struct {
+	TotalSize      int    `json:"totalSize"`
+	Done           bool   `json:"done"`
+	NextRecordsURL string `json:"nextRecordsUrl"`
+}
+
+// Next requests the next page of the response. It returns false when there are no more pages or the request
+// failed, in which case Err returns the error.
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.nextURL == "" {
+		return false
+	}
+	it.page, it.err = it.client.get(it.ctx, it.nextURL)
+	if it.err != nil {
+		return false
+	}
+	var info pageInfo
+	if it.err = json.Unmarshal(it.page, &info); it.err != nil {
+		return false
+	}
+	it.TotalSize = info.TotalSize
+	it.nextURL = ""
+	if !info.Done {
+		it.nextURL = info.NextRecordsURL
+	}
+	return true
+}
+
+// Decode decodes the records of the current page into v with soql.Unmarshal
+func (it *Iterator) Decode(v interface{}) error {
+	return soql.Unmarshal(it.page, v)
+}
+
+// Page returns the response body of the current page
+func (it *Iterator) Page() []byte {
+	return it.page
+}
+
+// Err returns the error that stopped the iteration
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, c.instanceURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// newError returns the first error of the error response of Salesforce
+func newError(statusCode int, body []byte) *Error {
+	var errs []Error
+	if err := json.Unmarshal(body, &errs); err != nil || len(errs) == 0 {
+		return &Error{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+	}
+	errs[0].StatusCode = statusCode
+	return &errs[0]
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package client_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Client Suite")
+}
+
+type hostQuery struct {
+	SelectClause []host       `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause  hostCriteria `soql:"whereClause"`
+}
+
+type host struct {
+	ID       string `soql:"selectColumn,fieldName=Id"`
+	Name     string `soql:"selectColumn,fieldName=Name"`
+	RoleName string `soql:"selectColumn,fieldName=Role__r.Name"`
+}
+
+type hostCriteria struct {
+	Roles []string `soql:"inOperator,fieldName=Role__r.Name"`
+}
+
+type invalidQuery struct {
+	WhereClause hostCriteria `soql:"whereClause"`
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+	"github.com/forcedotcom/go-soql/client"
+)
+
+const firstPage = `{"totalSize":3,"done":false,"nextRecordsUrl":"/services/data/v58.0/query/01gD0000002HU6KIAW-2000","records":[
+	{"attributes":{"type":"SM_Logical_Host__c"},"Id":"a01","Name":"db-1","Role__r":{"Name":"db"}},
+	{"attributes":{"type":"SM_Logical_Host__c"},"Id":"a02","Name":"db-2","Role__r":{"Name":"db"}}]}`
+
+const lastPage = `{"totalSize":3,"done":true,"records":[
+	{"attributes":{"type":"SM_Logical_Host__c"},"Id":"a03","Name":"app-1","Role__r":{"Name":"app"}}]}`
+
+var _ = Describe("Client", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		handler  http.HandlerFunc
+		c        *client.Client
+		query    hostQuery
+		err      error
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		query = hostQuery{WhereClause: hostCriteria{Roles: []string{"db", "app"}}}
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/services/data/v58.0/query", "/services/data/v58.0/queryAll":
+				fmt.Fprint(w, firstPage)
+			case "/services/data/v58.0/query/01gD0000002HU6KIAW-2000":
+				fmt.Fprint(w, lastPage)
+			default:
+				http.NotFound(w, r)
+			}
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			handler(w, r)
+		}))
+		c = client.New(server.Client(), server.URL+"/", client.StaticToken("token"))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Query", func() {
+		JustBeforeEach(func() {
+			err = c.Query(context.Background(), &query, &query)
+		})
+
+		It("decodes records of all pages", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query.SelectClause).To(Equal([]host{
+				{ID: "a01", Name: "db-1", RoleName: "db"},
+				{ID: "a02", Name: "db-2", RoleName: "db"},
+				{ID: "a03", Name: "app-1", RoleName: "app"},
+			}))
+		})
+
+		It("sends the marshaled query with the access token", func() {
+			Expect(requests).To(HaveLen(2))
+			expectedQuery, _ := soql.Marshal(query)
+			Expect(requests[0].URL.Query().Get("q")).To(Equal(expectedQuery))
+			Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer token"))
+			Expect(requests[1].URL.Path).To(Equal("/services/data/v58.0/query/01gD0000002HU6KIAW-2000"))
+		})
+
+		Context("when Salesforce returns an error", func() {
+			BeforeEach(func() {
+				handler = func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `[{"message":"No such column 'Nmae' on entity 'SM_Logical_Host__c'","errorCode":"INVALID_FIELD"}]`)
+				}
+			})
+
+			It("returns the error", func() {
+				var sfErr *client.Error
+				Expect(errors.As(err, &sfErr)).To(BeTrue())
+				Expect(sfErr.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(sfErr.ErrorCode).To(Equal("INVALID_FIELD"))
+				Expect(sfErr.Message).To(Equal("No such column 'Nmae' on entity 'SM_Logical_Host__c'"))
+			})
+		})
+
+		Context("when response is not a Salesforce error", func() {
+			BeforeEach(func() {
+				handler = func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadGateway)
+					fmt.Fprint(w, "Bad Gateway")
+				}
+			})
+
+			It("returns the body as message", func() {
+				Expect(err).To(MatchError("502: Bad Gateway"))
+			})
+		})
+	})
+
+	Describe("Query with SOQL string", func() {
+		var hosts []host
+
+		JustBeforeEach(func() {
+			err = c.Query(context.Background(), "SELECT Id,Name,Role__r.Name FROM SM_Logical_Host__c", &hosts)
+		})
+
+		It("sends the query as is and decodes into slice", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests[0].URL.Query().Get("q")).To(Equal("SELECT Id,Name,Role__r.Name FROM SM_Logical_Host__c"))
+			Expect(hosts).To(HaveLen(3))
+		})
+	})
+
+	Describe("QueryAll", func() {
+		JustBeforeEach(func() {
+			err = c.QueryAll(context.Background(), query, &query)
+		})
+
+		It("uses queryAll resource", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests[0].URL.Path).To(Equal("/services/data/v58.0/queryAll"))
+			Expect(query.SelectClause).To(HaveLen(3))
+		})
+	})
+
+	Describe("Iterate", func() {
+		It("returns records page by page", func() {
+			it, err := c.Iterate(context.Background(), query)
+			Expect(err).ToNot(HaveOccurred())
+			var pageSizes []int
+			for it.Next() {
+				var hosts []host
+				Expect(it.Decode(&hosts)).To(Succeed())
+				pageSizes = append(pageSizes, len(hosts))
+			}
+			Expect(it.Err()).ToNot(HaveOccurred())
+			Expect(it.TotalSize).To(Equal(3))
+			Expect(pageSizes).To(Equal([]int{2, 1}))
+		})
+
+		It("returns error for invalid soql struct", func() {
+			_, err := c.Iterate(context.Background(), invalidQuery{})
+			Expect(errors.Is(err, soql.ErrNoSelectClause)).To(BeTrue())
+			Expect(requests).To(BeEmpty())
+		})
+	})
+
+	Describe("Query with invalid records", func() {
+		It("returns ErrInvalidUnmarshalTarget error", func() {
+			err = c.Query(context.Background(), query, query)
+			Expect(err).To(Equal(soql.ErrInvalidUnmarshalTarget))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Command soqlcheck checks soql struct tags. It can be run on its own or by go vet:
+//
+//	go install github.com/forcedotcom/go-soql/cmd/soqlcheck
+//	go vet -vettool=$(which soqlcheck) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/forcedotcom/go-soql/soqlcheck"
+)
+
+func main() {
+	singlechecker.Main(soqlcheck.Analyzer)
+}
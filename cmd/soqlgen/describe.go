@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+var errNoName = errors.New("describe has no sObject name")
+
+// sObjectDescribe is the part of the response of sobjects/<Name>/describe resource used by soqlgen
+type sObjectDescribe struct {
+	Name               string              `json:"name"`
+	Fields             []fieldDescribe     `json:"fields"`
+	ChildRelationships []childRelationship `json:"childRelationships"`
+}
+
+type fieldDescribe struct {
+	Name             string   `json:"name"`
+	Type             string   `json:"type"`
+	NameField        bool     `json:"nameField"`
+	RelationshipName string   `json:"relationshipName"`
+	ReferenceTo      []string `json:"referenceTo"`
+}
+
+type childRelationship struct {
+	ChildSObject     string `json:"childSObject"`
+	Field            string `json:"field"`
+	RelationshipName string `json:"relationshipName"`
+}
+
+func readDescribe(path string) (*sObjectDescribe, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var describe sObjectDescribe
+	if err := json.Unmarshal(data, &describe); err != nil {
+		return nil, err
+	}
+	if describe.Name == "" {
+		return nil, errNoName
+	}
+	return &describe, nil
+}
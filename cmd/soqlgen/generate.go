@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+const (
+	fieldsSuffix    = "Fields"
+	refSuffix       = "Ref"
+	referenceType   = "reference"
+	idField         = "Id"
+	timeImport      = "time"
+	generatedHeader = "// Code generated by soqlgen. DO NOT EDIT.\n\n"
+)
+
+// goTypes maps the types of Salesforce fields to Go types. Fields of other types, like compound address and
+// location fields, are skipped.
+var goTypes = map[string]string{
+	"id":              "string",
+	"string":          "string",
+	"textarea":        "string",
+	"phone":           "string",
+	"url":             "string",
+	"email":           "string",
+	"picklist":        "string",
+	"multipicklist":   "string",
+	"combobox":        "string",
+	"encryptedstring": "string",
+	"base64":          "string",
+	referenceType:     "string",
+	"boolean":         "bool",
+	"int":             "int",
+	"long":            "int64",
+	"double":          "float64",
+	"currency":        "float64",
+	"percent":         "float64",
+	"date":            "time.Time",
+	"datetime":        "time.Time",
+	"time":            "time.Time",
+}
+
+// timeFormats are the format parameters of the date and time fields. dateTime fields use soql.DateTimeFormat.
+var timeFormats = map[string]string{
+	"date": "2006-01-02",
+	"time": "15:04:05.000Z",
+}
+
+// nameSuffixes are the suffixes of custom objects, fields and relationships removed from Go names
+var nameSuffixes = []string{"__c", "__r", "__mdt", "__e", "__x", "__b", "__kav", "__pc", "__pr"}
+
+// goName returns the exported Go name for the name of sObject, field or relationship, e.g. HostName for
+// Host_Name__c and AccountID for AccountId
+func goName(name string) string {
+	for _, suffix := range nameSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			name = strings.TrimSuffix(name, suffix)
+			break
+		}
+	}
+	var buff strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		buff.WriteString(strings.ToUpper(part[:1]))
+		buff.WriteString(part[1:])
+	}
+	goName := buff.String()
+	if strings.HasSuffix(goName, idField) {
+		goName = strings.TrimSuffix(goName, idField) + "ID"
+	}
+	return goName
+}
+
+// nameSuffix returns the suffix goName removes from name as exported Go name, e.g. Mdt for Host__mdt
+func nameSuffix(name string) string {
+	for _, suffix := range nameSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.ToUpper(suffix[2:3]) + suffix[3:]
+		}
+	}
+	return ""
+}
+
+type structField struct {
+	name   string
+	goType string
+	tag    string
+}
+
+type structType struct {
+	name    string
+	comment string
+	fields  []structField
+	// names are the names of the fields used to avoid duplicates
+	names map[string]bool
+}
+
+func newStructType(name, comment string) *structType {
+	return &structType{name: name, comment: comment, names: make(map[string]bool)}
+}
+
+// addField adds the field named after sfName. If the name is already used, alternative is appended to it.
+func (s *structType) addField(sfName, alternative, goType, tag string) {
+	name := goName(sfName)
+	if s.names[name] {
+		name += alternative
+	}
+	for indx := 2; s.names[name]; indx++ {
+		name = goName(sfName) + alternative + strconv.Itoa(indx)
+	}
+	s.names[name] = true
+	s.fields = append(s.fields, structField{name: name, goType: goType, tag: tag})
+}
+
+type generator struct {
+	describes map[string]*sObjectDescribe
+	// refs are the names of sObjects referenced by lookup fields
+	refs map[string]bool
+	// typeNames are the names of the structs used to avoid duplicates, e.g. Host for both Host__c and Host__mdt
+	typeNames map[string]bool
+	// sObjectTypes, fieldsTypes and refTypes are the names of the structs of sObjects, of sObjects without
+	// child relationships and of lookups by sObject name
+	sObjectTypes map[string]string
+	fieldsTypes  map[string]string
+	refTypes     map[string]string
+	structs      []*structType
+	usesTime     bool
+}
+
+// generate returns the Go source of package pkg with the structs of describes
+func generate(pkg string, describes []*sObjectDescribe) ([]byte, error) {
+	g := &generator{
+		describes:    make(map[string]*sObjectDescribe),
+		refs:         make(map[string]bool),
+		typeNames:    make(map[string]bool),
+		sObjectTypes: make(map[string]string),
+		fieldsTypes:  make(map[string]string),
+		refTypes:     make(map[string]string),
+	}
+	var names []string
+	for _, describe := range describes {
+		if _, ok := g.describes[describe.Name]; ok {
+			return nil, fmt.Errorf("sObject %s is described more than once", describe.Name)
+		}
+		g.describes[describe.Name] = describe
+		names = append(names, describe.Name)
+	}
+	sort.Strings(names)
+	// The names of the structs of sObjects are chosen before the ones derived from them, so they only get
+	// a suffix when the names of sObjects collide
+	for _, name := range names {
+		g.sObjectTypes[name] = g.addTypeName(goName(name), nameSuffix(name))
+	}
+	for _, name := range names {
+		if g.hasChildren(g.describes[name]) {
+			g.fieldsTypes[name] = g.addTypeName(g.sObjectTypes[name]+fieldsSuffix, "")
+		}
+		for _, field := range g.describes[name].Fields {
+			if referenceTo, ok := lookupTarget(field); ok {
+				g.refs[referenceTo] = true
+			}
+		}
+	}
+	var refs []string
+	for name := range g.refs {
+		refs = append(refs, name)
+	}
+	sort.Strings(refs)
+	for _, name := range refs {
+		typeName, ok := g.sObjectTypes[name]
+		if !ok {
+			typeName = goName(name)
+		}
+		g.refTypes[name] = g.addTypeName(typeName+refSuffix, "")
+	}
+	for _, name := range names {
+		g.addSObject(g.describes[name])
+	}
+	for _, name := range refs {
+		g.addRef(name)
+	}
+	return g.source(pkg)
+}
+
+// addTypeName returns the unused struct name for name and marks it as used. If name is already used,
+// alternative is appended to it, and then a number if it is still used.
+func (g *generator) addTypeName(name, alternative string) string {
+	typeName := name
+	if g.typeNames[typeName] {
+		typeName += alternative
+	}
+	for indx := 2; g.typeNames[typeName]; indx++ {
+		typeName = name + alternative + strconv.Itoa(indx)
+	}
+	g.typeNames[typeName] = true
+	return typeName
+}
+
+// lookupTarget returns the sObject referenced by field if it is a lookup modelled as nested struct.
+// Polymorphic relationships reference more than one sObject and cannot be modelled as nested struct.
+func lookupTarget(field fieldDescribe) (string, bool) {
+	if field.Type != referenceType || field.RelationshipName == "" || len(field.ReferenceTo) != 1 {
+		return "", false
+	}
+	return field.ReferenceTo[0], true
+}
+
+// hasChildren reports whether describe has child relationships to described sObjects
+func (g *generator) hasChildren(describe *sObjectDescribe) bool {
+	for _, child := range describe.ChildRelationships {
+		if _, ok := g.describes[child.ChildSObject]; ok && child.RelationshipName != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// childStructName returns the name of the struct used in child relationship subqueries. Subqueries cannot be
+// nested, so it is the struct without child relationships.
+func (g *generator) childStructName(describe *sObjectDescribe) string {
+	if name, ok := g.fieldsTypes[describe.Name]; ok {
+		return name
+	}
+	return g.sObjectTypes[describe.Name]
+}
+
+func (g *generator) addSObject(describe *sObjectDescribe) {
+	name := g.sObjectTypes[describe.Name]
+	fields := newStructType(name, fmt.Sprintf("%s is the %s sObject", name, describe.Name))
+	g.addFields(fields, describe)
+	if !g.hasChildren(describe) {
+		g.structs = append(g.structs, fields)
+		return
+	}
+
+	withChildren := newStructType(name, fmt.Sprintf("%s is the %s sObject with its child relationships", name, describe.Name))
+	g.addFields(withChildren, describe)
+	fields.name = g.fieldsTypes[describe.Name]
+	fields.comment = fmt.Sprintf("%s is the %s sObject without child relationships, used in subqueries", fields.name, describe.Name)
+	var wrappers []*structType
+	for _, child := range describe.ChildRelationships {
+		childDescribe, ok := g.describes[child.ChildSObject]
+		if !ok || child.RelationshipName == "" {
+			continue
+		}
+		wrapperName := g.addTypeName(name+goName(child.RelationshipName), "")
+		wrapper := newStructType(wrapperName,
+			fmt.Sprintf("%s is the %s child relationship of %s", wrapperName, child.RelationshipName, describe.Name))
+		wrapper.addField(soql.SelectClause, "", "[]"+g.childStructName(childDescribe),
+			fmt.Sprintf("%s,%s=%s", soql.SelectClause, soql.TableName, child.ChildSObject))
+		wrapper.fields[0].name = "SelectClause"
+		wrappers = append(wrappers, wrapper)
+		withChildren.addField(child.RelationshipName, "Children", wrapper.name,
+			fmt.Sprintf("%s,%s=%s", soql.SelectChild, soql.FieldName, child.RelationshipName))
+	}
+	g.structs = append(g.structs, withChildren, fields)
+	g.structs = append(g.structs, wrappers...)
+}
+
+// addFields adds the columns and parent relationships of describe to s
+func (g *generator) addFields(s *structType, describe *sObjectDescribe) {
+	for _, field := range describe.Fields {
+		goType, ok := goTypes[field.Type]
+		if !ok {
+			continue
+		}
+		tag := soql.SelectColumn + "," + soql.FieldName + "=" + field.Name
+		if format, ok := timeFormats[field.Type]; ok {
+			tag += "," + soql.Format + "=" + format
+		}
+		if strings.HasPrefix(goType, timeImport) {
+			g.usesTime = true
+		}
+		s.addField(field.Name, "", goType, tag)
+	}
+	for _, field := range describe.Fields {
+		referenceTo, ok := lookupTarget(field)
+		if !ok {
+			continue
+		}
+		s.addField(field.RelationshipName, "Rel", g.refTypes[referenceTo],
+			soql.SelectColumn+","+soql.FieldName+"="+field.RelationshipName)
+	}
+}
+
+// addRef adds the struct used for lookups to sObject name. It has the Id and the name field if sObject is
+// described. Lookups use it instead of the struct of sObject to avoid cycles, e.g. Account.Owner.Account.
+func (g *generator) addRef(name string) {
+	structName := g.refTypes[name]
+	ref := newStructType(structName, fmt.Sprintf("%s is the %s sObject referenced by lookup fields", structName, name))
+	ref.addField(idField, "", "string", soql.SelectColumn+","+soql.FieldName+"="+idField)
+	if describe, ok := g.describes[name]; ok {
+		for _, field := range describe.Fields {
+			goType, ok := goTypes[field.Type]
+			if !field.NameField || !ok || field.Name == idField {
+				continue
+			}
+			ref.addField(field.Name, "", goType, soql.SelectColumn+","+soql.FieldName+"="+field.Name)
+			break
+		}
+	}
+	g.structs = append(g.structs, ref)
+}
+
+func (g *generator) source(pkg string) ([]byte, error) {
+	var buff strings.Builder
+	buff.WriteString(generatedHeader)
+	buff.WriteString("package " + pkg + "\n\n")
+	if g.usesTime {
+		buff.WriteString("import \"" + timeImport + "\"\n\n")
+	}
+	for _, s := range g.structs {
+		buff.WriteString("// " + s.comment + "\n")
+		buff.WriteString("type " + s.name + " struct {\n")
+		for _, field := range s.fields {
+			buff.WriteString(fmt.Sprintf("\t%s %s `%s:%q`\n", field.name, field.goType, soql.SoqlTag, field.tag))
+		}
+		buff.WriteString("}\n\n")
+	}
+	return format.Source([]byte(buff.String()))
+}
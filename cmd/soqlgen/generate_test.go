@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var (
+		describes []*sObjectDescribe
+		source    []byte
+		err       error
+	)
+
+	JustBeforeEach(func() {
+		source, err = generate("sobjects", describes)
+	})
+
+	Context("when sObjects are described", func() {
+		BeforeEach(func() {
+			describes = nil
+			// Order of files does not change the generated source
+			for _, name := range []string{"Contact.json", "Account.json"} {
+				describe, readErr := readDescribe(filepath.Join("testdata", name))
+				Expect(readErr).ToNot(HaveOccurred())
+				describes = append(describes, describe)
+			}
+		})
+
+		It("returns the structs of sObjects, their child relationships and lookups", func() {
+			Expect(err).ToNot(HaveOccurred())
+			expected, readErr := ioutil.ReadFile(filepath.Join("testdata", "sobjects.golden"))
+			Expect(readErr).ToNot(HaveOccurred())
+			Expect(string(source)).To(Equal(string(expected)))
+		})
+	})
+
+	Context("when names of sObjects collide", func() {
+		BeforeEach(func() {
+			fields := func(extra ...fieldDescribe) []fieldDescribe {
+				return append([]fieldDescribe{{Name: "Id", Type: "id"}, {Name: "Name", Type: "string", NameField: true}}, extra...)
+			}
+			describes = []*sObjectDescribe{
+				{Name: "Host__mdt", Fields: fields()},
+				{Name: "Host__c", Fields: fields(fieldDescribe{Name: "Config__c", Type: "reference", RelationshipName: "Config__r", ReferenceTo: []string{"Host__mdt"}})},
+				{Name: "AssetRef__c", Fields: fields()},
+				{
+					Name: "Asset__c",
+					Fields: fields(
+						fieldDescribe{Name: "Asset__c", Type: "reference", RelationshipName: "Asset__r", ReferenceTo: []string{"Asset"}},
+						fieldDescribe{Name: "Host__c", Type: "reference", RelationshipName: "Host__r", ReferenceTo: []string{"Host__c"}},
+					),
+				},
+				{
+					Name:   "Asset",
+					Fields: fields(),
+					ChildRelationships: []childRelationship{
+						{ChildSObject: "Asset__c", Field: "Asset__c", RelationshipName: "Custom_Assets__r"},
+						{ChildSObject: "AssetRef__c", Field: "Asset__c", RelationshipName: "Ref__r"},
+					},
+				},
+			}
+		})
+
+		It("returns structs with distinct names", func() {
+			Expect(err).ToNot(HaveOccurred())
+			expected, readErr := ioutil.ReadFile(filepath.Join("testdata", "collisions.golden"))
+			Expect(readErr).ToNot(HaveOccurred())
+			Expect(string(source)).To(Equal(string(expected)))
+		})
+	})
+
+	Context("when sObject is described more than once", func() {
+		BeforeEach(func() {
+			describes = []*sObjectDescribe{{Name: "Account"}, {Name: "Account"}}
+		})
+
+		It("returns error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when sObject has no child relationships to described sObjects", func() {
+		BeforeEach(func() {
+			describes = []*sObjectDescribe{{
+				Name: "Case",
+				Fields: []fieldDescribe{
+					{Name: "Id", Type: "id"},
+					{Name: "CaseNumber", Type: "string", NameField: true},
+					{Name: "ClosedDate", Type: "datetime"},
+				},
+				ChildRelationships: []childRelationship{{ChildSObject: "CaseComment", Field: "ParentId", RelationshipName: "CaseComments"}},
+			}}
+		})
+
+		It("returns only the struct of sObject", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(source)).To(Equal("// Code generated by soqlgen. DO NOT EDIT.\n\npackage sobjects\n\nimport \"time\"\n\n" +
+				"// Case is the Case sObject\ntype Case struct {\n" +
+				"\tID         string    `soql:\"selectColumn,fieldName=Id\"`\n" +
+				"\tCaseNumber string    `soql:\"selectColumn,fieldName=CaseNumber\"`\n" +
+				"\tClosedDate time.Time `soql:\"selectColumn,fieldName=ClosedDate\"`\n}\n"))
+		})
+	})
+})
+
+var _ = Describe("goName", func() {
+	It("returns exported Go names", func() {
+		Expect(goName("Name")).To(Equal("Name"))
+		Expect(goName("AccountId")).To(Equal("AccountID"))
+		Expect(goName("Paid__c")).To(Equal("Paid"))
+		Expect(goName("Host_Name__c")).To(Equal("HostName"))
+		Expect(goName("Parent_Host__r")).To(Equal("ParentHost"))
+		Expect(goName("ns__Setting__mdt")).To(Equal("NsSetting"))
+		Expect(goName("lower_case")).To(Equal("LowerCase"))
+	})
+})
+
+var _ = Describe("readDescribe", func() {
+	Context("when file does not exist", func() {
+		It("returns error", func() {
+			_, err := readDescribe(filepath.Join("testdata", "Missing.json"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when describe has no name", func() {
+		It("returns errNoName error", func() {
+			dir, err := ioutil.TempDir("", "soqlgen")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "Empty.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"fields":[]}`), 0644)).To(Succeed())
+			_, err = readDescribe(path)
+			Expect(err).To(Equal(errNoName))
+		})
+	})
+})
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Command soqlgen generates Go structs with soql tags from the describe JSON of sObjects, as returned by
+// sobjects/<Name>/describe resource of Salesforce REST API.
+//
+// Usage:
+//
+//	soqlgen [-pkg name] [-o file] describe.json...
+//
+// For every sObject a struct with a selectColumn member per field is generated. Lookups are generated as
+// parent relationship structs named <sObject>Ref, which have the Id and the name field of the referenced
+// sObject. Child relationships to the other described sObjects are generated as selectChild members. In that
+// case the struct without child relationships is generated as well, named <sObject>Fields, since subqueries
+// cannot be nested.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	pkg := flag.String("pkg", "main", "name of the package of generated file")
+	output := flag.String("o", "", "generated file, standard output if not set")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: soqlgen [-pkg name] [-o file] describe.json...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*pkg, *output, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "soqlgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, output string, paths []string) error {
+	var describes []*sObjectDescribe
+	for _, path := range paths {
+		describe, err := readDescribe(path)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		describes = append(describes, describe)
+	}
+	source, err := generate(pkg, describes)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return ioutil.WriteFile(output, source, 0644)
+}
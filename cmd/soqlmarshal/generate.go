@@ -0,0 +1,972 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+const generatedHeader = "// Code generated by soqlmarshal. DO NOT EDIT.\n\n"
+
+// role is the clause a struct is used for, every role has its own helper method
+type role int
+
+const (
+	queryRole role = iota
+	selectRole
+	whereRole
+)
+
+var methodNames = [...]string{
+	queryRole:  "soqlQuery",
+	selectRole: "soqlSelect",
+	whereRole:  "soqlWhere",
+}
+
+type method struct {
+	named *types.Named
+	role  role
+}
+
+type generator struct {
+	pkg     *types.Package
+	buff    bytes.Buffer
+	queue   []method
+	queued  map[method]bool
+	imports map[string]bool
+	// formats are the declarations of tags passed to soql.BuildCondition for members with format parameter
+	formats []string
+}
+
+// generate returns the source of MarshalSOQL methods of types typeNames of pkg and of the helper methods of
+// the structs used by them
+func generate(pkg *types.Package, typeNames []string) ([]byte, error) {
+	g := &generator{pkg: pkg, queued: make(map[method]bool), imports: make(map[string]bool)}
+	seen := make(map[string]bool)
+	for _, name := range typeNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Name())
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok || !g.isLocalStruct(named) {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+		if m, _, _ := types.LookupFieldOrMethod(named, true, pkg, "MarshalSOQL"); m != nil {
+			return nil, fmt.Errorf("type %s already has MarshalSOQL", name)
+		}
+		g.printf("\n// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.\n")
+		g.printf("func (v %s) MarshalSOQL() (string, error) {\nreturn v.soqlQuery(\"\")\n}\n", name)
+		g.enqueue(named, queryRole)
+	}
+	for len(g.queue) > 0 {
+		m := g.queue[0]
+		g.queue = g.queue[1:]
+		var err error
+		switch m.role {
+		case queryRole:
+			err = g.queryMethod(m.named)
+		case selectRole:
+			err = g.selectMethod(m.named)
+		case whereRole:
+			err = g.whereMethod(m.named)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var source bytes.Buffer
+	source.WriteString(generatedHeader)
+	fmt.Fprintf(&source, "package %s\n\nimport (\n", pkg.Name())
+	for _, path := range []string{"strconv", "strings", "", soqlPackage} {
+		if path == "" {
+			source.WriteString("\n")
+		} else if g.imports[path] {
+			fmt.Fprintf(&source, "%q\n", path)
+		}
+	}
+	source.WriteString(")\n")
+	if len(g.formats) > 0 {
+		source.WriteString("\nvar (\n")
+		source.WriteString(strings.Join(g.formats, ""))
+		source.WriteString(")\n")
+	}
+	source.Write(g.buff.Bytes())
+	return format.Source(source.Bytes())
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buff, format, args...)
+}
+
+func (g *generator) isLocalStruct(named *types.Named) bool {
+	_, isStruct := named.Underlying().(*types.Struct)
+	return isStruct && named.Obj().Pkg() == g.pkg && named.TypeParams().Len() == 0
+}
+
+// enqueue adds the helper method of role to the ones to be generated unless named already has it, which
+// happens when it is used by the types of another generated file
+func (g *generator) enqueue(named *types.Named, r role) {
+	m := method{named: named, role: r}
+	if g.queued[m] {
+		return
+	}
+	g.queued[m] = true
+	if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), true, g.pkg, methodNames[r]); obj != nil {
+		return
+	}
+	g.queue = append(g.queue, m)
+}
+
+// require returns the struct t, used as role by member m of owner, and enqueues its helper method
+func (g *generator) require(owner *types.Named, m member, t types.Type, r role) (*types.Named, error) {
+	named, ok := t.(*types.Named)
+	if !ok || !g.isLocalStruct(named) {
+		return nil, g.unsupported(owner, m, types.TypeString(t, types.RelativeTo(g.pkg))+" is not a struct of package "+g.pkg.Name())
+	}
+	g.enqueue(named, r)
+	return named, nil
+}
+
+func (g *generator) unsupported(owner *types.Named, m member, reason string) error {
+	return fmt.Errorf("%s.%s: %s", owner.Obj().Name(), m.name, reason)
+}
+
+func (g *generator) method(named *types.Named, r role, params string, b *body) {
+	g.printf("\nfunc (v *%s) %s(%s) (string, error) {\n", named.Obj().Name(), methodNames[r], params)
+	if b.uses["buff"] {
+		g.imports["strings"] = true
+		g.printf("var buff strings.Builder\n")
+	}
+	if b.uses["prefix"] {
+		g.printf("prefix := \"\"\nif table != \"\" {\nprefix = table + \".\"\n}\n")
+	}
+	if b.uses["condition"] {
+		g.printf("var condition string\nvar err error\n")
+	}
+	g.buff.Write(b.code.Bytes())
+	g.printf("}\n")
+}
+
+// queryMethod generates soqlQuery method, which returns the query of the struct as marshal function of soql
+// package does. child is the relationship name for subqueries of selectChild members and empty otherwise.
+func (g *generator) queryMethod(named *types.Named) error {
+	b := g.newBody()
+	if err := g.queryBody(b, named); err != nil {
+		return err
+	}
+	g.method(named, queryRole, "child string", b)
+	return nil
+}
+
+func (g *generator) queryBody(b *body, named *types.Named) error {
+	s := named.Underlying().(*types.Struct)
+	if s.NumFields() == 0 {
+		b.printf("return \"\", nil")
+		return nil
+	}
+	plan := compileQueryPlan(s)
+	// The select clause is still marshaled when one of the following members fails, but it is not used
+	fails := len(plan.clauses) > 0 && plan.clauses[len(plan.clauses)-1].err != nil
+	clauses := make(map[string]queryClause)
+	var selectType *types.Named
+	for _, clause := range plan.clauses {
+		if clause.err != nil {
+			b.fail(clause.err, clause.name, clause.tag)
+			return nil
+		}
+		clauses[clause.clauseKey] = clause
+		if clause.clauseKey == soql.SelectClause {
+			var err error
+			if selectType, err = g.selectValue(b, named, clause, fails); err != nil {
+				return err
+			}
+		}
+	}
+	selectClause, ok := clauses[soql.SelectClause]
+	if !ok {
+		if plan.soqlTagPresent {
+			b.fail(soql.ErrNoSelectClause, "", "")
+			return nil
+		}
+		b.printf("if child != \"\" {\nreturn \"()\", nil\n}\nreturn \"\", nil")
+		return nil
+	}
+	tableName := selectClause.tableName
+
+	b.printf("if child != \"\" {")
+	b.literal("(")
+	b.printf("}")
+	b.literal("SELECT ")
+	if selectClause.fields != "" {
+		b.literal("FIELDS(" + selectClause.fields + ")")
+		b.printf("if selectClause != \"\" {")
+		b.literal(",")
+		b.printf("}")
+	}
+	b.write("selectClause")
+	b.literal(" FROM ")
+	b.printf("if child != \"\" {")
+	b.write("child")
+	if tableName != "" {
+		b.printf("} else {")
+		b.literal(tableName)
+	}
+	b.printf("}")
+	if clause, ok := clauses[soql.UsingScope]; ok {
+		if err := g.usingScope(b, named, clause); err != nil {
+			return err
+		}
+	}
+
+	if clause, ok := clauses[soql.WhereClause]; ok {
+		relation := `""`
+		if tableName != "" {
+			b.printf("relation := \"\"\nif child != \"\" {\nrelation = %q\n}", tableName)
+			relation = "relation"
+		}
+		if err := g.whereValue(b, named, clause, relation, "whereClause", " WHERE "); err != nil {
+			return err
+		}
+	}
+	if clause, ok := clauses[soql.WithClause]; ok {
+		if err := g.with(b, named, clause); err != nil {
+			return err
+		}
+	}
+	mappings := make(map[string]string)
+	mapSelectColumns(mappings, "", "", selectType.Underlying().(*types.Struct))
+	if clause, ok := clauses[soql.GroupByClause]; ok {
+		if err := g.groupBy(b, named, clause, mappings, tableName); err != nil {
+			return err
+		}
+	}
+	if clause, ok := clauses[soql.HavingClause]; ok {
+		if err := g.whereValue(b, named, clause, `""`, "havingClause", " HAVING "); err != nil {
+			return err
+		}
+	}
+	// ordered is the condition under which ORDER BY is written, which is empty without orderByClause
+	ordered := ""
+	if clause, ok := clauses[soql.OrderByClause]; ok {
+		stop, err := g.orderBy(b, named, clause, mappings, tableName)
+		if stop || err != nil {
+			return err
+		}
+		if _, ok := clause.typ.Underlying().(*types.Pointer); ok {
+			ordered = "len(*v." + clause.name + ") > 0"
+		} else {
+			ordered = "len(v." + clause.name + ") > 0"
+		}
+	}
+	intPointer := types.NewPointer(types.Typ[types.Int])
+	for _, keyword := range []struct {
+		clauseKey string
+		keyword   string
+		err       error
+	}{
+		{soql.LimitClause, " LIMIT ", soql.ErrInvalidLimitClause},
+		{soql.OffsetClause, " OFFSET ", soql.ErrInvalidOffsetClause},
+	} {
+		clause, ok := clauses[keyword.clauseKey]
+		if !ok {
+			continue
+		}
+		if !types.Identical(clause.typ, intPointer) {
+			return g.unsupported(named, clause.member, "type of "+keyword.clauseKey+" must be *int")
+		}
+		b.printf("if v.%s != nil {", clause.name)
+		b.printf("if *v.%s < 0 {", clause.name)
+		b.fail(keyword.err, clause.name, clause.tag)
+		b.printf("}")
+		b.literal(keyword.keyword)
+		b.imports["strconv"] = true
+		b.write("strconv.Itoa(*v." + clause.name + ")")
+		b.printf("}")
+	}
+	if selectClause.fields == "CUSTOM" || selectClause.fields == "ALL" {
+		clause, ok := clauses[soql.LimitClause]
+		if !ok {
+			b.fail(soql.ErrInvalidFieldsLimit, selectClause.name, selectClause.tag)
+			return nil
+		}
+		b.printf("if v.%s == nil || *v.%s > %d {", clause.name, clause.name, soql.MaxFieldsLimit)
+		b.fail(soql.ErrInvalidFieldsLimit, clause.name, clause.tag)
+		b.printf("}")
+	}
+	if clause, ok := clauses[soql.ForClause]; ok {
+		if err := g.forClause(b, named, clause, ordered); err != nil {
+			return err
+		}
+	}
+	b.printf("if child != \"\" {")
+	b.literal(")")
+	b.printf("}")
+	b.printf("return buff.String(), nil")
+	return nil
+}
+
+// with generates with clause of the query from its withClause member, which is a soql.SecurityMode. Child
+// queries only support SECURITY_ENFORCED.
+func (g *generator) with(b *body, owner *types.Named, clause queryClause) error {
+	if !isNamed(clause.typ, soqlPackage, "SecurityMode") {
+		return g.unsupported(owner, clause.member, "type of withClause must be soql.SecurityMode")
+	}
+	b.imports["strings"] = true
+	b.printf("if v.%s != \"\" {", clause.name)
+	b.printf("mode := strings.ToUpper(string(v.%s))", clause.name)
+	b.printf("if mode != %q && (child != \"\" || (mode != %q && mode != %q)) {",
+		soql.SecurityEnforced, soql.UserMode, soql.SystemMode)
+	b.fail(soql.ErrInvalidWithClause, clause.name, clause.tag)
+	b.printf("}")
+	b.literal(" WITH ")
+	b.write("mode")
+	b.printf("}")
+	return nil
+}
+
+// usingScope generates using scope clause of the query from its usingScope member, which is a
+// soql.FilterScope matched case insensitively
+func (g *generator) usingScope(b *body, owner *types.Named, clause queryClause) error {
+	if !isNamed(clause.typ, soqlPackage, "FilterScope") {
+		return g.unsupported(owner, clause.member, "type of usingScope must be soql.FilterScope")
+	}
+	b.imports["strings"] = true
+	b.printf("if v.%s != \"\" {", clause.name)
+	b.literal(" USING SCOPE ")
+	b.printf("switch strings.ToLower(string(v.%s)) {", clause.name)
+	for _, scope := range []soql.FilterScope{
+		soql.ScopeDelegated, soql.ScopeEverything, soql.ScopeMine, soql.ScopeMineAndMyGroups,
+		soql.ScopeMyTerritory, soql.ScopeMyTeamTerritory, soql.ScopeTeam, soql.ScopeScopingRule,
+	} {
+		b.printf("case %q:", strings.ToLower(string(scope)))
+		b.literal(string(scope))
+	}
+	b.printf("default:")
+	b.fail(soql.ErrInvalidUsingScope, clause.name, clause.tag)
+	b.printf("}")
+	b.printf("}")
+	return nil
+}
+
+// forClause generates for clause of the query from its forClause member, which is a soql.ForMode. Child
+// queries do not support it and UPDATE is not supported when ordered, if any, holds.
+func (g *generator) forClause(b *body, owner *types.Named, clause queryClause, ordered string) error {
+	if !isNamed(clause.typ, soqlPackage, "ForMode") {
+		return g.unsupported(owner, clause.member, "type of forClause must be soql.ForMode")
+	}
+	b.imports["strings"] = true
+	b.printf("if v.%s != \"\" {", clause.name)
+	b.printf("mode := strings.ToUpper(string(v.%s))", clause.name)
+	invalid := fmt.Sprintf("(mode != %q && mode != %q && mode != %q) || child != \"\"", soql.ForView, soql.ForReference, soql.ForUpdate)
+	if ordered != "" {
+		invalid += fmt.Sprintf(" || (mode == %q && %s)", soql.ForUpdate, ordered)
+	}
+	b.printf("if %s {", invalid)
+	b.fail(soql.ErrInvalidForClause, clause.name, clause.tag)
+	b.printf("}")
+	b.literal(" FOR ")
+	b.write("mode")
+	b.printf("}")
+	return nil
+}
+
+// selectValue generates the select clause of the query from its selectClause member, which is a struct, a
+// pointer to struct or a slice of them. It returns the struct type. The select clause is discarded if the query
+// fails anyway.
+func (g *generator) selectValue(b *body, owner *types.Named, clause queryClause, discard bool) (*types.Named, error) {
+	value := "v." + clause.name
+	var elem types.Type
+	switch t := clause.typ.Underlying().(type) {
+	case *types.Struct:
+		elem = clause.typ
+	case *types.Pointer:
+		elem = t.Elem()
+		b.printf("if %s == nil {", value)
+		b.fail(soql.ErrNilValue, clause.name, clause.tag)
+		b.printf("}")
+	case *types.Slice:
+		elem = t.Elem()
+		if pointer, ok := elem.(*types.Pointer); ok {
+			elem = pointer.Elem()
+		}
+		value = ""
+	default:
+		return nil, g.unsupported(owner, clause.member, "type of selectClause must be a struct, a pointer to struct or a slice of them")
+	}
+	selectType, err := g.require(owner, clause.member, elem, selectRole)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		value = "new(" + selectType.Obj().Name() + ")"
+	}
+	prefix := `""`
+	if clause.tableName != "" {
+		b.printf("prefix := \"\"\nif child != \"\" {\nprefix = %q\n}", clause.tableName+".")
+		prefix = "prefix"
+	}
+	if discard {
+		b.imports[soqlPackage] = true
+		b.printf("if _, err := %s.soqlSelect(%s); err != nil {", value, prefix)
+		b.printf("return \"\", soql.WrapMarshalError(err, v, %q, %q)\n}", clause.name, clause.tag)
+		return selectType, nil
+	}
+	b.printf("selectClause, err := %s.soqlSelect(%s)", value, prefix)
+	b.check(clause.name, clause.tag)
+	return selectType, nil
+}
+
+// whereValue generates where or having clause of the query from member of clause, which is a struct or a
+// pointer to struct. table is the expression prefixing its columns.
+func (g *generator) whereValue(b *body, owner *types.Named, clause queryClause, table, variable, keyword string) error {
+	elem := clause.typ
+	if pointer, ok := clause.typ.Underlying().(*types.Pointer); ok {
+		elem = pointer.Elem()
+		b.printf("if v.%s == nil {", clause.name)
+		b.fail(soql.ErrNilValue, clause.name, clause.tag)
+		b.printf("}")
+	}
+	if _, err := g.require(owner, clause.member, elem, whereRole); err != nil {
+		return err
+	}
+	b.printf("%s, err := v.%s.soqlWhere(%s, %q)", variable, clause.name, table, clause.joiner)
+	b.check(clause.name, clause.tag)
+	b.printf("if %s != \"\" {", variable)
+	b.literal(keyword)
+	b.write(variable)
+	b.printf("}")
+	return nil
+}
+
+func (g *generator) groupBy(b *body, owner *types.Named, clause queryClause, mappings map[string]string, tableName string) error {
+	if !types.Identical(clause.typ, types.NewSlice(types.Typ[types.String])) {
+		return g.unsupported(owner, clause.member, "type of groupByClause must be []string")
+	}
+	b.printf("if len(v.%s) > 0 {", clause.name)
+	var function string
+	switch strings.ToLower(clause.groupByType) {
+	case "":
+	case soql.Rollup:
+		function = "ROLLUP("
+	case soql.Cube:
+		function = "CUBE("
+	default:
+		b.fail(soql.ErrInvalidGroupByClause, clause.name, clause.tag)
+		b.printf("}")
+		return nil
+	}
+	b.literal(" GROUP BY " + function)
+	b.printf("for i, name := range v.%s {", clause.name)
+	b.printf("if i > 0 {")
+	b.literal(",")
+	b.printf("}")
+	b.printf("switch name {")
+	for _, name := range sortedKeys(mappings) {
+		if isAggregateColumn(mappings[name]) {
+			continue
+		}
+		b.printf("case %q:", name)
+		b.column(mappings[name], tableName)
+	}
+	b.printf("default:")
+	b.fail(soql.ErrInvalidGroupByClause, clause.name, clause.tag)
+	b.printf("}")
+	b.printf("}")
+	if function != "" {
+		b.literal(")")
+	}
+	b.printf("}")
+	return nil
+}
+
+// orderBy generates the order by clause. It returns true when the clause always fails.
+func (g *generator) orderBy(b *body, owner *types.Named, clause queryClause, mappings map[string]string, tableName string) (bool, error) {
+	value := "v." + clause.name
+	t := clause.typ
+	if pointer, ok := t.Underlying().(*types.Pointer); ok {
+		t = pointer.Elem()
+		b.printf("if %s == nil {", value)
+		b.fail(soql.ErrNilValue, clause.name, clause.tag)
+		b.printf("}")
+		value = "*" + value
+	}
+	if slice, ok := t.Underlying().(*types.Slice); !ok || !isNamed(slice.Elem(), soqlPackage, "Order") {
+		return false, g.unsupported(owner, clause.member, "type of orderByClause must be []soql.Order")
+	}
+	if len(mappings) == 0 {
+		b.fail(soql.ErrInvalidSelectColumnOrderByClause, clause.name, clause.tag)
+		return true, nil
+	}
+	b.printf("if len(%s) > 0 {", value)
+	b.literal(" ORDER BY ")
+	b.printf("for i, order := range %s {", value)
+	b.printf("if i > 0 {")
+	b.literal(",")
+	b.printf("}")
+	b.printf("switch order.Field {")
+	for _, name := range sortedKeys(mappings) {
+		// Blank names are rejected before they are looked up
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		b.printf("case %q:", name)
+		b.column(mappings[name], tableName)
+	}
+	b.printf("default:")
+	b.fail(soql.ErrInvalidOrderByClause, clause.name, clause.tag)
+	b.printf("}")
+	b.printf("if order.IsDesc {")
+	b.literal(" DESC")
+	b.printf("} else {")
+	b.literal(" ASC")
+	b.printf("}")
+	b.printf("}")
+	b.printf("}")
+	return false, nil
+}
+
+// segment is either a literal text or an expression of the select clause
+type segment struct {
+	text string
+	expr string
+}
+
+// part is the text of one member of select struct, which is empty for nested structs without columns and
+// children without members
+type part []segment
+
+// join returns parts separated by commas without the trailing ones, which are trimmed by marshalSelectClause
+func join(parts []part) part {
+	last := -1
+	for i, p := range parts {
+		if len(p) > 0 {
+			last = i
+		}
+	}
+	var joined part
+	for i := 0; i <= last; i++ {
+		if i > 0 {
+			joined = append(joined, segment{text: ","})
+		}
+		joined = append(joined, parts[i]...)
+	}
+	return joined
+}
+
+// selectMethod generates soqlSelect method, which returns the select clause of the struct with columns
+// prefixed with prefix. Whether members are empty depends only on their types, so the commas between them
+// are known in advance.
+func (g *generator) selectMethod(named *types.Named) error {
+	b := g.newBody()
+	parts, stopped, err := g.selectParts(b, named, named.Underlying().(*types.Struct), "", "v", "")
+	if err != nil {
+		return err
+	}
+	if !stopped {
+		joined := join(parts)
+		if len(joined) == 0 {
+			b.printf("return \"\", nil")
+		} else {
+			for _, s := range joined {
+				if s.expr != "" {
+					b.write(s.expr)
+				} else {
+					b.literal(s.text)
+				}
+			}
+			b.printf("return buff.String(), nil")
+		}
+	}
+	g.method(named, selectRole, "prefix string", b)
+	return nil
+}
+
+// selectParts returns the parts of members of select struct s. Nested structs are marshaled from their zero
+// value, so their members are inlined with suffix appended to the prefix and path to the member names of
+// errors. value is the expression of s or empty for zero value. It returns true if s always fails.
+func (g *generator) selectParts(b *body, owner *types.Named, s *types.Struct, suffix, value, path string) ([]part, bool, error) {
+	var parts []part
+	for _, f := range compileSelectPlan(s) {
+		fieldPath := path + f.name
+		if f.err != nil {
+			b.fail(f.err, fieldPath, f.tag)
+			return nil, true, nil
+		}
+		if function, isAggregate := aggregateFunctions[f.clauseKey]; isAggregate {
+			text := suffix + f.fieldName + ")"
+			if f.alias != "" {
+				text += " " + f.alias
+			}
+			parts = append(parts, part{{text: function + "("}, {expr: "prefix"}, {text: text}})
+		} else if f.clauseKey == soql.SelectChild {
+			child, ok := f.typ.Underlying().(*types.Struct)
+			if !ok {
+				return nil, false, g.unsupported(owner, f.member, "type of selectChild must be a struct")
+			}
+			if child.NumFields() == 0 {
+				parts = append(parts, nil)
+				continue
+			}
+			childType, err := g.require(owner, f.member, f.typ, queryRole)
+			if err != nil {
+				return nil, false, err
+			}
+			childValue := "new(" + childType.Obj().Name() + ")"
+			if value != "" {
+				childValue = value + "." + f.name
+			}
+			b.children++
+			variable := fmt.Sprintf("child%d", b.children)
+			b.printf("%s, err := %s.soqlQuery(prefix + %q)", variable, childValue, suffix+f.fieldName)
+			b.check(fieldPath, f.tag)
+			parts = append(parts, part{{expr: variable}})
+		} else if f.clauseKey == soql.SelectTypeOf {
+			typeOfPart, stopped, err := g.typeOf(b, owner, f, fieldPath)
+			if stopped || err != nil {
+				return nil, stopped, err
+			}
+			parts = append(parts, typeOfPart)
+		} else if f.isNested {
+			nestedParts, stopped, err := g.selectParts(b, owner, f.typ.Underlying().(*types.Struct), suffix+f.fieldName+".", "", fieldPath+".")
+			if stopped || err != nil {
+				return nil, stopped, err
+			}
+			parts = append(parts, join(nestedParts))
+		} else {
+			parts = append(parts, part{{expr: "prefix"}, {text: suffix + f.fieldName}})
+		}
+	}
+	return parts, false, nil
+}
+
+// typeOf returns the part of TYPEOF expression of member f tagged with selectTypeOf. The columns of branches are
+// not prefixed, so the part is a literal text unless the branches select children. It returns true if the
+// expression always fails.
+func (g *generator) typeOf(b *body, owner *types.Named, f selectField, path string) (part, bool, error) {
+	plan := compileTypeOfPlan(f.typ.Underlying().(*types.Struct))
+	typeOfPart := part{{text: "TYPEOF " + f.fieldName}}
+	var elsePart part
+	for _, branch := range plan.branches {
+		branchPath := path + "." + branch.name
+		if branch.err != nil {
+			b.fail(branch.err, branchPath, branch.tag)
+			return nil, true, nil
+		}
+		for _, column := range compileSelectPlan(branch.structType) {
+			if column.err != nil {
+				break
+			}
+			if column.clauseKey != soql.SelectColumn {
+				b.fail(soql.ErrInvalidTag, branchPath+"."+column.name, column.tag)
+				return nil, true, nil
+			}
+		}
+		parts, stopped, err := g.selectParts(b, owner, branch.structType, "", "", branchPath+".")
+		if stopped || err != nil {
+			return nil, stopped, err
+		}
+		var columns part
+		for _, s := range join(parts) {
+			if s.expr != "prefix" {
+				columns = append(columns, s)
+			}
+		}
+		if len(columns) == 0 {
+			b.fail(soql.ErrInvalidTag, branchPath, branch.tag)
+			return nil, true, nil
+		}
+		if branch.clauseKey == soql.SelectElse {
+			elsePart = columns
+			continue
+		}
+		typeOfPart = append(typeOfPart, segment{text: " WHEN " + branch.sObject + " THEN "})
+		typeOfPart = append(typeOfPart, columns...)
+	}
+	if plan.err != nil {
+		b.fail(plan.err, path, f.tag)
+		return nil, true, nil
+	}
+	if elsePart != nil {
+		typeOfPart = append(typeOfPart, segment{text: " ELSE "})
+		typeOfPart = append(typeOfPart, elsePart...)
+	}
+	return append(typeOfPart, segment{text: " END"}), false, nil
+}
+
+// whereMethod generates soqlWhere method, which returns the conditions of the struct joined by joiner as
+// marshalWhereClause function of soql package does. table prefixes the columns of conditions.
+func (g *generator) whereMethod(named *types.Named) error {
+	b := g.newBody()
+	for _, f := range compileWherePlan(named.Underlying().(*types.Struct)) {
+		if f.err != nil {
+			// Subqueries only fail when they are neither structs nor pointers
+			if f.clauseKey == soql.Subquery {
+				return g.unsupported(named, f.member, "type of subquery must be a struct, a pointer to struct or a slice of them")
+			}
+			b.fail(f.err, f.name, f.tag)
+			g.method(named, whereRole, "table, joiner string", b)
+			return nil
+		}
+		var err error
+		if f.clauseKey == soql.Subquery {
+			err = g.subquery(b, named, f)
+		} else {
+			g.condition(b, named, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	b.printf("return buff.String(), nil")
+	b.uses["buff"] = true
+	g.method(named, whereRole, "table, joiner string", b)
+	return nil
+}
+
+func (g *generator) subquery(b *body, owner *types.Named, f whereField) error {
+	elem := f.typ
+	switch t := f.typ.Underlying().(type) {
+	case *types.Pointer:
+		elem = t.Elem()
+		b.printf("if v.%s != nil {", f.name)
+	case *types.Slice:
+		elem = t.Elem()
+		b.printf("if len(v.%s) > 0 {", f.name)
+	}
+	guarded := elem != f.typ
+	if f.joinErr != nil {
+		b.fail(f.joinErr, f.name, f.tag)
+		if guarded {
+			b.printf("}")
+		}
+		return nil
+	}
+	b.uses["condition"] = true
+	if f.joiner == inOperator || f.joiner == notInOperator {
+		if m, _, _ := types.LookupFieldOrMethod(f.typ, false, g.pkg, "MarshalSOQL"); m != nil {
+			b.imports[soqlPackage] = true
+			b.printf("condition, err = soql.Marshal(v.%s)", f.name)
+		} else {
+			if _, err := g.require(owner, f.member, elem, queryRole); err != nil {
+				return err
+			}
+			b.printf("condition, err = v.%s.soqlQuery(\"\")", f.name)
+		}
+		b.check(f.name, f.tag)
+		b.printf("if buff.Len() > 0 {\nbuff.WriteString(joiner)\n}")
+		b.literal(f.joinFieldName + f.joiner + "(")
+		b.write("condition")
+		b.literal(")")
+		if guarded {
+			b.printf("}")
+		}
+		return nil
+	}
+	if f.isSlice {
+		if err := g.groups(b, owner, f, elem); err != nil {
+			return err
+		}
+	} else {
+		if _, err := g.require(owner, f.member, elem, whereRole); err != nil {
+			return err
+		}
+		b.printf("condition, err = v.%s.soqlWhere(table, %q)", f.name, f.joiner)
+		b.check(f.name, f.tag)
+	}
+	// Groups without conditions are left out
+	b.printf("if condition != \"\" {\nif buff.Len() > 0 {\nbuff.WriteString(joiner)\n}")
+	if f.not {
+		b.literal("(NOT ")
+	}
+	b.literal("(")
+	b.write("condition")
+	b.literal(")")
+	if f.not {
+		b.literal(")")
+	}
+	b.printf("}")
+	if guarded {
+		b.printf("}")
+	}
+	return nil
+}
+
+// groups sets condition to the conditions of the structs of slice member f joined by OR, without the braces
+// around them, as marshalWhereGroups function of soql package does
+func (g *generator) groups(b *body, owner *types.Named, f whereField, elem types.Type) error {
+	value := "v." + f.name + "[i]"
+	b.printf("var groups strings.Builder\nfor i := range v.%s {", f.name)
+	if pointer, ok := elem.Underlying().(*types.Pointer); ok {
+		elem = pointer.Elem()
+		b.printf("if %s == nil {\ncontinue\n}", value)
+	}
+	if _, err := g.require(owner, f.member, elem, whereRole); err != nil {
+		return err
+	}
+	b.printf("condition, err = %s.soqlWhere(table, %q)", value, f.joiner)
+	b.check(f.name, f.tag)
+	b.printf("if condition != \"\" {\nif groups.Len() > 0 {\ngroups.WriteString(%q)\n}", orCondition)
+	b.printf("groups.WriteString(\"(\" + condition + \")\")\n}\n}")
+	b.printf("condition = groups.String()")
+	return nil
+}
+
+func (g *generator) condition(b *body, owner *types.Named, f whereField) {
+	b.uses["condition"] = true
+	b.uses["prefix"] = true
+	b.imports[soqlPackage] = true
+	value := "v." + f.name
+	deref := dereferences(f.clauseKey, f.typ)
+	if deref {
+		b.printf("if %s != nil {", value)
+		value = "*" + value
+	}
+	tags := "nil"
+	if f.format != nil {
+		tags = "soqlFormat" + owner.Obj().Name() + f.name
+		g.formats = append(g.formats, fmt.Sprintf("%s = map[string]string{soql.Format: %q}\n", tags, *f.format))
+	}
+	operator := "soql." + strings.ToUpper(f.clauseKey[:1]) + f.clauseKey[1:]
+	b.printf("condition, err = soql.BuildCondition(%s, %s, prefix+%q, %s)", operator, value, f.fieldName, tags)
+	b.check(f.name, f.tag)
+	b.printf("if condition != \"\" {\nif buff.Len() > 0 {\nbuff.WriteString(joiner)\n}\nbuff.WriteString(condition)\n}")
+	if deref {
+		b.printf("}")
+	}
+}
+
+// dereferences reports whether the pointer member of type t can be dereferenced before it is passed to the
+// builder of operator, which returns empty condition for nil pointers of these types
+func dereferences(operator string, t types.Type) bool {
+	switch operator {
+	case soql.NullOperator:
+		pointer, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			return false
+		}
+		_, ok = pointer.Elem().Underlying().(*types.Basic)
+		return ok
+	case soql.EqualsOperator, soql.NotEqualsOperator, soql.GreaterThanOperator, soql.GreaterThanOrEqualsToOperator,
+		soql.LessThanOperator, soql.LessThanOrEqualsToOperator, soql.LessOrEqualNextNDaysOperator:
+		pointer, ok := t.(*types.Pointer)
+		if !ok {
+			return false
+		}
+		return isBasic(pointer.Elem(), types.IsNumeric|types.IsBoolean) ||
+			isNamed(pointer.Elem(), "time", "Time") || isNamed(pointer.Elem(), soqlPackage, "DateLiteral")
+	case soql.GreaterNextNDaysOperator, soql.GreaterOrEqualNextNDaysOperator, soql.EqualsNextNDaysOperator,
+		soql.LessNextNDaysOperator, soql.GreaterLastNDaysOperator, soql.GreaterOrEqualLastNDaysOperator,
+		soql.EqualsLastNDaysOperator, soql.LessLastNDaysOperator, soql.LessOrEqualLastNDaysOperator:
+		pointer, ok := t.(*types.Pointer)
+		return ok && isBasic(pointer.Elem(), types.IsInteger)
+	}
+	return false
+}
+
+// isBasic reports whether t is a predeclared type with info other than complex numbers and uintptr, which are
+// not supported by the operators
+func isBasic(t types.Type, info types.BasicInfo) bool {
+	basic, ok := t.(*types.Basic)
+	return ok && basic.Info()&info != 0 && basic.Info()&types.IsComplex == 0 && basic.Kind() != types.Uintptr
+}
+
+func isAggregateColumn(columnName string) bool {
+	return strings.HasSuffix(columnName, ")")
+}
+
+// prefixColumn prepends tableName to columnName, inside the function for aggregate columns
+func prefixColumn(tableName, columnName string) string {
+	if indx := strings.Index(columnName, "("); indx >= 0 && isAggregateColumn(columnName) {
+		return columnName[:indx+1] + tableName + "." + columnName[indx+1:]
+	}
+	return tableName + "." + columnName
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// body is the body of a generated method. Literals written to buff are merged until the next statement.
+type body struct {
+	code     bytes.Buffer
+	pending  strings.Builder
+	uses     map[string]bool
+	imports  map[string]bool
+	children int
+}
+
+func (g *generator) newBody() *body {
+	return &body{uses: make(map[string]bool), imports: g.imports}
+}
+
+func (b *body) printf(format string, args ...interface{}) {
+	b.flush()
+	fmt.Fprintf(&b.code, format, args...)
+	b.code.WriteByte('\n')
+}
+
+func (b *body) flush() {
+	if b.pending.Len() == 0 {
+		return
+	}
+	b.uses["buff"] = true
+	fmt.Fprintf(&b.code, "buff.WriteString(%q)\n", b.pending.String())
+	b.pending.Reset()
+}
+
+func (b *body) literal(text string) {
+	b.pending.WriteString(text)
+}
+
+func (b *body) write(expr string) {
+	b.printf("buff.WriteString(%s)", expr)
+	b.uses["buff"] = true
+}
+
+// column writes columnName prefixed with tableName in subqueries
+func (b *body) column(columnName, tableName string) {
+	if tableName == "" {
+		b.literal(columnName)
+		return
+	}
+	b.printf("if child != \"\" {")
+	b.literal(prefixColumn(tableName, columnName))
+	b.printf("} else {")
+	b.literal(columnName)
+	b.printf("}")
+}
+
+// fail returns err, which is one of the sentinel errors of soql package, for member field with tag
+func (b *body) fail(err error, field, tag string) {
+	b.imports[soqlPackage] = true
+	// The names of sentinel errors are their messages
+	b.printf("return \"\", soql.WrapMarshalError(soql.%s, v, %q, %q)", err.Error(), field, tag)
+}
+
+// check returns err of the last statement annotated with member field with tag
+func (b *body) check(field, tag string) {
+	b.imports[soqlPackage] = true
+	b.printf("if err != nil {\nreturn \"\", soql.WrapMarshalError(err, v, %q, %q)\n}", field, tag)
+}
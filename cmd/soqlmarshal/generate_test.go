@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var pkg *types.Package
+
+	Context("when types are soql structs", func() {
+		output := filepath.Join("internal", "fixtures", "fixtures_soql.go")
+
+		BeforeEach(func() {
+			var loadErr error
+			pkg, loadErr = loadPackage(filepath.Join("internal", "fixtures"), output)
+			Expect(loadErr).ToNot(HaveOccurred())
+		})
+
+		It("returns the generated file of fixtures", func() {
+			source, err := generate(pkg, []string{"HostQuery", "AggregateQuery", "ParentQuery", "InvalidTagQuery",
+				"InvalidWhereQuery", "InvalidTypeOfQuery", "UnlimitedQuery", "UnknownClauseQuery", "NoSelectQuery", "EmptyQuery"})
+			Expect(err).ToNot(HaveOccurred())
+			expected, readErr := ioutil.ReadFile(output)
+			Expect(readErr).ToNot(HaveOccurred())
+			Expect(string(source)).To(Equal(string(expected)))
+		})
+	})
+
+	Context("when types are not supported", func() {
+		BeforeEach(func() {
+			var loadErr error
+			pkg, loadErr = loadPackage(filepath.Join("testdata", "unsupported"), "")
+			Expect(loadErr).ToNot(HaveOccurred())
+		})
+
+		for _, c := range []struct{ typeName, message string }{
+			{"Missing", "type Missing not found in package unsupported"},
+			{"Name", "type Name is not a struct"},
+			{"Marshaled", "type Marshaled already has MarshalSOQL"},
+			{"StringWhere", "StringWhere.WhereClause: string is not a struct of package unsupported"},
+			{"TimeSelect", "TimeSelect.SelectClause: time.Time is not a struct of package unsupported"},
+			{"IntLimit", "IntLimit.LimitClause: type of limitClause must be *int"},
+			{"NamedGroupBy", "NamedGroupBy.GroupByClause: type of groupByClause must be []string"},
+			{"OrderByStrings", "OrderByStrings.OrderByClause: type of orderByClause must be []soql.Order"},
+			{"SliceChild", "ChildSelect.Versions: type of selectChild must be a struct"},
+			{"StringsGroup", "StringsCriteria.Names: type of subquery must be a struct, a pointer to struct or a slice of them"},
+			{"StringWith", "StringWith.WithClause: type of withClause must be soql.SecurityMode"},
+			{"StringFor", "StringFor.ForClause: type of forClause must be soql.ForMode"},
+			{"StringScope", "StringScope.UsingScope: type of usingScope must be soql.FilterScope"},
+		} {
+			c := c
+			It("returns error for "+c.typeName, func() {
+				_, err := generate(pkg, []string{c.typeName})
+				Expect(err).To(MatchError(c.message))
+			})
+		}
+	})
+})
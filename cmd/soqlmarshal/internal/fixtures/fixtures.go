@@ -0,0 +1,300 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package fixtures has soql structs whose MarshalSOQL methods generated by soqlmarshal are compared with
+// soql.Marshal.
+package fixtures
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+//go:generate go run github.com/forcedotcom/go-soql/cmd/soqlmarshal -type=HostQuery,AggregateQuery,ParentQuery,InvalidTagQuery,InvalidWhereQuery,InvalidTypeOfQuery,UnlimitedQuery,UnknownClauseQuery,NoSelectQuery,EmptyQuery -output=fixtures_soql.go
+
+type HostQuery struct {
+	SelectClause  *Host             `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	UsingScope    soql.FilterScope  `soql:"usingScope"`
+	WhereClause   HostCriteria      `soql:"whereClause"`
+	WithClause    soql.SecurityMode `soql:"withClause"`
+	OrderByClause []soql.Order      `soql:"orderByClause"`
+	LimitClause   *int              `soql:"limitClause"`
+	OffsetClause  *int              `soql:"offsetClause"`
+	ForClause     soql.ForMode      `soql:"forClause"`
+	NonSoql       NonSoqlStruct     `json:"nonSoql"`
+}
+
+type NonSoqlStruct struct {
+	Key string
+}
+
+type Host struct {
+	ID          string     `soql:"selectColumn,fieldName=Id"`
+	Name        string     `soql:"selectColumn,fieldName=Name__c"`
+	Role        Role       `soql:"selectColumn,fieldName=Role__r"`
+	Discovered  time.Time  `soql:"selectColumn,fieldName=Last_Discovered_Date__c"`
+	Versions    Versions   `soql:"selectChild,fieldName=Application_Versions__r"`
+	NoColumns   NoColumns  `soql:"selectColumn,fieldName=Empty__r"`
+	NoMembers   struct{}   `soql:"selectChild,fieldName=Nothing__r"`
+	NonSoql     string     `json:"nonSoql"`
+	Environment *string    `soql:"selectColumn,fieldName=Environment__c"`
+	Parent      ParentHost `soql:"selectColumn,fieldName=Parent__r"`
+	Owner       Owner      `soql:"selectTypeOf,fieldName=Owner"`
+}
+
+// Owner selects the columns of the polymorphic Owner relationship
+type Owner struct {
+	User  *Role `soql:"selectWhen"`
+	Queue Queue `soql:"selectWhen,tableName=Group"`
+	Other Role  `soql:"selectElse"`
+}
+
+type Queue struct {
+	Name  string    `soql:"selectColumn,fieldName=Name"`
+	Role  Role      `soql:"selectColumn,fieldName=Role__r"`
+	Empty NoColumns `soql:"selectColumn,fieldName=Empty__r"`
+}
+
+type Role struct {
+	Name string `soql:"selectColumn,fieldName=Name"`
+}
+
+type NoColumns struct {
+	Value string
+}
+
+type ParentHost struct {
+	Name     string    `soql:"selectColumn,fieldName=Name__c"`
+	Role     Role      `soql:"selectColumn,fieldName=Role__r"`
+	Versions Versions  `soql:"selectChild,fieldName=Application_Versions__r"`
+	Empty    NoColumns `soql:"selectColumn,fieldName=Empty__r"`
+}
+
+type Versions struct {
+	SelectClause  []*Version      `soql:"selectClause,tableName=SM_Application_Versions__c,fields=Custom"`
+	WhereClause   VersionCriteria `soql:"whereClause,joiner=or"`
+	OrderByClause []soql.Order    `soql:"orderByClause"`
+	LimitClause   *int            `soql:"limitClause"`
+}
+
+type Version struct {
+	Version string `soql:"selectColumn,fieldName=Version__c"`
+	Role    Role   `soql:"selectColumn,fieldName=Role__r"`
+	Count   int    `soql:"selectCount,fieldName=Id,alias=total"`
+}
+
+type VersionCriteria struct {
+	Versions []string         `soql:"inOperator,fieldName=Version__c"`
+	Current  *bool            `soql:"nullOperator,fieldName=Current__c"`
+	Group    *VersionCriteria `soql:"subquery,joiner=and"`
+}
+
+type HostCriteria struct {
+	Patterns   []string           `soql:"likeOperator,fieldName=Host_Name__c"`
+	Excluded   []string           `soql:"notLikeOperator,fieldName=Host_Name__c"`
+	Roles      []string           `soql:"inOperator,fieldName=Role__r.Name"`
+	NotRoles   []string           `soql:"notInOperator,fieldName=Role__r.Name"`
+	Cores      []int              `soql:"inOperator,fieldName=Num_of_CPU_Cores__c"`
+	Days       []soql.DateLiteral `soql:"inOperator,fieldName=CreatedDate"`
+	Dates      []time.Time        `soql:"notInOperator,fieldName=Birth_Date__c,format=2006-01-02"`
+	Tags       []string           `soql:"includesOperator,fieldName=Tags__c"`
+	TagSets    [][]string         `soql:"excludesOperator,fieldName=Tags__c"`
+	Type       string             `soql:"equalsOperator,fieldName=Type__c"`
+	NotType    string             `soql:"notEqualsOperator,fieldName=Type__c"`
+	MinCores   *int               `soql:"greaterThanOrEqualsToOperator,fieldName=Num_of_CPU_Cores__c"`
+	MaxCores   int8               `soql:"lessThanOperator,fieldName=Num_of_CPU_Cores__c"`
+	Weight     *float64           `soql:"greaterThanOperator,fieldName=Weight__c"`
+	Active     *bool              `soql:"equalsOperator,fieldName=Active__c"`
+	Discovered *bool              `soql:"nullOperator,fieldName=Last_Discovered_Date__c"`
+	Since      *time.Time         `soql:"greaterThanOperator,fieldName=CreatedDate"`
+	Until      time.Time          `soql:"lessThanOrEqualsToOperator,fieldName=Birth_Date__c,format=2006-01-02"`
+	Modified   soql.DateLiteral   `soql:"equalsOperator,fieldName=LastModifiedDate"`
+	Expiring   *uint              `soql:"lessOrEqualNextNDaysOperator,fieldName=Expiry__c"`
+	Created    uint8              `soql:"greaterLastNDaysOperator,fieldName=CreatedDate"`
+	Renewed    *int64             `soql:"equalsNextNDaysOperator,fieldName=Renewal__c"`
+	Either     *HostGroup         `soql:"subquery,joiner=or"`
+	Both       HostGroup          `soql:"subquery"`
+	Apps       *AppQuery          `soql:"subquery,joiner=in,fieldName=Id"`
+	NotApps    AppQuery           `soql:"subquery,joiner=not in,fieldName=Id"`
+	Roled      *RoleQuery         `soql:"subquery,joiner=in,fieldName=Role__c"`
+	Unlike     *HostGroup         `soql:"subquery,joiner=or,not"`
+	Any        []HostGroup        `soql:"subquery"`
+	None       []*HostGroup       `soql:"subquery,joiner=or,not"`
+}
+
+// Status is written as its underlying string
+type Status string
+
+// Priority is written as its name
+type Priority uint8
+
+func (p Priority) String() string {
+	return [...]string{"Low", "Medium", "High", "Critical"}[p%4]
+}
+
+// Amount is written as unquoted decimal number of cents
+type Amount int64
+
+func (a Amount) SOQLValue() (string, bool) {
+	return strconv.FormatFloat(float64(a)/100, 'f', 2, 64), false
+}
+
+type HostGroup struct {
+	Statuses   []string   `soql:"inOperator,fieldName=Status__c"`
+	Owner      string     `soql:"equalsOperator,fieldName=Owner.Name"`
+	Status     Status     `soql:"notEqualsOperator,fieldName=Status__c"`
+	Kinds      []Status   `soql:"likeOperator,fieldName=Kind__c"`
+	Priorities []Priority `soql:"notInOperator,fieldName=Priority__c"`
+	MinAmount  *Amount    `soql:"greaterThanOrEqualsToOperator,fieldName=Amount__c"`
+	Nested     *HostGroup `soql:"subquery,joiner=or"`
+}
+
+type AppQuery struct {
+	SelectClause AppHost     `soql:"selectClause,tableName=SM_Application_Host__c"`
+	WhereClause  AppCriteria `soql:"whereClause"`
+}
+
+type AppHost struct {
+	HostID string `soql:"selectColumn,fieldName=Host__c"`
+}
+
+type AppCriteria struct {
+	Names []string `soql:"inOperator,fieldName=Application__r.Name"`
+}
+
+// RoleQuery marshals itself
+type RoleQuery struct {
+	Name string
+}
+
+func (q RoleQuery) MarshalSOQL() (string, error) {
+	if q.Name == "" {
+		return "", soql.ErrNilValue
+	}
+	return "SELECT Id FROM SM_Role__c WHERE Name = '" + q.Name + "'", nil
+}
+
+type AggregateQuery struct {
+	SelectClause  []Aggregate      `soql:"selectClause,tableName=Case"`
+	WhereClause   *AggregateFilter `soql:"whereClause,joiner=OR"`
+	GroupByClause []string         `soql:"groupByClause,type=rollup"`
+	HavingClause  *AggregateFilter `soql:"havingClause"`
+	OrderByClause *[]soql.Order    `soql:"orderByClause"`
+	LimitClause   *int             `soql:"limitClause"`
+	OffsetClause  *int             `soql:"offsetClause"`
+	ForClause     soql.ForMode     `soql:"forClause"`
+}
+
+type Aggregate struct {
+	Status   string    `soql:"selectColumn,fieldName=Status"`
+	Owner    Role      `soql:"selectColumn,fieldName=Owner"`
+	Count    int       `soql:"selectCount,fieldName=Id,alias=cnt"`
+	Total    float64   `soql:"selectSum,fieldName=Amount__c"`
+	Grouping int       `soql:"selectGrouping,fieldName=Status,alias=Status"`
+	Earliest time.Time `soql:"selectMin,fieldName=CreatedDate"`
+}
+
+type AggregateFilter struct {
+	Statuses []string `soql:"inOperator,fieldName=Status"`
+	MinCount *int     `soql:"greaterThanOperator,fieldName=COUNT(Id)"`
+}
+
+type ParentQuery struct {
+	SelectClause ParentSelect `soql:"selectClause,tableName=Account"`
+}
+
+type ParentSelect struct {
+	ID         string         `soql:"selectColumn,fieldName=Id"`
+	Cases      ChildQuery     `soql:"selectChild,fieldName=Cases"`
+	Aggregated AggregateQuery `soql:"selectChild,fieldName=Aggregated__r"`
+}
+
+// ChildQuery is marshaled as the child of ParentQuery
+type ChildQuery struct {
+	SelectClause  Aggregate         `soql:"selectClause,tableName=,fields=standard"`
+	UsingScope    soql.FilterScope  `soql:"usingScope"`
+	WhereClause   *AggregateFilter  `soql:"whereClause"`
+	WithClause    soql.SecurityMode `soql:"withClause"`
+	GroupByClause []string          `soql:"groupByClause,type=CUBE"`
+	OrderByClause []soql.Order      `soql:"orderByClause"`
+}
+
+type InvalidTagQuery struct {
+	SelectClause  NoColumns    `soql:"selectClause,tableName=Account"`
+	WhereClause   InvalidTag   `soql:"whereClause"`
+	GroupByClause []string     `soql:"groupByClause,type=grouping"`
+	OrderByClause []soql.Order `soql:"orderByClause"`
+	LimitClause   *int         `soql:"limitClause"`
+}
+
+type InvalidTag struct {
+	Name   string       `soql:"equalsOperator,fieldName=Name"`
+	Group  *HostGroup   `soql:"subquery,joiner=in"`
+	Xor    *HostGroup   `soql:"subquery,joiner=xor"`
+	Broken *BrokenGroup `soql:"subquery"`
+	Typed  *TypedGroup  `soql:"subquery"`
+	Listed []AppQuery   `soql:"subquery,joiner=in,fieldName=Id"`
+	NotIn  *AppQuery    `soql:"subquery,joiner=in,fieldName=Id,not"`
+}
+
+type BrokenGroup struct {
+	Other string `soql:"lessOperator,fieldName=Other"`
+}
+
+// TypedGroup has a member of type not supported by the operators
+type TypedGroup struct {
+	Labels map[string]string `soql:"equalsOperator,fieldName=Labels__c"`
+}
+
+// UnlimitedQuery selects FIELDS(ALL) without limitClause
+type UnlimitedQuery struct {
+	SelectClause Queue `soql:"selectClause,tableName=Group,fields=all"`
+	OffsetClause *int  `soql:"offsetClause"`
+}
+
+type UnknownClauseQuery struct {
+	SelectClause Host   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	Invalid      string `soql:"selectColum"`
+}
+
+type InvalidWhereQuery struct {
+	SelectClause InvalidSelect `soql:"selectClause,tableName=Account"`
+	WhereClause  *HostGroup    `soql:"whereClause,joiner=nor"`
+}
+
+type InvalidTypeOfQuery struct {
+	SelectClause []InvalidTypeOfSelect `soql:"selectClause,tableName=Task"`
+}
+
+type InvalidTypeOfSelect struct {
+	ID   string        `soql:"selectColumn,fieldName=Id"`
+	What InvalidTypeOf `soql:"selectTypeOf"`
+}
+
+// InvalidTypeOf selects a child relationship in a branch of TYPEOF
+type InvalidTypeOf struct {
+	Account Role      `soql:"selectWhen"`
+	Lead    ChildHost `soql:"selectWhen"`
+}
+
+type ChildHost struct {
+	Versions Versions `soql:"selectChild,fieldName=Application_Versions__r"`
+}
+
+type InvalidSelect struct {
+	Name string `soql:"selectColumn,fieldName="`
+}
+
+type NoSelectQuery struct {
+	WhereClause HostGroup `soql:"whereClause"`
+}
+
+type EmptyQuery struct {
+	Value string
+}
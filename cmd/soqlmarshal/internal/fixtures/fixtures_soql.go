@@ -0,0 +1,1586 @@
+// Code generated by soqlmarshal. DO NOT EDIT.
+
+package fixtures
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var (
+	soqlFormatHostCriteriaDates = map[string]string{soql.Format: "2006-01-02"}
+	soqlFormatHostCriteriaUntil = map[string]string{soql.Format: "2006-01-02"}
+)
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v HostQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v AggregateQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v ParentQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v InvalidTagQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v InvalidWhereQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v InvalidTypeOfQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v UnlimitedQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v UnknownClauseQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v NoSelectQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+// MarshalSOQL returns the SOQL query of v, as soql.Marshal does.
+func (v EmptyQuery) MarshalSOQL() (string, error) {
+	return v.soqlQuery("")
+}
+
+func (v *HostQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	if v.SelectClause == nil {
+		return "", soql.WrapMarshalError(soql.ErrNilValue, v, "SelectClause", "selectClause,tableName=SM_Logical_Host__c")
+	}
+	prefix := ""
+	if child != "" {
+		prefix = "SM_Logical_Host__c."
+	}
+	selectClause, err := v.SelectClause.soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=SM_Logical_Host__c")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT ")
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("SM_Logical_Host__c")
+	}
+	if v.UsingScope != "" {
+		buff.WriteString(" USING SCOPE ")
+		switch strings.ToLower(string(v.UsingScope)) {
+		case "delegated":
+			buff.WriteString("delegated")
+		case "everything":
+			buff.WriteString("everything")
+		case "mine":
+			buff.WriteString("mine")
+		case "mine_and_my_groups":
+			buff.WriteString("mine_and_my_groups")
+		case "my_territory":
+			buff.WriteString("my_territory")
+		case "my_team_territory":
+			buff.WriteString("my_team_territory")
+		case "team":
+			buff.WriteString("team")
+		case "scopingrule":
+			buff.WriteString("scopingRule")
+		default:
+			return "", soql.WrapMarshalError(soql.ErrInvalidUsingScope, v, "UsingScope", "usingScope")
+		}
+	}
+	relation := ""
+	if child != "" {
+		relation = "SM_Logical_Host__c"
+	}
+	whereClause, err := v.WhereClause.soqlWhere(relation, " AND ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "WhereClause", "whereClause")
+	}
+	if whereClause != "" {
+		buff.WriteString(" WHERE ")
+		buff.WriteString(whereClause)
+	}
+	if v.WithClause != "" {
+		mode := strings.ToUpper(string(v.WithClause))
+		if mode != "SECURITY_ENFORCED" && (child != "" || (mode != "USER_MODE" && mode != "SYSTEM_MODE")) {
+			return "", soql.WrapMarshalError(soql.ErrInvalidWithClause, v, "WithClause", "withClause")
+		}
+		buff.WriteString(" WITH ")
+		buff.WriteString(mode)
+	}
+	if len(v.OrderByClause) > 0 {
+		buff.WriteString(" ORDER BY ")
+		for i, order := range v.OrderByClause {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			switch order.Field {
+			case "Discovered":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Last_Discovered_Date__c")
+				} else {
+					buff.WriteString("Last_Discovered_Date__c")
+				}
+			case "Environment":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Environment__c")
+				} else {
+					buff.WriteString("Environment__c")
+				}
+			case "ID":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Id")
+				} else {
+					buff.WriteString("Id")
+				}
+			case "Name":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Name__c")
+				} else {
+					buff.WriteString("Name__c")
+				}
+			case "NoColumns":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Empty__r")
+				} else {
+					buff.WriteString("Empty__r")
+				}
+			case "Parent":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Parent__r")
+				} else {
+					buff.WriteString("Parent__r")
+				}
+			case "Parent.Empty":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Parent__r.Empty__r")
+				} else {
+					buff.WriteString("Parent__r.Empty__r")
+				}
+			case "Parent.Name":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Parent__r.Name__c")
+				} else {
+					buff.WriteString("Parent__r.Name__c")
+				}
+			case "Parent.Role":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Parent__r.Role__r")
+				} else {
+					buff.WriteString("Parent__r.Role__r")
+				}
+			case "Parent.Role.Name":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Parent__r.Role__r.Name")
+				} else {
+					buff.WriteString("Parent__r.Role__r.Name")
+				}
+			case "Role":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Role__r")
+				} else {
+					buff.WriteString("Role__r")
+				}
+			case "Role.Name":
+				if child != "" {
+					buff.WriteString("SM_Logical_Host__c.Role__r.Name")
+				} else {
+					buff.WriteString("Role__r.Name")
+				}
+			default:
+				return "", soql.WrapMarshalError(soql.ErrInvalidOrderByClause, v, "OrderByClause", "orderByClause")
+			}
+			if order.IsDesc {
+				buff.WriteString(" DESC")
+			} else {
+				buff.WriteString(" ASC")
+			}
+		}
+	}
+	if v.LimitClause != nil {
+		if *v.LimitClause < 0 {
+			return "", soql.WrapMarshalError(soql.ErrInvalidLimitClause, v, "LimitClause", "limitClause")
+		}
+		buff.WriteString(" LIMIT ")
+		buff.WriteString(strconv.Itoa(*v.LimitClause))
+	}
+	if v.OffsetClause != nil {
+		if *v.OffsetClause < 0 {
+			return "", soql.WrapMarshalError(soql.ErrInvalidOffsetClause, v, "OffsetClause", "offsetClause")
+		}
+		buff.WriteString(" OFFSET ")
+		buff.WriteString(strconv.Itoa(*v.OffsetClause))
+	}
+	if v.ForClause != "" {
+		mode := strings.ToUpper(string(v.ForClause))
+		if (mode != "VIEW" && mode != "REFERENCE" && mode != "UPDATE") || child != "" || (mode == "UPDATE" && len(v.OrderByClause) > 0) {
+			return "", soql.WrapMarshalError(soql.ErrInvalidForClause, v, "ForClause", "forClause")
+		}
+		buff.WriteString(" FOR ")
+		buff.WriteString(mode)
+	}
+	if child != "" {
+		buff.WriteString(")")
+	}
+	return buff.String(), nil
+}
+
+func (v *AggregateQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if child != "" {
+		prefix = "Case."
+	}
+	selectClause, err := new(Aggregate).soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=Case")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT ")
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("Case")
+	}
+	relation := ""
+	if child != "" {
+		relation = "Case"
+	}
+	if v.WhereClause == nil {
+		return "", soql.WrapMarshalError(soql.ErrNilValue, v, "WhereClause", "whereClause,joiner=OR")
+	}
+	whereClause, err := v.WhereClause.soqlWhere(relation, " OR ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "WhereClause", "whereClause,joiner=OR")
+	}
+	if whereClause != "" {
+		buff.WriteString(" WHERE ")
+		buff.WriteString(whereClause)
+	}
+	if len(v.GroupByClause) > 0 {
+		buff.WriteString(" GROUP BY ROLLUP(")
+		for i, name := range v.GroupByClause {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			switch name {
+			case "Owner":
+				if child != "" {
+					buff.WriteString("Case.Owner")
+				} else {
+					buff.WriteString("Owner")
+				}
+			case "Owner.Name":
+				if child != "" {
+					buff.WriteString("Case.Owner.Name")
+				} else {
+					buff.WriteString("Owner.Name")
+				}
+			case "Status":
+				if child != "" {
+					buff.WriteString("Case.Status")
+				} else {
+					buff.WriteString("Status")
+				}
+			default:
+				return "", soql.WrapMarshalError(soql.ErrInvalidGroupByClause, v, "GroupByClause", "groupByClause,type=rollup")
+			}
+		}
+		buff.WriteString(")")
+	}
+	if v.HavingClause == nil {
+		return "", soql.WrapMarshalError(soql.ErrNilValue, v, "HavingClause", "havingClause")
+	}
+	havingClause, err := v.HavingClause.soqlWhere("", " AND ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "HavingClause", "havingClause")
+	}
+	if havingClause != "" {
+		buff.WriteString(" HAVING ")
+		buff.WriteString(havingClause)
+	}
+	if v.OrderByClause == nil {
+		return "", soql.WrapMarshalError(soql.ErrNilValue, v, "OrderByClause", "orderByClause")
+	}
+	if len(*v.OrderByClause) > 0 {
+		buff.WriteString(" ORDER BY ")
+		for i, order := range *v.OrderByClause {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			switch order.Field {
+			case "Count":
+				if child != "" {
+					buff.WriteString("COUNT(Case.Id)")
+				} else {
+					buff.WriteString("COUNT(Id)")
+				}
+			case "Earliest":
+				if child != "" {
+					buff.WriteString("MIN(Case.CreatedDate)")
+				} else {
+					buff.WriteString("MIN(CreatedDate)")
+				}
+			case "Grouping":
+				if child != "" {
+					buff.WriteString("GROUPING(Case.Status)")
+				} else {
+					buff.WriteString("GROUPING(Status)")
+				}
+			case "Owner":
+				if child != "" {
+					buff.WriteString("Case.Owner")
+				} else {
+					buff.WriteString("Owner")
+				}
+			case "Owner.Name":
+				if child != "" {
+					buff.WriteString("Case.Owner.Name")
+				} else {
+					buff.WriteString("Owner.Name")
+				}
+			case "Status":
+				if child != "" {
+					buff.WriteString("Case.Status")
+				} else {
+					buff.WriteString("Status")
+				}
+			case "Total":
+				if child != "" {
+					buff.WriteString("SUM(Case.Amount__c)")
+				} else {
+					buff.WriteString("SUM(Amount__c)")
+				}
+			case "cnt":
+				if child != "" {
+					buff.WriteString("COUNT(Case.Id)")
+				} else {
+					buff.WriteString("COUNT(Id)")
+				}
+			default:
+				return "", soql.WrapMarshalError(soql.ErrInvalidOrderByClause, v, "OrderByClause", "orderByClause")
+			}
+			if order.IsDesc {
+				buff.WriteString(" DESC")
+			} else {
+				buff.WriteString(" ASC")
+			}
+		}
+	}
+	if v.LimitClause != nil {
+		if *v.LimitClause < 0 {
+			return "", soql.WrapMarshalError(soql.ErrInvalidLimitClause, v, "LimitClause", "limitClause")
+		}
+		buff.WriteString(" LIMIT ")
+		buff.WriteString(strconv.Itoa(*v.LimitClause))
+	}
+	if v.OffsetClause != nil {
+		if *v.OffsetClause < 0 {
+			return "", soql.WrapMarshalError(soql.ErrInvalidOffsetClause, v, "OffsetClause", "offsetClause")
+		}
+		buff.WriteString(" OFFSET ")
+		buff.WriteString(strconv.Itoa(*v.OffsetClause))
+	}
+	if v.ForClause != "" {
+		mode := strings.ToUpper(string(v.ForClause))
+		if (mode != "VIEW" && mode != "REFERENCE" && mode != "UPDATE") || child != "" || (mode == "UPDATE" && len(*v.OrderByClause) > 0) {
+			return "", soql.WrapMarshalError(soql.ErrInvalidForClause, v, "ForClause", "forClause")
+		}
+		buff.WriteString(" FOR ")
+		buff.WriteString(mode)
+	}
+	if child != "" {
+		buff.WriteString(")")
+	}
+	return buff.String(), nil
+}
+
+func (v *ParentQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if child != "" {
+		prefix = "Account."
+	}
+	selectClause, err := v.SelectClause.soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=Account")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT ")
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("Account")
+	}
+	if child != "" {
+		buff.WriteString(")")
+	}
+	return buff.String(), nil
+}
+
+func (v *InvalidTagQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if child != "" {
+		prefix = "Account."
+	}
+	selectClause, err := v.SelectClause.soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=Account")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT ")
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("Account")
+	}
+	relation := ""
+	if child != "" {
+		relation = "Account"
+	}
+	whereClause, err := v.WhereClause.soqlWhere(relation, " AND ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "WhereClause", "whereClause")
+	}
+	if whereClause != "" {
+		buff.WriteString(" WHERE ")
+		buff.WriteString(whereClause)
+	}
+	if len(v.GroupByClause) > 0 {
+		return "", soql.WrapMarshalError(soql.ErrInvalidGroupByClause, v, "GroupByClause", "groupByClause,type=grouping")
+	}
+	return "", soql.WrapMarshalError(soql.ErrInvalidSelectColumnOrderByClause, v, "OrderByClause", "orderByClause")
+}
+
+func (v *InvalidWhereQuery) soqlQuery(child string) (string, error) {
+	prefix := ""
+	if child != "" {
+		prefix = "Account."
+	}
+	if _, err := v.SelectClause.soqlSelect(prefix); err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=Account")
+	}
+	return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "WhereClause", "whereClause,joiner=nor")
+}
+
+func (v *InvalidTypeOfQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if child != "" {
+		prefix = "Task."
+	}
+	selectClause, err := new(InvalidTypeOfSelect).soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=Task")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT ")
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("Task")
+	}
+	if child != "" {
+		buff.WriteString(")")
+	}
+	return buff.String(), nil
+}
+
+func (v *UnlimitedQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if child != "" {
+		prefix = "Group."
+	}
+	selectClause, err := v.SelectClause.soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=Group,fields=all")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT FIELDS(ALL)")
+	if selectClause != "" {
+		buff.WriteString(",")
+	}
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("Group")
+	}
+	if v.OffsetClause != nil {
+		if *v.OffsetClause < 0 {
+			return "", soql.WrapMarshalError(soql.ErrInvalidOffsetClause, v, "OffsetClause", "offsetClause")
+		}
+		buff.WriteString(" OFFSET ")
+		buff.WriteString(strconv.Itoa(*v.OffsetClause))
+	}
+	return "", soql.WrapMarshalError(soql.ErrInvalidFieldsLimit, v, "SelectClause", "selectClause,tableName=Group,fields=all")
+}
+
+func (v *UnknownClauseQuery) soqlQuery(child string) (string, error) {
+	prefix := ""
+	if child != "" {
+		prefix = "SM_Logical_Host__c."
+	}
+	if _, err := v.SelectClause.soqlSelect(prefix); err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=SM_Logical_Host__c")
+	}
+	return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "Invalid", "selectColum")
+}
+
+func (v *NoSelectQuery) soqlQuery(child string) (string, error) {
+	return "", soql.WrapMarshalError(soql.ErrNoSelectClause, v, "", "")
+}
+
+func (v *EmptyQuery) soqlQuery(child string) (string, error) {
+	if child != "" {
+		return "()", nil
+	}
+	return "", nil
+}
+
+func (v *Host) soqlSelect(prefix string) (string, error) {
+	var buff strings.Builder
+	child1, err := v.Versions.soqlQuery(prefix + "Application_Versions__r")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Versions", "selectChild,fieldName=Application_Versions__r")
+	}
+	child2, err := new(Versions).soqlQuery(prefix + "Parent__r.Application_Versions__r")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Parent.Versions", "selectChild,fieldName=Application_Versions__r")
+	}
+	buff.WriteString(prefix)
+	buff.WriteString("Id,")
+	buff.WriteString(prefix)
+	buff.WriteString("Name__c,")
+	buff.WriteString(prefix)
+	buff.WriteString("Role__r.Name,")
+	buff.WriteString(prefix)
+	buff.WriteString("Last_Discovered_Date__c,")
+	buff.WriteString(child1)
+	buff.WriteString(",,,")
+	buff.WriteString(prefix)
+	buff.WriteString("Environment__c,")
+	buff.WriteString(prefix)
+	buff.WriteString("Parent__r.Name__c,")
+	buff.WriteString(prefix)
+	buff.WriteString("Parent__r.Role__r.Name,")
+	buff.WriteString(child2)
+	buff.WriteString(",TYPEOF Owner WHEN User THEN Name WHEN Group THEN Name,Role__r.Name ELSE Name END")
+	return buff.String(), nil
+}
+
+func (v *HostCriteria) soqlWhere(table, joiner string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	var condition string
+	var err error
+	condition, err = soql.BuildCondition(soql.LikeOperator, v.Patterns, prefix+"Host_Name__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Patterns", "likeOperator,fieldName=Host_Name__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.NotLikeOperator, v.Excluded, prefix+"Host_Name__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Excluded", "notLikeOperator,fieldName=Host_Name__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.InOperator, v.Roles, prefix+"Role__r.Name", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Roles", "inOperator,fieldName=Role__r.Name")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.NotInOperator, v.NotRoles, prefix+"Role__r.Name", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "NotRoles", "notInOperator,fieldName=Role__r.Name")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.InOperator, v.Cores, prefix+"Num_of_CPU_Cores__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Cores", "inOperator,fieldName=Num_of_CPU_Cores__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.InOperator, v.Days, prefix+"CreatedDate", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Days", "inOperator,fieldName=CreatedDate")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.NotInOperator, v.Dates, prefix+"Birth_Date__c", soqlFormatHostCriteriaDates)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Dates", "notInOperator,fieldName=Birth_Date__c,format=2006-01-02")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.IncludesOperator, v.Tags, prefix+"Tags__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Tags", "includesOperator,fieldName=Tags__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.ExcludesOperator, v.TagSets, prefix+"Tags__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "TagSets", "excludesOperator,fieldName=Tags__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.EqualsOperator, v.Type, prefix+"Type__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Type", "equalsOperator,fieldName=Type__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.NotEqualsOperator, v.NotType, prefix+"Type__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "NotType", "notEqualsOperator,fieldName=Type__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.MinCores != nil {
+		condition, err = soql.BuildCondition(soql.GreaterThanOrEqualsToOperator, *v.MinCores, prefix+"Num_of_CPU_Cores__c", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "MinCores", "greaterThanOrEqualsToOperator,fieldName=Num_of_CPU_Cores__c")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	condition, err = soql.BuildCondition(soql.LessThanOperator, v.MaxCores, prefix+"Num_of_CPU_Cores__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "MaxCores", "lessThanOperator,fieldName=Num_of_CPU_Cores__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.Weight != nil {
+		condition, err = soql.BuildCondition(soql.GreaterThanOperator, *v.Weight, prefix+"Weight__c", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Weight", "greaterThanOperator,fieldName=Weight__c")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	if v.Active != nil {
+		condition, err = soql.BuildCondition(soql.EqualsOperator, *v.Active, prefix+"Active__c", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Active", "equalsOperator,fieldName=Active__c")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	if v.Discovered != nil {
+		condition, err = soql.BuildCondition(soql.NullOperator, *v.Discovered, prefix+"Last_Discovered_Date__c", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Discovered", "nullOperator,fieldName=Last_Discovered_Date__c")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	if v.Since != nil {
+		condition, err = soql.BuildCondition(soql.GreaterThanOperator, *v.Since, prefix+"CreatedDate", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Since", "greaterThanOperator,fieldName=CreatedDate")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	condition, err = soql.BuildCondition(soql.LessThanOrEqualsToOperator, v.Until, prefix+"Birth_Date__c", soqlFormatHostCriteriaUntil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Until", "lessThanOrEqualsToOperator,fieldName=Birth_Date__c,format=2006-01-02")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.EqualsOperator, v.Modified, prefix+"LastModifiedDate", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Modified", "equalsOperator,fieldName=LastModifiedDate")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.Expiring != nil {
+		condition, err = soql.BuildCondition(soql.LessOrEqualNextNDaysOperator, *v.Expiring, prefix+"Expiry__c", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Expiring", "lessOrEqualNextNDaysOperator,fieldName=Expiry__c")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	condition, err = soql.BuildCondition(soql.GreaterLastNDaysOperator, v.Created, prefix+"CreatedDate", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Created", "greaterLastNDaysOperator,fieldName=CreatedDate")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.Renewed != nil {
+		condition, err = soql.BuildCondition(soql.EqualsNextNDaysOperator, *v.Renewed, prefix+"Renewal__c", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Renewed", "equalsNextNDaysOperator,fieldName=Renewal__c")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	if v.Either != nil {
+		condition, err = v.Either.soqlWhere(table, " OR ")
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Either", "subquery,joiner=or")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(")
+			buff.WriteString(condition)
+			buff.WriteString(")")
+		}
+	}
+	condition, err = v.Both.soqlWhere(table, " AND ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Both", "subquery")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString("(")
+		buff.WriteString(condition)
+		buff.WriteString(")")
+	}
+	if v.Apps != nil {
+		condition, err = v.Apps.soqlQuery("")
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Apps", "subquery,joiner=in,fieldName=Id")
+		}
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString("Id IN (")
+		buff.WriteString(condition)
+		buff.WriteString(")")
+	}
+	condition, err = v.NotApps.soqlQuery("")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "NotApps", "subquery,joiner=not in,fieldName=Id")
+	}
+	if buff.Len() > 0 {
+		buff.WriteString(joiner)
+	}
+	buff.WriteString("Id NOT IN (")
+	buff.WriteString(condition)
+	buff.WriteString(")")
+	if v.Roled != nil {
+		condition, err = soql.Marshal(v.Roled)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Roled", "subquery,joiner=in,fieldName=Role__c")
+		}
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString("Role__c IN (")
+		buff.WriteString(condition)
+		buff.WriteString(")")
+	}
+	if v.Unlike != nil {
+		condition, err = v.Unlike.soqlWhere(table, " OR ")
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Unlike", "subquery,joiner=or,not")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(NOT (")
+			buff.WriteString(condition)
+			buff.WriteString("))")
+		}
+	}
+	if len(v.Any) > 0 {
+		var groups strings.Builder
+		for i := range v.Any {
+			condition, err = v.Any[i].soqlWhere(table, " AND ")
+			if err != nil {
+				return "", soql.WrapMarshalError(err, v, "Any", "subquery")
+			}
+			if condition != "" {
+				if groups.Len() > 0 {
+					groups.WriteString(" OR ")
+				}
+				groups.WriteString("(" + condition + ")")
+			}
+		}
+		condition = groups.String()
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(")
+			buff.WriteString(condition)
+			buff.WriteString(")")
+		}
+	}
+	if len(v.None) > 0 {
+		var groups strings.Builder
+		for i := range v.None {
+			if v.None[i] == nil {
+				continue
+			}
+			condition, err = v.None[i].soqlWhere(table, " OR ")
+			if err != nil {
+				return "", soql.WrapMarshalError(err, v, "None", "subquery,joiner=or,not")
+			}
+			if condition != "" {
+				if groups.Len() > 0 {
+					groups.WriteString(" OR ")
+				}
+				groups.WriteString("(" + condition + ")")
+			}
+		}
+		condition = groups.String()
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(NOT (")
+			buff.WriteString(condition)
+			buff.WriteString("))")
+		}
+	}
+	return buff.String(), nil
+}
+
+func (v *Aggregate) soqlSelect(prefix string) (string, error) {
+	var buff strings.Builder
+	buff.WriteString(prefix)
+	buff.WriteString("Status,")
+	buff.WriteString(prefix)
+	buff.WriteString("Owner.Name,COUNT(")
+	buff.WriteString(prefix)
+	buff.WriteString("Id) cnt,SUM(")
+	buff.WriteString(prefix)
+	buff.WriteString("Amount__c),GROUPING(")
+	buff.WriteString(prefix)
+	buff.WriteString("Status) Status,MIN(")
+	buff.WriteString(prefix)
+	buff.WriteString("CreatedDate)")
+	return buff.String(), nil
+}
+
+func (v *AggregateFilter) soqlWhere(table, joiner string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	var condition string
+	var err error
+	condition, err = soql.BuildCondition(soql.InOperator, v.Statuses, prefix+"Status", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Statuses", "inOperator,fieldName=Status")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.MinCount != nil {
+		condition, err = soql.BuildCondition(soql.GreaterThanOperator, *v.MinCount, prefix+"COUNT(Id)", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "MinCount", "greaterThanOperator,fieldName=COUNT(Id)")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	return buff.String(), nil
+}
+
+func (v *ParentSelect) soqlSelect(prefix string) (string, error) {
+	var buff strings.Builder
+	child1, err := v.Cases.soqlQuery(prefix + "Cases")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Cases", "selectChild,fieldName=Cases")
+	}
+	child2, err := v.Aggregated.soqlQuery(prefix + "Aggregated__r")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Aggregated", "selectChild,fieldName=Aggregated__r")
+	}
+	buff.WriteString(prefix)
+	buff.WriteString("Id,")
+	buff.WriteString(child1)
+	buff.WriteString(",")
+	buff.WriteString(child2)
+	return buff.String(), nil
+}
+
+func (v *NoColumns) soqlSelect(prefix string) (string, error) {
+	return "", nil
+}
+
+func (v *InvalidTag) soqlWhere(table, joiner string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	var condition string
+	var err error
+	condition, err = soql.BuildCondition(soql.EqualsOperator, v.Name, prefix+"Name", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Name", "equalsOperator,fieldName=Name")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.Group != nil {
+		return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "Group", "subquery,joiner=in")
+	}
+	if v.Xor != nil {
+		return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "Xor", "subquery,joiner=xor")
+	}
+	if v.Broken != nil {
+		condition, err = v.Broken.soqlWhere(table, " AND ")
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Broken", "subquery")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(")
+			buff.WriteString(condition)
+			buff.WriteString(")")
+		}
+	}
+	if v.Typed != nil {
+		condition, err = v.Typed.soqlWhere(table, " AND ")
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Typed", "subquery")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(")
+			buff.WriteString(condition)
+			buff.WriteString(")")
+		}
+	}
+	if len(v.Listed) > 0 {
+		return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "Listed", "subquery,joiner=in,fieldName=Id")
+	}
+	if v.NotIn != nil {
+		return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "NotIn", "subquery,joiner=in,fieldName=Id,not")
+	}
+	return buff.String(), nil
+}
+
+func (v *InvalidSelect) soqlSelect(prefix string) (string, error) {
+	return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "Name", "selectColumn,fieldName=")
+}
+
+func (v *InvalidTypeOfSelect) soqlSelect(prefix string) (string, error) {
+	return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "What.Lead.Versions", "selectChild,fieldName=Application_Versions__r")
+}
+
+func (v *Queue) soqlSelect(prefix string) (string, error) {
+	var buff strings.Builder
+	buff.WriteString(prefix)
+	buff.WriteString("Name,")
+	buff.WriteString(prefix)
+	buff.WriteString("Role__r.Name")
+	return buff.String(), nil
+}
+
+func (v *Versions) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if child != "" {
+		prefix = "SM_Application_Versions__c."
+	}
+	selectClause, err := new(Version).soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=SM_Application_Versions__c,fields=Custom")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT FIELDS(CUSTOM)")
+	if selectClause != "" {
+		buff.WriteString(",")
+	}
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("SM_Application_Versions__c")
+	}
+	relation := ""
+	if child != "" {
+		relation = "SM_Application_Versions__c"
+	}
+	whereClause, err := v.WhereClause.soqlWhere(relation, " OR ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "WhereClause", "whereClause,joiner=or")
+	}
+	if whereClause != "" {
+		buff.WriteString(" WHERE ")
+		buff.WriteString(whereClause)
+	}
+	if len(v.OrderByClause) > 0 {
+		buff.WriteString(" ORDER BY ")
+		for i, order := range v.OrderByClause {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			switch order.Field {
+			case "Count":
+				if child != "" {
+					buff.WriteString("COUNT(SM_Application_Versions__c.Id)")
+				} else {
+					buff.WriteString("COUNT(Id)")
+				}
+			case "Role":
+				if child != "" {
+					buff.WriteString("SM_Application_Versions__c.Role__r")
+				} else {
+					buff.WriteString("Role__r")
+				}
+			case "Role.Name":
+				if child != "" {
+					buff.WriteString("SM_Application_Versions__c.Role__r.Name")
+				} else {
+					buff.WriteString("Role__r.Name")
+				}
+			case "Version":
+				if child != "" {
+					buff.WriteString("SM_Application_Versions__c.Version__c")
+				} else {
+					buff.WriteString("Version__c")
+				}
+			case "total":
+				if child != "" {
+					buff.WriteString("COUNT(SM_Application_Versions__c.Id)")
+				} else {
+					buff.WriteString("COUNT(Id)")
+				}
+			default:
+				return "", soql.WrapMarshalError(soql.ErrInvalidOrderByClause, v, "OrderByClause", "orderByClause")
+			}
+			if order.IsDesc {
+				buff.WriteString(" DESC")
+			} else {
+				buff.WriteString(" ASC")
+			}
+		}
+	}
+	if v.LimitClause != nil {
+		if *v.LimitClause < 0 {
+			return "", soql.WrapMarshalError(soql.ErrInvalidLimitClause, v, "LimitClause", "limitClause")
+		}
+		buff.WriteString(" LIMIT ")
+		buff.WriteString(strconv.Itoa(*v.LimitClause))
+	}
+	if v.LimitClause == nil || *v.LimitClause > 200 {
+		return "", soql.WrapMarshalError(soql.ErrInvalidFieldsLimit, v, "LimitClause", "limitClause")
+	}
+	if child != "" {
+		buff.WriteString(")")
+	}
+	return buff.String(), nil
+}
+
+func (v *HostGroup) soqlWhere(table, joiner string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	var condition string
+	var err error
+	condition, err = soql.BuildCondition(soql.InOperator, v.Statuses, prefix+"Status__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Statuses", "inOperator,fieldName=Status__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.EqualsOperator, v.Owner, prefix+"Owner.Name", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Owner", "equalsOperator,fieldName=Owner.Name")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.NotEqualsOperator, v.Status, prefix+"Status__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Status", "notEqualsOperator,fieldName=Status__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.LikeOperator, v.Kinds, prefix+"Kind__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Kinds", "likeOperator,fieldName=Kind__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.NotInOperator, v.Priorities, prefix+"Priority__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Priorities", "notInOperator,fieldName=Priority__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	condition, err = soql.BuildCondition(soql.GreaterThanOrEqualsToOperator, v.MinAmount, prefix+"Amount__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "MinAmount", "greaterThanOrEqualsToOperator,fieldName=Amount__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.Nested != nil {
+		condition, err = v.Nested.soqlWhere(table, " OR ")
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Nested", "subquery,joiner=or")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(")
+			buff.WriteString(condition)
+			buff.WriteString(")")
+		}
+	}
+	return buff.String(), nil
+}
+
+func (v *AppQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if child != "" {
+		prefix = "SM_Application_Host__c."
+	}
+	selectClause, err := v.SelectClause.soqlSelect(prefix)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=SM_Application_Host__c")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT ")
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	} else {
+		buff.WriteString("SM_Application_Host__c")
+	}
+	relation := ""
+	if child != "" {
+		relation = "SM_Application_Host__c"
+	}
+	whereClause, err := v.WhereClause.soqlWhere(relation, " AND ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "WhereClause", "whereClause")
+	}
+	if whereClause != "" {
+		buff.WriteString(" WHERE ")
+		buff.WriteString(whereClause)
+	}
+	if child != "" {
+		buff.WriteString(")")
+	}
+	return buff.String(), nil
+}
+
+func (v *ChildQuery) soqlQuery(child string) (string, error) {
+	var buff strings.Builder
+	selectClause, err := v.SelectClause.soqlSelect("")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "SelectClause", "selectClause,tableName=,fields=standard")
+	}
+	if child != "" {
+		buff.WriteString("(")
+	}
+	buff.WriteString("SELECT FIELDS(STANDARD)")
+	if selectClause != "" {
+		buff.WriteString(",")
+	}
+	buff.WriteString(selectClause)
+	buff.WriteString(" FROM ")
+	if child != "" {
+		buff.WriteString(child)
+	}
+	if v.UsingScope != "" {
+		buff.WriteString(" USING SCOPE ")
+		switch strings.ToLower(string(v.UsingScope)) {
+		case "delegated":
+			buff.WriteString("delegated")
+		case "everything":
+			buff.WriteString("everything")
+		case "mine":
+			buff.WriteString("mine")
+		case "mine_and_my_groups":
+			buff.WriteString("mine_and_my_groups")
+		case "my_territory":
+			buff.WriteString("my_territory")
+		case "my_team_territory":
+			buff.WriteString("my_team_territory")
+		case "team":
+			buff.WriteString("team")
+		case "scopingrule":
+			buff.WriteString("scopingRule")
+		default:
+			return "", soql.WrapMarshalError(soql.ErrInvalidUsingScope, v, "UsingScope", "usingScope")
+		}
+	}
+	if v.WhereClause == nil {
+		return "", soql.WrapMarshalError(soql.ErrNilValue, v, "WhereClause", "whereClause")
+	}
+	whereClause, err := v.WhereClause.soqlWhere("", " AND ")
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "WhereClause", "whereClause")
+	}
+	if whereClause != "" {
+		buff.WriteString(" WHERE ")
+		buff.WriteString(whereClause)
+	}
+	if v.WithClause != "" {
+		mode := strings.ToUpper(string(v.WithClause))
+		if mode != "SECURITY_ENFORCED" && (child != "" || (mode != "USER_MODE" && mode != "SYSTEM_MODE")) {
+			return "", soql.WrapMarshalError(soql.ErrInvalidWithClause, v, "WithClause", "withClause")
+		}
+		buff.WriteString(" WITH ")
+		buff.WriteString(mode)
+	}
+	if len(v.GroupByClause) > 0 {
+		buff.WriteString(" GROUP BY CUBE(")
+		for i, name := range v.GroupByClause {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			switch name {
+			case "Owner":
+				buff.WriteString("Owner")
+			case "Owner.Name":
+				buff.WriteString("Owner.Name")
+			case "Status":
+				buff.WriteString("Status")
+			default:
+				return "", soql.WrapMarshalError(soql.ErrInvalidGroupByClause, v, "GroupByClause", "groupByClause,type=CUBE")
+			}
+		}
+		buff.WriteString(")")
+	}
+	if len(v.OrderByClause) > 0 {
+		buff.WriteString(" ORDER BY ")
+		for i, order := range v.OrderByClause {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			switch order.Field {
+			case "Count":
+				buff.WriteString("COUNT(Id)")
+			case "Earliest":
+				buff.WriteString("MIN(CreatedDate)")
+			case "Grouping":
+				buff.WriteString("GROUPING(Status)")
+			case "Owner":
+				buff.WriteString("Owner")
+			case "Owner.Name":
+				buff.WriteString("Owner.Name")
+			case "Status":
+				buff.WriteString("Status")
+			case "Total":
+				buff.WriteString("SUM(Amount__c)")
+			case "cnt":
+				buff.WriteString("COUNT(Id)")
+			default:
+				return "", soql.WrapMarshalError(soql.ErrInvalidOrderByClause, v, "OrderByClause", "orderByClause")
+			}
+			if order.IsDesc {
+				buff.WriteString(" DESC")
+			} else {
+				buff.WriteString(" ASC")
+			}
+		}
+	}
+	if child != "" {
+		buff.WriteString(")")
+	}
+	return buff.String(), nil
+}
+
+func (v *BrokenGroup) soqlWhere(table, joiner string) (string, error) {
+	return "", soql.WrapMarshalError(soql.ErrInvalidTag, v, "Other", "lessOperator,fieldName=Other")
+}
+
+func (v *TypedGroup) soqlWhere(table, joiner string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	var condition string
+	var err error
+	condition, err = soql.BuildCondition(soql.EqualsOperator, v.Labels, prefix+"Labels__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Labels", "equalsOperator,fieldName=Labels__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	return buff.String(), nil
+}
+
+func (v *Version) soqlSelect(prefix string) (string, error) {
+	var buff strings.Builder
+	buff.WriteString(prefix)
+	buff.WriteString("Version__c,")
+	buff.WriteString(prefix)
+	buff.WriteString("Role__r.Name,COUNT(")
+	buff.WriteString(prefix)
+	buff.WriteString("Id) total")
+	return buff.String(), nil
+}
+
+func (v *VersionCriteria) soqlWhere(table, joiner string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	var condition string
+	var err error
+	condition, err = soql.BuildCondition(soql.InOperator, v.Versions, prefix+"Version__c", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Versions", "inOperator,fieldName=Version__c")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	if v.Current != nil {
+		condition, err = soql.BuildCondition(soql.NullOperator, *v.Current, prefix+"Current__c", nil)
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Current", "nullOperator,fieldName=Current__c")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString(condition)
+		}
+	}
+	if v.Group != nil {
+		condition, err = v.Group.soqlWhere(table, " AND ")
+		if err != nil {
+			return "", soql.WrapMarshalError(err, v, "Group", "subquery,joiner=and")
+		}
+		if condition != "" {
+			if buff.Len() > 0 {
+				buff.WriteString(joiner)
+			}
+			buff.WriteString("(")
+			buff.WriteString(condition)
+			buff.WriteString(")")
+		}
+	}
+	return buff.String(), nil
+}
+
+func (v *AppHost) soqlSelect(prefix string) (string, error) {
+	var buff strings.Builder
+	buff.WriteString(prefix)
+	buff.WriteString("Host__c")
+	return buff.String(), nil
+}
+
+func (v *AppCriteria) soqlWhere(table, joiner string) (string, error) {
+	var buff strings.Builder
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	var condition string
+	var err error
+	condition, err = soql.BuildCondition(soql.InOperator, v.Names, prefix+"Application__r.Name", nil)
+	if err != nil {
+		return "", soql.WrapMarshalError(err, v, "Names", "inOperator,fieldName=Application__r.Name")
+	}
+	if condition != "" {
+		if buff.Len() > 0 {
+			buff.WriteString(joiner)
+		}
+		buff.WriteString(condition)
+	}
+	return buff.String(), nil
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package fixtures
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+// The reflective types have the members of the fixtures but not their MarshalSOQL methods, so soql.Marshal
+// uses reflection for them
+type (
+	reflectiveHostQuery          HostQuery
+	reflectiveAggregateQuery     AggregateQuery
+	reflectiveParentQuery        ParentQuery
+	reflectiveInvalidTagQuery    InvalidTagQuery
+	reflectiveInvalidWhereQuery  InvalidWhereQuery
+	reflectiveInvalidTypeOfQuery InvalidTypeOfQuery
+	reflectiveUnlimitedQuery     UnlimitedQuery
+	reflectiveUnknownClauseQuery UnknownClauseQuery
+	reflectiveNoSelectQuery      NoSelectQuery
+	reflectiveEmptyQuery         EmptyQuery
+)
+
+var fixtures = []struct {
+	new        func() interface{}
+	reflective func(v interface{}) interface{}
+}{
+	{
+		func() interface{} { return new(HostQuery) },
+		func(v interface{}) interface{} { return reflectiveHostQuery(*v.(*HostQuery)) },
+	},
+	{
+		func() interface{} { return new(AggregateQuery) },
+		func(v interface{}) interface{} { return reflectiveAggregateQuery(*v.(*AggregateQuery)) },
+	},
+	{
+		func() interface{} { return new(ParentQuery) },
+		func(v interface{}) interface{} { return reflectiveParentQuery(*v.(*ParentQuery)) },
+	},
+	{
+		func() interface{} { return new(InvalidTagQuery) },
+		func(v interface{}) interface{} { return reflectiveInvalidTagQuery(*v.(*InvalidTagQuery)) },
+	},
+	{
+		func() interface{} { return new(InvalidWhereQuery) },
+		func(v interface{}) interface{} { return reflectiveInvalidWhereQuery(*v.(*InvalidWhereQuery)) },
+	},
+	{
+		func() interface{} { return new(InvalidTypeOfQuery) },
+		func(v interface{}) interface{} { return reflectiveInvalidTypeOfQuery(*v.(*InvalidTypeOfQuery)) },
+	},
+	{
+		func() interface{} { return new(UnlimitedQuery) },
+		func(v interface{}) interface{} { return reflectiveUnlimitedQuery(*v.(*UnlimitedQuery)) },
+	},
+	{
+		func() interface{} { return new(UnknownClauseQuery) },
+		func(v interface{}) interface{} { return reflectiveUnknownClauseQuery(*v.(*UnknownClauseQuery)) },
+	},
+	{
+		func() interface{} { return new(NoSelectQuery) },
+		func(v interface{}) interface{} { return reflectiveNoSelectQuery(*v.(*NoSelectQuery)) },
+	},
+	{
+		func() interface{} { return new(EmptyQuery) },
+		func(v interface{}) interface{} { return reflectiveEmptyQuery(*v.(*EmptyQuery)) },
+	},
+}
+
+// words are the values of strings, which include the names of members for orderByClause and groupByClause
+// along with date literals, security modes, for clause modes and scopes
+var words = []string{
+	"", " ", "db", "O'Brien", `back\slash`, "50%_off", "Name", "ID", "Role", "Role.Name", "Parent.Role.Name",
+	"Discovered", "Version", "Count", "total", "cnt", "Status", "Owner.Name", "Grouping", "Earliest", "Total",
+	"Bogus", "TODAY", "LAST_N_DAYS:3", "NEXT_N_WEEKS", "LAST_N_DAYS:-1",
+	"security_enforced", "USER_MODE", "SYSTEM_MODE", "view", "REFERENCE", "UPDATE", "mine",
+	"Team", "scopingrule",
+}
+
+// maxDepth limits the recursion of pointers and slices of recursive structs
+const maxDepth = 8
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// filler sets the members of structs from the bytes of random input
+type filler struct {
+	data []byte
+}
+
+func (f *filler) next() byte {
+	if len(f.data) == 0 {
+		return 0
+	}
+	b := f.data[0]
+	f.data = f.data[1:]
+	return b
+}
+
+func (f *filler) fill(v reflect.Value, depth int) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if f.next()%2 == 0 || depth > maxDepth {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		f.fill(v.Elem(), depth+1)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			v.Set(reflect.ValueOf(time.Unix(int64(int8(f.next()))*86400*37, 0).UTC()))
+			return
+		}
+		// members other than pointers and structs are often left zero, since many of them fail Marshal when
+		// they are set
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			kind := field.Kind()
+			if field.CanSet() && (kind == reflect.Ptr || kind == reflect.Struct || f.next()%2 == 0) {
+				f.fill(field, depth+1)
+			}
+		}
+	case reflect.Slice:
+		// most slices are empty, since a single invalid element of orderByClause or groupByClause fails Marshal
+		n := int(f.next()%8) - 4
+		if n <= 0 || depth > maxDepth {
+			return
+		}
+		slice := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			f.fill(slice.Index(i), depth+1)
+		}
+		v.Set(slice)
+	case reflect.String:
+		v.SetString(words[int(f.next())%len(words)])
+	case reflect.Bool:
+		v.SetBool(f.next()%2 == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// few of ints are negative, since negative limitClause and offsetClause fail Marshal
+		b := int64(f.next())
+		if b < 16 {
+			b = -b
+		}
+		v.SetInt(b % 128)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(f.next()))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(int8(f.next())) / 4)
+	}
+}
+
+// compare checks that MarshalSOQL of the fixture filled from data returns the same query and error as
+// soql.Marshal using reflection
+func compare(t *testing.T, index int, data []byte) {
+	fixture := fixtures[index%len(fixtures)]
+	v := fixture.new()
+	(&filler{data: data}).fill(reflect.ValueOf(v).Elem(), 0)
+	if _, ok := v.(soql.Marshaler); !ok {
+		t.Fatalf("%T does not implement soql.Marshaler", v)
+	}
+	query, err := soql.Marshal(v)
+	expectedQuery, expectedErr := soql.Marshal(fixture.reflective(v))
+	if query != expectedQuery {
+		t.Fatalf("%T: MarshalSOQL returned\n%s\nbut Marshal returned\n%s", v, query, expectedQuery)
+	}
+	if (err == nil) != (expectedErr == nil) {
+		t.Fatalf("%T: MarshalSOQL returned error %v but Marshal returned %v", v, err, expectedErr)
+	}
+	if err == nil {
+		return
+	}
+	marshalErr, ok := err.(*soql.MarshalError)
+	expectedMarshalErr := expectedErr.(*soql.MarshalError)
+	if !ok || marshalErr.Type != reflect.TypeOf(v).Elem() || marshalErr.Field != expectedMarshalErr.Field ||
+		marshalErr.Tag != expectedMarshalErr.Tag || marshalErr.Err != expectedMarshalErr.Err {
+		t.Fatalf("%T: MarshalSOQL returned error %v but Marshal returned %v", v, err, expectedErr)
+	}
+}
+
+func TestMarshalSOQL(t *testing.T) {
+	for i := range fixtures {
+		compare(t, i, nil)
+		compare(t, i, bytes.Repeat([]byte{1}, 256))
+		compare(t, i, bytes.Repeat([]byte{4, 7}, 256))
+	}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		data := make([]byte, random.Intn(512))
+		random.Read(data)
+		compare(t, i, data)
+	}
+}
+
+func TestMarshalNilPointer(t *testing.T) {
+	_, err := soql.Marshal((*HostQuery)(nil))
+	marshalErr, ok := err.(*soql.MarshalError)
+	if !ok || marshalErr.Err != soql.ErrNilValue || marshalErr.Type != reflect.TypeOf((*HostQuery)(nil)) {
+		t.Fatalf("Marshal returned error %v", err)
+	}
+}
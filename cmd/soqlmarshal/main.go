@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Command soqlmarshal generates MarshalSOQL methods for soql structs, so that soql.Marshal constructs their
+// queries without reflection. It is meant to be run by go generate:
+//
+//	//go:generate go run github.com/forcedotcom/go-soql/cmd/soqlmarshal -type=HostQuery,RoleQuery
+//
+// Usage:
+//
+//	soqlmarshal -type T[,T...] [-output file] [dir]
+//
+// The given types and the selectClause, whereClause, havingClause, selectChild and subquery structs used by
+// them get unexported helper methods, so all of them must be declared in the package in dir, which is the
+// current directory by default. The generated methods return the same query and errors as soql.Marshal.
+// Members whose types would make soql.Marshal fail regardless of their values, e.g. a whereClause member that
+// is not a struct, are reported instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of type names, required")
+	output := flag.String("output", "", "generated file, <type>_soql.go in dir if not set")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: soqlmarshal -type T[,T...] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	types := strings.Split(*typeNames, ",")
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(types[0])+"_soql.go")
+	}
+	if err := run(dir, types, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "soqlmarshal: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, typeNames []string, output string) error {
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		return err
+	}
+	source, err := generate(pkg, typeNames)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, source, 0644)
+}
@@ -0,0 +1,392 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package main
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+// The plans below are compiled from go/types the same way as soql package compiles them from reflect types,
+// so that the generated code finds the same errors in the same order as soql.Marshal.
+
+const (
+	andCondition  = " AND "
+	orCondition   = " OR "
+	inOperator    = " IN "
+	notInOperator = " NOT IN "
+	soqlPackage   = "github.com/forcedotcom/go-soql"
+)
+
+// aggregateFunctions maps the aggregate column tags to SOQL aggregate functions
+var aggregateFunctions = map[string]string{
+	soql.SelectCount:         "COUNT",
+	soql.SelectCountDistinct: "COUNT_DISTINCT",
+	soql.SelectSum:           "SUM",
+	soql.SelectAvg:           "AVG",
+	soql.SelectMin:           "MIN",
+	soql.SelectMax:           "MAX",
+	soql.SelectGrouping:      "GROUPING",
+}
+
+// member is a member of struct with soql tag
+type member struct {
+	name string
+	tag  string
+	typ  types.Type
+}
+
+func members(s *types.Struct) []member {
+	var tagged []member
+	for i := 0; i < s.NumFields(); i++ {
+		tag := reflect.StructTag(s.Tag(i)).Get(soql.SoqlTag)
+		if tag == "" {
+			continue
+		}
+		tagged = append(tagged, member{name: s.Field(i).Name(), tag: tag, typ: s.Field(i).Type()})
+	}
+	return tagged
+}
+
+type queryClause struct {
+	member
+	clauseKey   string
+	tableName   string
+	joiner      string
+	groupByType string
+	fields      string
+	err         error
+}
+
+type queryPlan struct {
+	soqlTagPresent bool
+	clauses        []queryClause
+}
+
+func compileQueryPlan(s *types.Struct) queryPlan {
+	var plan queryPlan
+	present := make(map[string]bool)
+	multipleClauseErrors := map[string]error{
+		soql.SelectClause:  soql.ErrMultipleSelectClause,
+		soql.WhereClause:   soql.ErrMultipleWhereClause,
+		soql.OrderByClause: soql.ErrMultipleOrderByClause,
+		soql.LimitClause:   soql.ErrMultipleLimitClause,
+		soql.OffsetClause:  soql.ErrMultipleOffsetClause,
+		soql.GroupByClause: soql.ErrMultipleGroupByClause,
+		soql.HavingClause:  soql.ErrMultipleHavingClause,
+		soql.WithClause:    soql.ErrMultipleWithClause,
+		soql.ForClause:     soql.ErrMultipleForClause,
+		soql.UsingScope:    soql.ErrMultipleUsingScope,
+	}
+	for _, m := range members(s) {
+		plan.soqlTagPresent = true
+		clause := queryClause{member: m, clauseKey: getClauseKey(m.tag)}
+		multipleClauseErr, ok := multipleClauseErrors[clause.clauseKey]
+		switch {
+		case !ok:
+			clause.err = soql.ErrInvalidTag
+		case present[clause.clauseKey]:
+			clause.err = multipleClauseErr
+		default:
+			present[clause.clauseKey] = true
+		}
+		switch clause.clauseKey {
+		case soql.SelectClause:
+			clause.tableName = getTagValue(m.tag, soql.TableName, m.name)
+			fields, err := getFields(m.tag)
+			if clause.err == nil {
+				clause.fields, clause.err = fields, err
+			}
+		case soql.WhereClause, soql.HavingClause:
+			joiner, err := getJoiner(m.tag)
+			if clause.err == nil {
+				clause.joiner, clause.err = joiner, err
+			}
+		case soql.GroupByClause:
+			clause.groupByType = getTagValue(m.tag, soql.GroupByType, "")
+		}
+		plan.clauses = append(plan.clauses, clause)
+		if clause.err != nil {
+			break
+		}
+	}
+	return plan
+}
+
+type selectField struct {
+	member
+	clauseKey string
+	fieldName string
+	alias     string
+	isNested  bool
+	err       error
+}
+
+func compileSelectPlan(s *types.Struct) []selectField {
+	var plan []selectField
+	for _, m := range members(s) {
+		_, isStruct := m.typ.Underlying().(*types.Struct)
+		f := selectField{
+			member:    m,
+			clauseKey: getClauseKey(m.tag),
+			fieldName: getTagValue(m.tag, soql.FieldName, m.name),
+			alias:     getTagValue(m.tag, soql.Alias, ""),
+			isNested:  isStruct && !isNamed(m.typ, "time", "Time"),
+		}
+		_, isAggregate := aggregateFunctions[f.clauseKey]
+		if (f.clauseKey != soql.SelectColumn && f.clauseKey != soql.SelectChild && f.clauseKey != soql.SelectTypeOf && !isAggregate) ||
+			f.fieldName == "" {
+			f.err = soql.ErrInvalidTag
+		}
+		if f.clauseKey == soql.SelectTypeOf {
+			f.isNested = false
+			if !isStruct {
+				f.err = soql.ErrInvalidTag
+			}
+		}
+		plan = append(plan, f)
+	}
+	return plan
+}
+
+type typeOfPlan struct {
+	branches []typeOfBranch
+	err      error
+}
+
+type typeOfBranch struct {
+	member
+	clauseKey string
+	sObject   string
+	// structType is nil if the member is neither a struct nor a pointer to struct
+	structType *types.Struct
+	err        error
+}
+
+func compileTypeOfPlan(s *types.Struct) typeOfPlan {
+	plan := typeOfPlan{err: soql.ErrInvalidTag}
+	hasElse := false
+	for _, m := range members(s) {
+		branch := typeOfBranch{member: m, clauseKey: getClauseKey(m.tag)}
+		switch branch.clauseKey {
+		case soql.SelectWhen:
+			branch.sObject = getTagValue(m.tag, soql.TableName, m.name)
+			if branch.sObject == "" {
+				branch.err = soql.ErrInvalidTag
+			}
+			plan.err = nil
+		case soql.SelectElse:
+			if hasElse {
+				branch.err = soql.ErrInvalidTag
+			}
+			hasElse = true
+		default:
+			branch.err = soql.ErrInvalidTag
+		}
+		t := m.typ
+		if pointer, ok := t.(*types.Pointer); ok {
+			t = pointer.Elem()
+		}
+		if structType, ok := t.Underlying().(*types.Struct); ok && !isNamed(t, "time", "Time") {
+			branch.structType = structType
+		} else {
+			branch.err = soql.ErrInvalidTag
+		}
+		plan.branches = append(plan.branches, branch)
+	}
+	return plan
+}
+
+// operators are the tags of conditions of whereClause structs
+var operators = map[string]bool{
+	soql.LikeOperator:                    true,
+	soql.NotLikeOperator:                 true,
+	soql.InOperator:                      true,
+	soql.NotInOperator:                   true,
+	soql.IncludesOperator:                true,
+	soql.ExcludesOperator:                true,
+	soql.EqualsOperator:                  true,
+	soql.NullOperator:                    true,
+	soql.NotEqualsOperator:               true,
+	soql.GreaterThanOperator:             true,
+	soql.GreaterThanOrEqualsToOperator:   true,
+	soql.LessThanOperator:                true,
+	soql.LessThanOrEqualsToOperator:      true,
+	soql.GreaterNextNDaysOperator:        true,
+	soql.GreaterOrEqualNextNDaysOperator: true,
+	soql.EqualsNextNDaysOperator:         true,
+	soql.LessNextNDaysOperator:           true,
+	soql.LessOrEqualNextNDaysOperator:    true,
+	soql.GreaterLastNDaysOperator:        true,
+	soql.GreaterOrEqualLastNDaysOperator: true,
+	soql.EqualsLastNDaysOperator:         true,
+	soql.LessLastNDaysOperator:           true,
+	soql.LessOrEqualLastNDaysOperator:    true,
+}
+
+type whereField struct {
+	member
+	clauseKey     string
+	fieldName     string
+	joinFieldName string
+	joiner        string
+	not           bool
+	isSlice       bool
+	// format is set when the tag has format parameter, which is the only parameter used by the operators
+	format *string
+	err    error
+	// joinErr is returned only for subqueries that are neither nil nor empty slices
+	joinErr error
+}
+
+func compileWherePlan(s *types.Struct) []whereField {
+	var plan []whereField
+	for _, m := range members(s) {
+		f := whereField{
+			member:    m,
+			clauseKey: getClauseKey(m.tag),
+			fieldName: getTagValue(m.tag, soql.FieldName, m.name),
+		}
+		if f.clauseKey == soql.Subquery {
+			switch t := m.typ.Underlying().(type) {
+			case *types.Struct, *types.Pointer:
+			case *types.Slice:
+				f.isSlice = true
+				elem := t.Elem().Underlying()
+				if pointer, ok := elem.(*types.Pointer); ok {
+					elem = pointer.Elem().Underlying()
+				}
+				if _, ok := elem.(*types.Struct); !ok {
+					f.err = soql.ErrInvalidTag
+				}
+			default:
+				f.err = soql.ErrInvalidTag
+			}
+			f.not = hasTagOption(m.tag, soql.NotOption)
+			f.joiner, f.joinErr = getJoiner(m.tag)
+			if f.joiner == inOperator || f.joiner == notInOperator {
+				f.joinFieldName = getTagValue(m.tag, soql.FieldName, "")
+				if f.joinFieldName == "" || f.not || f.isSlice {
+					f.joinErr = soql.ErrInvalidTag
+				}
+			}
+		} else {
+			if f.fieldName == "" || !operators[f.clauseKey] {
+				f.err = soql.ErrInvalidTag
+			}
+			for _, item := range strings.Split(m.tag, ",") {
+				if key, value := parseTagString(item); key == soql.Format {
+					format := value
+					f.format = &format
+				}
+			}
+		}
+		plan = append(plan, f)
+	}
+	return plan
+}
+
+// mapSelectColumns maps the names of members of select struct s to the names of their columns, the same way
+// as mapSelectColumns of soql package, for orderByClause and groupByClause
+func mapSelectColumns(mappings map[string]string, parent string, gusParent string, s *types.Struct) {
+	for _, m := range members(s) {
+		clauseKey := getClauseKey(m.tag)
+		function, isAggregate := aggregateFunctions[clauseKey]
+		if clauseKey != soql.SelectColumn && !isAggregate {
+			continue
+		}
+		fieldName := m.name
+		gusFieldName := getTagValue(m.tag, soql.FieldName, m.name)
+		if parent != "" {
+			fieldName = parent + "." + fieldName
+			gusFieldName = gusParent + "." + gusFieldName
+		}
+		if isAggregate {
+			gusFieldName = function + "(" + gusFieldName + ")"
+			if alias := getTagValue(m.tag, soql.Alias, ""); alias != "" {
+				if _, ok := mappings[alias]; !ok {
+					mappings[alias] = gusFieldName
+				}
+			}
+			mappings[fieldName] = gusFieldName
+			continue
+		}
+		mappings[fieldName] = gusFieldName
+		if nested, ok := m.typ.Underlying().(*types.Struct); ok {
+			mapSelectColumns(mappings, fieldName, gusFieldName, nested)
+		}
+	}
+}
+
+func getClauseKey(clauseTag string) string {
+	return strings.Split(clauseTag, ",")[0]
+}
+
+func getJoiner(clauseTag string) (string, error) {
+	switch strings.ToLower(getTagValue(clauseTag, soql.Joiner, "")) {
+	case "or":
+		return orCondition, nil
+	case "in":
+		return inOperator, nil
+	case "not in":
+		return notInOperator, nil
+	case "and", "":
+		return andCondition, nil
+	default:
+		return "", soql.ErrInvalidTag
+	}
+}
+
+func getFields(clauseTag string) (string, error) {
+	tag := getTagValue(clauseTag, soql.Fields, "")
+	switch strings.ToLower(tag) {
+	case soql.FieldsStandard, soql.FieldsCustom, soql.FieldsAll:
+		return strings.ToUpper(tag), nil
+	case "":
+		return "", nil
+	default:
+		return "", soql.ErrInvalidTag
+	}
+}
+
+func parseTagString(tagString string) (string, string) {
+	delimInd := strings.Index(tagString, "=")
+	if delimInd == -1 {
+		return tagString, ""
+	}
+	return tagString[:delimInd], tagString[delimInd+1:]
+}
+
+func hasTagOption(clauseTag, option string) bool {
+	for _, tagItem := range strings.Split(clauseTag, ",")[1:] {
+		if tagItem == option {
+			return true
+		}
+	}
+	return false
+}
+
+func getTagValue(clauseTag, key, defaultValue string) string {
+	for _, tagItem := range strings.Split(clauseTag, ",") {
+		if tagKey, tagValue := parseTagString(tagItem); tagKey == key {
+			return tagValue
+		}
+	}
+	return defaultValue
+}
+
+// isNamed reports whether t is the named type name of package pkgPath
+func isNamed(t types.Type, pkgPath, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+}
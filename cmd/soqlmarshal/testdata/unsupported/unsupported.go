@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package unsupported
+
+import (
+	"time"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+type Host struct {
+	Name string `soql:"selectColumn,fieldName=Name__c"`
+}
+
+type Marshaled struct {
+	SelectClause Host `soql:"selectClause,tableName=SM_Logical_Host__c"`
+}
+
+func (Marshaled) MarshalSOQL() (string, error) {
+	return "SELECT Name__c FROM SM_Logical_Host__c", nil
+}
+
+type Name string
+
+type StringWhere struct {
+	SelectClause Host   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause  string `soql:"whereClause"`
+}
+
+type TimeSelect struct {
+	SelectClause time.Time `soql:"selectClause,tableName=SM_Logical_Host__c"`
+}
+
+type IntLimit struct {
+	SelectClause Host `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	LimitClause  int  `soql:"limitClause"`
+}
+
+type NamedGroupBy struct {
+	SelectClause  Host   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	GroupByClause []Name `soql:"groupByClause"`
+}
+
+type OrderByStrings struct {
+	SelectClause  Host     `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	OrderByClause []string `soql:"orderByClause"`
+}
+
+type SliceChild struct {
+	SelectClause ChildSelect `soql:"selectClause,tableName=SM_Logical_Host__c"`
+}
+
+type ChildSelect struct {
+	Versions []soql.Order `soql:"selectChild,fieldName=Application_Versions__r"`
+}
+
+type StringsGroup struct {
+	SelectClause Host            `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause  StringsCriteria `soql:"whereClause"`
+}
+
+type StringsCriteria struct {
+	Names []string `soql:"subquery,joiner=or"`
+}
+
+type StringWith struct {
+	SelectClause Host   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WithClause   string `soql:"withClause"`
+}
+
+type StringFor struct {
+	SelectClause Host   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	ForClause    string `soql:"forClause"`
+}
+
+type StringScope struct {
+	SelectClause Host   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	UsingScope   string `soql:"usingScope"`
+}
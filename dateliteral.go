@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// DateLiteral is a SOQL date literal like TODAY or LAST_N_WEEKS:4. It can be used as value of any comparison
+// operator tag, including inOperator and notInOperator as []DateLiteral, and is written to the query
+// without quotes. Literals that take a parameter are created with N, e.g. LastNWeeks.N(4).
+// type TestQueryCriteria struct {
+// 	CreatedDate  soql.DateLiteral   `soql:"greaterThanOrEqualsToOperator,fieldName=CreatedDate"`
+// 	CloseDate    []soql.DateLiteral `soql:"inOperator,fieldName=CloseDate"`
+// }
+// t := TestQueryCriteria{
+// 	CreatedDate: soql.LastNWeeks.N(4),
+// 	CloseDate:   []soql.DateLiteral{soql.Today, soql.Tomorrow},
+// }
+// whereClause, err := MarshalWhereClause(t)
+// if err != nil {
+//		log.Warn("Error in marshaling where clause")
+// }
+// fmt.Println(whereClause)
+// This will print whereClause as:
+// CreatedDate >= LAST_N_WEEKS:4 AND CloseDate IN (TODAY,TOMORROW)
+type DateLiteral string
+
+// Date literals supported by SOQL. The ones with N in their name take a parameter and should be used with N method.
+const (
+	Yesterday           DateLiteral = "YESTERDAY"
+	Today               DateLiteral = "TODAY"
+	Tomorrow            DateLiteral = "TOMORROW"
+	LastWeek            DateLiteral = "LAST_WEEK"
+	ThisWeek            DateLiteral = "THIS_WEEK"
+	NextWeek            DateLiteral = "NEXT_WEEK"
+	LastMonth           DateLiteral = "LAST_MONTH"
+	ThisMonth           DateLiteral = "THIS_MONTH"
+	NextMonth           DateLiteral = "NEXT_MONTH"
+	Last90Days          DateLiteral = "LAST_90_DAYS"
+	Next90Days          DateLiteral = "NEXT_90_DAYS"
+	ThisQuarter         DateLiteral = "THIS_QUARTER"
+	LastQuarter         DateLiteral = "LAST_QUARTER"
+	NextQuarter         DateLiteral = "NEXT_QUARTER"
+	ThisYear            DateLiteral = "THIS_YEAR"
+	LastYear            DateLiteral = "LAST_YEAR"
+	NextYear            DateLiteral = "NEXT_YEAR"
+	ThisFiscalQuarter   DateLiteral = "THIS_FISCAL_QUARTER"
+	LastFiscalQuarter   DateLiteral = "LAST_FISCAL_QUARTER"
+	NextFiscalQuarter   DateLiteral = "NEXT_FISCAL_QUARTER"
+	ThisFiscalYear      DateLiteral = "THIS_FISCAL_YEAR"
+	LastFiscalYear      DateLiteral = "LAST_FISCAL_YEAR"
+	NextFiscalYear      DateLiteral = "NEXT_FISCAL_YEAR"
+	LastNDays           DateLiteral = "LAST_N_DAYS"
+	NextNDays           DateLiteral = "NEXT_N_DAYS"
+	NDaysAgo            DateLiteral = "N_DAYS_AGO"
+	LastNWeeks          DateLiteral = "LAST_N_WEEKS"
+	NextNWeeks          DateLiteral = "NEXT_N_WEEKS"
+	NWeeksAgo           DateLiteral = "N_WEEKS_AGO"
+	LastNMonths         DateLiteral = "LAST_N_MONTHS"
+	NextNMonths         DateLiteral = "NEXT_N_MONTHS"
+	NMonthsAgo          DateLiteral = "N_MONTHS_AGO"
+	LastNQuarters       DateLiteral = "LAST_N_QUARTERS"
+	NextNQuarters       DateLiteral = "NEXT_N_QUARTERS"
+	NQuartersAgo        DateLiteral = "N_QUARTERS_AGO"
+	LastNYears          DateLiteral = "LAST_N_YEARS"
+	NextNYears          DateLiteral = "NEXT_N_YEARS"
+	NYearsAgo           DateLiteral = "N_YEARS_AGO"
+	LastNFiscalQuarters DateLiteral = "LAST_N_FISCAL_QUARTERS"
+	NextNFiscalQuarters DateLiteral = "NEXT_N_FISCAL_QUARTERS"
+	NFiscalQuartersAgo  DateLiteral = "N_FISCAL_QUARTERS_AGO"
+	LastNFiscalYears    DateLiteral = "LAST_N_FISCAL_YEARS"
+	NextNFiscalYears    DateLiteral = "NEXT_N_FISCAL_YEARS"
+	NFiscalYearsAgo     DateLiteral = "N_FISCAL_YEARS_AGO"
+)
+
+// dateLiterals lists SOQL date literals. The value indicates whether the literal takes a parameter
+// like NEXT_N_DAYS:5
+var dateLiterals = map[DateLiteral]bool{
+	Yesterday:           false,
+	Today:               false,
+	Tomorrow:            false,
+	LastWeek:            false,
+	ThisWeek:            false,
+	NextWeek:            false,
+	LastMonth:           false,
+	ThisMonth:           false,
+	NextMonth:           false,
+	Last90Days:          false,
+	Next90Days:          false,
+	ThisQuarter:         false,
+	LastQuarter:         false,
+	NextQuarter:         false,
+	ThisYear:            false,
+	LastYear:            false,
+	NextYear:            false,
+	ThisFiscalQuarter:   false,
+	LastFiscalQuarter:   false,
+	NextFiscalQuarter:   false,
+	ThisFiscalYear:      false,
+	LastFiscalYear:      false,
+	NextFiscalYear:      false,
+	LastNDays:           true,
+	NextNDays:           true,
+	NDaysAgo:            true,
+	LastNWeeks:          true,
+	NextNWeeks:          true,
+	NWeeksAgo:           true,
+	LastNMonths:         true,
+	NextNMonths:         true,
+	NMonthsAgo:          true,
+	LastNQuarters:       true,
+	NextNQuarters:       true,
+	NQuartersAgo:        true,
+	LastNYears:          true,
+	NextNYears:          true,
+	NYearsAgo:           true,
+	LastNFiscalQuarters: true,
+	NextNFiscalQuarters: true,
+	NFiscalQuartersAgo:  true,
+	LastNFiscalYears:    true,
+	NextNFiscalYears:    true,
+	NFiscalYearsAgo:     true,
+}
+
+// ErrInvalidDateLiteral error is returned when DateLiteral is not one of the SOQL date literals or its parameter is
+// missing or invalid
+var ErrInvalidDateLiteral = errors.New("ErrInvalidDateLiteral")
+
+// N returns the date literal with parameter n, e.g. LastNDays.N(5) is LAST_N_DAYS:5
+func (d DateLiteral) N(n int) DateLiteral {
+	return DateLiteral(string(d) + ":" + strconv.Itoa(n))
+}
+
+// validate returns ErrInvalidDateLiteral if d is not a SOQL date literal with a parameter when one is required
+func (d DateLiteral) validate() error {
+	name, parameter, hasParameter := string(d), "", false
+	if indx := strings.Index(name, ":"); indx >= 0 {
+		name, parameter, hasParameter = name[:indx], name[indx+1:], true
+	}
+	takesParameter, ok := dateLiterals[DateLiteral(name)]
+	if !ok || takesParameter != hasParameter {
+		return ErrInvalidDateLiteral
+	}
+	if hasParameter {
+		if n, err := strconv.Atoi(parameter); err != nil || n < 0 {
+			return ErrInvalidDateLiteral
+		}
+	}
+	return nil
+}
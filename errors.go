@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"reflect"
+	"strings"
+)
+
+// MarshalError is returned by Marshal, MarshalSearch, MarshalSelectClause and MarshalWhereClause when the struct
+// passed to them cannot be marshaled. It describes the member of the struct that caused the failure and wraps one
+// of the sentinel errors, so errors.Is(err, ErrInvalidTag) and similar checks keep working.
+// Consider following go struct
+// type TestSoqlStruct struct {
+// 	SelectClause NonNestedStruct   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+// 	WhereClause  TestQueryCriteria `soql:"whereClause"`
+// }
+// type TestQueryCriteria struct {
+// 	Roles []string `soql:"inOperator,fieldName="`
+// }
+// _, err := Marshal(TestSoqlStruct{})
+// fmt.Println(err)
+// This will print err as:
+// ErrInvalidTag: main.TestSoqlStruct.WhereClause.Roles with soql tag "inOperator,fieldName="
+type MarshalError struct {
+	// Type is the type of the struct passed to the marshaling function
+	Type reflect.Type
+	// Field is the path of the offending member within Type, e.g. WhereClause.Subquery.Roles. It is empty when
+	// the error is not caused by a single member, e.g. for ErrNoSelectClause
+	Field string
+	// Tag is the soql tag of the offending member
+	Tag string
+	// Err is the reason of the failure and is one of the sentinel errors like ErrInvalidTag
+	Err error
+}
+
+func (e *MarshalError) Error() string {
+	var buff strings.Builder
+	buff.WriteString(e.Err.Error())
+	buff.WriteString(": ")
+	if e.Type != nil {
+		buff.WriteString(e.Type.String())
+	}
+	if e.Field != "" {
+		buff.WriteString(period)
+		buff.WriteString(e.Field)
+	}
+	if e.Tag != "" {
+		buff.WriteString(" with soql tag \"")
+		buff.WriteString(e.Tag)
+		buff.WriteString(doubleQuote)
+	}
+	return buff.String()
+}
+
+// Unwrap returns the sentinel error so that MarshalError can be used with errors.Is
+func (e *MarshalError) Unwrap() error {
+	return e.Err
+}
+
+// newMarshalError returns err annotated with the member at index of reflectedType. When err is a *MarshalError
+// returned for a nested struct, the name of the member is prepended to its field path instead.
+func newMarshalError(err error, reflectedType reflect.Type, index int) error {
+	field := reflectedType.Field(index)
+	return annotateMarshalError(err, reflectedType, field.Name, field.Tag.Get(SoqlTag))
+}
+
+// WrapMarshalError returns err annotated with member field of the struct v points to, which has soql tag tag,
+// the same way as Marshal annotates errors. It is used by the code generated by soqlmarshal command.
+func WrapMarshalError(err error, v interface{}, field, tag string) error {
+	return annotateMarshalError(err, reflect.TypeOf(v).Elem(), field, tag)
+}
+
+func annotateMarshalError(err error, reflectedType reflect.Type, field, tag string) error {
+	marshalErr, ok := err.(*MarshalError)
+	if !ok {
+		return &MarshalError{Type: reflectedType, Field: field, Tag: tag, Err: err}
+	}
+	path := field
+	if marshalErr.Field != "" {
+		path += period + marshalErr.Field
+	}
+	if marshalErr.Tag != "" {
+		tag = marshalErr.Tag
+	}
+	return &MarshalError{Type: reflectedType, Field: path, Tag: tag, Err: marshalErr.Err}
+}
@@ -1,6 +1,6 @@
 module github.com/forcedotcom/go-soql
 
-go 1.12
+go 1.13
 
 require (
 	github.com/onsi/ginkgo v1.10.3
@@ -46,6 +46,9 @@ const (
 	closeLike                       = "%'"
 	inOperator                      = " IN "
 	notInOperator                   = " NOT IN "
+	includesOperator                = " INCLUDES "
+	excludesOperator                = " EXCLUDES "
+	semicolon                       = ";"
 	equalsOperator                  = " = "
 	period                          = "."
 	null                            = "null"
@@ -70,8 +73,19 @@ const (
 	orderByKeyword                  = " ORDER BY "
 	limitKeyword                    = " LIMIT "
 	offsetKeyword                   = " OFFSET "
+	forKeyword                      = " FOR "
+	usingScopeKeyword               = " USING SCOPE "
+	fieldsFunction                  = "FIELDS"
+	groupByKeyword                  = " GROUP BY "
+	havingKeyword                   = " HAVING "
+	space                           = " "
 	ascKeyword                      = " ASC"
 	descKeyword                     = " DESC"
+	typeOfKeyword                   = "TYPEOF "
+	whenKeyword                     = "WHEN "
+	thenKeyword                     = " THEN "
+	elseKeyword                     = " ELSE "
+	endKeyword                      = " END"
 
 	// DateTimeFormat is the golang reference time in the soql dateTime fields format
 	DateTimeFormat = "2006-01-02T15:04:05.000-0700"
@@ -87,6 +101,15 @@ const (
 	SelectColumn = "selectColumn"
 	// SelectChild is the tag to be used when selecting from child tables
 	SelectChild = "selectChild"
+	// SelectTypeOf is the tag to be used for selecting the fields of a polymorphic relationship, like What of
+	// Task, that depend on the sObject of the related record using TYPEOF
+	SelectTypeOf = "selectTypeOf"
+	// SelectWhen is the tag to be used in the struct tagged with selectTypeOf for the columns selected when the
+	// related record is the sObject given by tableName parameter
+	SelectWhen = "selectWhen"
+	// SelectElse is the tag to be used in the struct tagged with selectTypeOf for the columns selected when the
+	// related record is none of the sObjects of selectWhen members
+	SelectElse = "selectElse"
 	// FieldName is the parameter to be used to specify the name of the field in underlying SOQL object
 	FieldName = "fieldName"
 	// WhereClause is the tag to be used when marking the struct to be considered for where clause
@@ -109,6 +132,10 @@ const (
 	InOperator = "inOperator"
 	// NotInOperator is the tag to be used for "not in" operator in where clause
 	NotInOperator = "notInOperator"
+	// IncludesOperator is the tag to be used for "includes" operator on multi-select picklists in where clause
+	IncludesOperator = "includesOperator"
+	// ExcludesOperator is the tag to be used for "excludes" operator on multi-select picklists in where clause
+	ExcludesOperator = "excludesOperator"
 	// EqualsOperator is the tag to be used for "=" operator in where clause
 	EqualsOperator = "equalsOperator"
 	// NotEqualsOperator is the tag to be used for "!=" operator in where clause
@@ -146,13 +173,138 @@ const (
 
 	// Subquery is the tag to be used for a subquery in a where clause
 	Subquery = "subquery"
+	// NotOption is the option to be used with subquery to negate the conditions of the group, e.g. (NOT (A OR B))
+	NotOption = "not"
+
+	// SelectCount is the tag to be used for selecting COUNT(fieldName) aggregate in select clause
+	SelectCount = "selectCount"
+	// SelectCountDistinct is the tag to be used for selecting COUNT_DISTINCT(fieldName) aggregate in select clause
+	SelectCountDistinct = "selectCountDistinct"
+	// SelectSum is the tag to be used for selecting SUM(fieldName) aggregate in select clause
+	SelectSum = "selectSum"
+	// SelectAvg is the tag to be used for selecting AVG(fieldName) aggregate in select clause
+	SelectAvg = "selectAvg"
+	// SelectMin is the tag to be used for selecting MIN(fieldName) aggregate in select clause
+	SelectMin = "selectMin"
+	// SelectMax is the tag to be used for selecting MAX(fieldName) aggregate in select clause
+	SelectMax = "selectMax"
+	// SelectGrouping is the tag to be used for selecting GROUPING(fieldName) in select clause of queries
+	// using GROUP BY ROLLUP or GROUP BY CUBE
+	SelectGrouping = "selectGrouping"
+	// Alias is the parameter to be used to specify the alias of aggregate columns in select clause
+	Alias = "alias"
+	// GroupByClause is the tag to be used when marking the string slice to be considered for group by clause
+	GroupByClause = "groupByClause"
+	// GroupByType is the parameter to be used with groupByClause to group by ROLLUP or CUBE
+	GroupByType = "type"
+	// Rollup is the value of type parameter of groupByClause for GROUP BY ROLLUP
+	Rollup = "rollup"
+	// Cube is the value of type parameter of groupByClause for GROUP BY CUBE
+	Cube = "cube"
+	// HavingClause is the tag to be used when marking the struct to be considered for having clause
+	HavingClause = "havingClause"
+	// WithClause is the tag to be used when marking the SecurityMode to be considered for with clause
+	WithClause = "withClause"
+	// ForClause is the tag to be used when marking the ForMode to be considered for for clause
+	ForClause = "forClause"
+	// UsingScope is the tag to be used when marking the FilterScope to be considered for using scope clause
+	UsingScope = "usingScope"
+	// Fields is the parameter to be used with selectClause to select FIELDS(STANDARD), FIELDS(CUSTOM) or
+	// FIELDS(ALL) along with the columns of the struct
+	Fields = "fields"
+	// FieldsStandard is the value of fields parameter of selectClause for FIELDS(STANDARD)
+	FieldsStandard = "standard"
+	// FieldsCustom is the value of fields parameter of selectClause for FIELDS(CUSTOM)
+	FieldsCustom = "custom"
+	// FieldsAll is the value of fields parameter of selectClause for FIELDS(ALL)
+	FieldsAll = "all"
+	// MaxFieldsLimit is the largest limitClause allowed for queries selecting FIELDS(CUSTOM) or FIELDS(ALL)
+	MaxFieldsLimit = 200
 )
 
+// SecurityMode is the value of withClause, which makes Salesforce enforce the field and object level security
+// of the running user on the query
+type SecurityMode string
+
+// Security modes supported by with clause
+const (
+	// SecurityEnforced fails the query if the running user cannot access the fields or objects it selects.
+	// It is the only mode allowed in child queries.
+	SecurityEnforced SecurityMode = "SECURITY_ENFORCED"
+	// UserMode evaluates the query with the sharing rules and permissions of the running user
+	UserMode SecurityMode = "USER_MODE"
+	// SystemMode evaluates the query with the permissions of the system
+	SystemMode SecurityMode = "SYSTEM_MODE"
+)
+
+var securityModes = map[SecurityMode]bool{
+	SecurityEnforced: true,
+	UserMode:         true,
+	SystemMode:       true,
+}
+
+// ForMode is the value of forClause, which is written at the end of top level queries
+type ForMode string
+
+// Modes supported by for clause
+const (
+	// ForView updates the recently viewed data of the returned records
+	ForView ForMode = "VIEW"
+	// ForReference updates the recently referenced data of the returned records
+	ForReference ForMode = "REFERENCE"
+	// ForUpdate locks the returned records until the transaction ends. It cannot be combined with ORDER BY.
+	ForUpdate ForMode = "UPDATE"
+)
+
+var forModes = map[ForMode]bool{
+	ForView:      true,
+	ForReference: true,
+	ForUpdate:    true,
+}
+
+// FilterScope is the value of usingScope, which limits the records of the query to the given scope, e.g. to
+// the records the running user owns
+type FilterScope string
+
+// Scopes supported by using scope clause
+const (
+	// ScopeDelegated returns the records delegated to another user
+	ScopeDelegated FilterScope = "delegated"
+	// ScopeEverything returns all records, which is the default
+	ScopeEverything FilterScope = "everything"
+	// ScopeMine returns the records the running user owns
+	ScopeMine FilterScope = "mine"
+	// ScopeMineAndMyGroups returns the records the running user or the queues of the user own
+	ScopeMineAndMyGroups FilterScope = "mine_and_my_groups"
+	// ScopeMyTerritory returns the records in the territories of the running user
+	ScopeMyTerritory FilterScope = "my_territory"
+	// ScopeMyTeamTerritory returns the records in the territories of the team of the running user
+	ScopeMyTeamTerritory FilterScope = "my_team_territory"
+	// ScopeTeam returns the records assigned to a team
+	ScopeTeam FilterScope = "team"
+	// ScopeScopingRule returns the records matching the scoping rule of the running user
+	ScopeScopingRule FilterScope = "scopingRule"
+)
+
+// filterScopes maps the scopes in lower case to the way they are written in queries
+var filterScopes = map[FilterScope]FilterScope{
+	ScopeDelegated:       ScopeDelegated,
+	ScopeEverything:      ScopeEverything,
+	ScopeMine:            ScopeMine,
+	ScopeMineAndMyGroups: ScopeMineAndMyGroups,
+	ScopeMyTerritory:     ScopeMyTerritory,
+	ScopeMyTeamTerritory: ScopeMyTeamTerritory,
+	ScopeTeam:            ScopeTeam,
+	"scopingrule":        ScopeScopingRule,
+}
+
 var clauseBuilderMap = map[string]func(v interface{}, fieldName string, tags map[string]string) (string, error){
 	LikeOperator:                    buildLikeClause,
 	NotLikeOperator:                 buildNotLikeClause,
 	InOperator:                      buildInClause,
 	NotInOperator:                   buildNotInClause,
+	IncludesOperator:                buildIncludesClause,
+	ExcludesOperator:                buildExcludesClause,
 	EqualsOperator:                  buildEqualsClause,
 	NullOperator:                    buildNullClause,
 	NotEqualsOperator:               buildNotEqualsClause,
@@ -172,6 +324,17 @@ var clauseBuilderMap = map[string]func(v interface{}, fieldName string, tags map
 	LessOrEqualLastNDaysOperator:    buildLessOrEqualLastNDaysOperator,
 }
 
+// aggregateFunctions maps the aggregate column tags to SOQL aggregate functions
+var aggregateFunctions = map[string]string{
+	SelectCount:         "COUNT",
+	SelectCountDistinct: "COUNT_DISTINCT",
+	SelectSum:           "SUM",
+	SelectAvg:           "AVG",
+	SelectMin:           "MIN",
+	SelectMax:           "MAX",
+	SelectGrouping:      "GROUPING",
+}
+
 var (
 	// ErrInvalidTag error is returned when invalid key is used in soql tag
 	ErrInvalidTag = errors.New("ErrInvalidTag")
@@ -201,6 +364,10 @@ var (
 	// ErrInvalidLimitClause error is returned when field with limitClause tag is invalid
 	ErrInvalidLimitClause = errors.New("ErrInvalidLimitClause")
 
+	// ErrInvalidFieldsLimit error is returned when query selecting FIELDS(CUSTOM) or FIELDS(ALL) has no
+	// limitClause or its value is greater than MaxFieldsLimit
+	ErrInvalidFieldsLimit = errors.New("ErrInvalidFieldsLimit")
+
 	// ErrMultipleLimitClause error is returned when there are multiple limitClause in struct
 	ErrMultipleLimitClause = errors.New("ErrMultipleLimitClause")
 
@@ -209,8 +376,45 @@ var (
 
 	// ErrMultipleOffsetClause error is returned when there are multiple offsetClause in struct
 	ErrMultipleOffsetClause = errors.New("ErrMultipleOffsetClause")
+
+	// ErrInvalidGroupByClause error is returned when field with groupByClause tag is invalid
+	ErrInvalidGroupByClause = errors.New("ErrInvalidGroupByClause")
+
+	// ErrMultipleGroupByClause error is returned when there are multiple groupByClause in struct
+	ErrMultipleGroupByClause = errors.New("ErrMultipleGroupByClause")
+
+	// ErrMultipleHavingClause error is returned when there are multiple havingClause in struct
+	ErrMultipleHavingClause = errors.New("ErrMultipleHavingClause")
+
+	// ErrInvalidWithClause error is returned when field with withClause tag is not a SecurityMode, is not one of
+	// the security modes or is not SecurityEnforced in child query
+	ErrInvalidWithClause = errors.New("ErrInvalidWithClause")
+
+	// ErrMultipleWithClause error is returned when there are multiple withClause in struct
+	ErrMultipleWithClause = errors.New("ErrMultipleWithClause")
+
+	// ErrInvalidForClause error is returned when field with forClause tag is not a ForMode, is not one of the
+	// modes, is set in child query or is ForUpdate in query with order by clause
+	ErrInvalidForClause = errors.New("ErrInvalidForClause")
+
+	// ErrMultipleForClause error is returned when there are multiple forClause in struct
+	ErrMultipleForClause = errors.New("ErrMultipleForClause")
+
+	// ErrInvalidUsingScope error is returned when field with usingScope tag is not a FilterScope or is not one
+	// of the scopes
+	ErrInvalidUsingScope = errors.New("ErrInvalidUsingScope")
+
+	// ErrMultipleUsingScope error is returned when there are multiple usingScope in struct
+	ErrMultipleUsingScope = errors.New("ErrMultipleUsingScope")
 )
 
+// Marshaler is the interface implemented by soql structs that construct their SOQL query themselves. The
+// MarshalSOQL methods generated by soqlmarshal command implement it without reflection and return the same
+// query and errors as Marshal would.
+type Marshaler interface {
+	MarshalSOQL() (string, error)
+}
+
 // Order is the struct for defining the order by clause on a per column basis
 // A slice of this struct tagged with the orderByClause tag in a soql struct
 // specifies the columns from the selectClause struct to be included in the
@@ -245,6 +449,8 @@ var sanitizeLikeCharacters = append(
 
 var sanitizeLikeReplacer = strings.NewReplacer(sanitizeLikeCharacters...)
 
+var timeType = reflect.TypeOf(time.Time{})
+
 func buildLikeClause(v interface{}, fieldName string, tags map[string]string) (string, error) {
 	return constructLikeClause(v, fieldName, false)
 }
@@ -257,7 +463,10 @@ func constructLikeClause(v interface{}, fieldName string, exclude bool) (string,
 	var buff strings.Builder
 	patterns, ok := v.([]string)
 	if !ok {
-		return buff.String(), ErrInvalidTag
+		var err error
+		if patterns, err = formatTextValues(v); err != nil {
+			return buff.String(), err
+		}
 	}
 	if len(patterns) > 1 {
 		buff.WriteString(openBrace)
@@ -298,6 +507,32 @@ func buildNotInClause(v interface{}, fieldName string, tags map[string]string) (
 
 func constructContainsClause(v interface{}, fieldName string, operator string, tags map[string]string) (string, error) {
 	var buff strings.Builder
+	items, useSingleQuotes, err := formatContainsValues(v, tags)
+	if err != nil {
+		return buff.String(), err
+	}
+
+	if len(items) > 0 {
+		buff.WriteString(fieldName)
+		buff.WriteString(operator)
+		buff.WriteString(openBrace)
+	}
+	for indx, item := range items {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		buff.WriteString(quoteValue(item, useSingleQuotes))
+	}
+	if len(items) > 0 {
+		buff.WriteString(closeBrace)
+	}
+	return buff.String(), nil
+}
+
+// formatContainsValues returns the items of slice v as they are written in IN and NOT IN clauses along
+// with whether they need to be quoted. The returned slice can be v itself, so it must not be modified.
+// Items of other types than the ones below are formatted by formatComparisonValue and returned quoted.
+func formatContainsValues(v interface{}, tags map[string]string) ([]string, bool, error) {
 	var items []string
 	useSingleQuotes := false
 
@@ -311,33 +546,114 @@ func constructContainsClause(v interface{}, fieldName string, operator string, t
 		for _, item := range u {
 			items = append(items, item.Format(getDateFormat(tags)))
 		}
+	case []DateLiteral:
+		for _, item := range u {
+			if err := item.validate(); err != nil {
+				return nil, false, err
+			}
+			items = append(items, string(item))
+		}
 	default:
-		return buff.String(), ErrInvalidTag
+		var err error
+		if items, err = formatCustomValues(v, tags); err != nil {
+			return nil, false, err
+		}
 	}
+	return items, useSingleQuotes, nil
+}
 
-	if len(items) > 0 {
-		buff.WriteString(fieldName)
-		buff.WriteString(operator)
-		buff.WriteString(openBrace)
+// quoteValue returns value sanitized and wrapped in single quotes if useSingleQuotes is set
+func quoteValue(value string, useSingleQuotes bool) string {
+	if !useSingleQuotes {
+		return value
+	}
+	return singleQuote + sanitizeReplacer.Replace(value) + singleQuote
+}
+
+func buildIncludesClause(v interface{}, fieldName string, tags map[string]string) (string, error) {
+	return constructMultiSelectClause(v, fieldName, includesOperator)
+}
+
+func buildExcludesClause(v interface{}, fieldName string, tags map[string]string) (string, error) {
+	return constructMultiSelectClause(v, fieldName, excludesOperator)
+}
+
+// constructMultiSelectClause builds INCLUDES or EXCLUDES clause for multi-select picklist. v is either
+// []string, where each value is a separate item, or [][]string, where the values of each inner slice are
+// joined with semicolon into one item that matches only if all of them are selected. Strings can be of any
+// type written as quoted strings instead.
+func constructMultiSelectClause(v interface{}, fieldName string, operator string) (string, error) {
+	var items []string
+	switch u := v.(type) {
+	case []string:
+		for _, item := range u {
+			items = append(items, sanitizeReplacer.Replace(item))
+		}
+	case [][]string:
+		for _, values := range u {
+			if item := joinMultiSelectValues(values); item != "" {
+				items = append(items, item)
+			}
+		}
+	default:
+		reflectedValue := reflect.ValueOf(v)
+		if reflectedValue.Kind() == reflect.Slice && reflectedValue.Type().Elem().Kind() == reflect.Slice &&
+			!implementsValuer(reflectedValue.Type()) && !isTextType(reflectedValue.Type().Elem()) {
+			if !isTextType(reflectedValue.Type().Elem().Elem()) {
+				return "", ErrInvalidTag
+			}
+			for indx := 0; indx < reflectedValue.Len(); indx++ {
+				values, err := formatTextValues(reflectedValue.Index(indx).Interface())
+				if err != nil {
+					return "", err
+				}
+				if item := joinMultiSelectValues(values); item != "" {
+					items = append(items, item)
+				}
+			}
+			break
+		}
+		values, err := formatTextValues(v)
+		if err != nil {
+			return "", err
+		}
+		for _, item := range values {
+			items = append(items, sanitizeReplacer.Replace(item))
+		}
 	}
+	if len(items) == 0 {
+		return "", nil
+	}
+
+	var buff strings.Builder
+	buff.WriteString(fieldName)
+	buff.WriteString(operator)
+	buff.WriteString(openBrace)
 	for indx, item := range items {
 		if indx > 0 {
 			buff.WriteString(comma)
 		}
-		if useSingleQuotes {
-			buff.WriteString(singleQuote)
-			buff.WriteString(sanitizeReplacer.Replace(item))
-			buff.WriteString(singleQuote)
-		} else {
-			buff.WriteString(item)
-		}
-	}
-	if len(items) > 0 {
-		buff.WriteString(closeBrace)
+		buff.WriteString(singleQuote)
+		buff.WriteString(item)
+		buff.WriteString(singleQuote)
 	}
+	buff.WriteString(closeBrace)
 	return buff.String(), nil
 }
 
+// joinMultiSelectValues returns values sanitized and joined with semicolon into one item of INCLUDES or
+// EXCLUDES clause, or empty string if there are no values
+func joinMultiSelectValues(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	sanitizedValues := make([]string, 0, len(values))
+	for _, value := range values {
+		sanitizedValues = append(sanitizedValues, sanitizeReplacer.Replace(value))
+	}
+	return strings.Join(sanitizedValues, semicolon)
+}
+
 func buildNotEqualsClause(v interface{}, fieldName string, tags map[string]string) (string, error) {
 	return constructComparisonClause(v, fieldName, notEqualsOperator, tags)
 }
@@ -364,6 +680,23 @@ func buildLessThanOrEqualsToClause(v interface{}, fieldName string, tags map[str
 
 func constructComparisonClause(v interface{}, fieldName, operator string, tags map[string]string) (string, error) {
 	var buff strings.Builder
+	value, useSingleQuotes, err := formatComparisonValue(v, tags)
+	if err != nil {
+		return buff.String(), err
+	}
+
+	if value != "" {
+		buff.WriteString(fieldName)
+		buff.WriteString(operator)
+		buff.WriteString(quoteValue(value, useSingleQuotes))
+	}
+	return buff.String(), nil
+}
+
+// formatComparisonValue returns v as it is written in comparison clauses along with whether it needs to be
+// quoted. Empty string is returned for nil pointers and empty date literals. Values of other types than the
+// ones below are formatted by formatCustomValue.
+func formatComparisonValue(v interface{}, tags map[string]string) (string, bool, error) {
 	var value string
 	useSingleQuotes := false
 
@@ -383,22 +716,21 @@ func constructComparisonClause(v interface{}, fieldName, operator string, tags m
 		if !reflect.ValueOf(u).IsNil() {
 			value = reflect.Indirect(reflect.ValueOf(u)).Interface().(time.Time).Format(getDateFormat(tags))
 		}
-	default:
-		return buff.String(), ErrInvalidTag
-	}
-
-	if value != "" {
-		buff.WriteString(fieldName)
-		buff.WriteString(operator)
-		if useSingleQuotes {
-			buff.WriteString(singleQuote)
-			buff.WriteString(sanitizeReplacer.Replace(value))
-			buff.WriteString(singleQuote)
-		} else {
-			buff.WriteString(value)
+	case DateLiteral:
+		if u != "" {
+			if err := u.validate(); err != nil {
+				return "", false, err
+			}
+			value = string(u)
 		}
+	case *DateLiteral:
+		if u != nil {
+			return formatComparisonValue(*u, tags)
+		}
+	default:
+		return formatCustomValue(v, tags)
 	}
-	return buff.String(), nil
+	return value, useSingleQuotes, nil
 }
 
 func buildGreaterNextNDaysOperator(v interface{}, fieldName string, tags map[string]string) (string, error) {
@@ -453,7 +785,10 @@ func constructDateLiteralsClause(v interface{}, fieldName string, operator strin
 			value = fmt.Sprint(reflect.Indirect(reflect.ValueOf(u)))
 		}
 	default:
-		return buff.String(), ErrInvalidTag
+		var err error
+		if value, err = formatDaysValue(v); err != nil {
+			return buff.String(), err
+		}
 	}
 
 	if value != "" {
@@ -470,12 +805,10 @@ func buildNullClause(v interface{}, fieldName string, tags map[string]string) (s
 		// Not an error case because nil value for *bool is valid
 		return "", nil
 	}
-	val := reflectedValue.Interface()
-	allowNull, ok := val.(bool)
-	if !ok {
+	if reflectedValue.Kind() != reflect.Bool {
 		return "", ErrInvalidTag
 	}
-	if allowNull {
+	if reflectedValue.Bool() {
 		return fieldName + equalsOperator + null, nil
 	}
 	return fieldName + notEqualsOperator + null, nil
@@ -496,6 +829,20 @@ func getReflectedValueAndType(v interface{}) (reflect.Value, reflect.Type, error
 	return reflectedValue, reflectedType, nil
 }
 
+// getSelectStructValueAndType returns the struct describing the select columns. The member tagged with
+// selectClause can also be a slice of such structs (or pointers to them) so that it can hold the records
+// decoded by Unmarshal, in which case the zero value of the element struct is returned.
+func getSelectStructValueAndType(reflectedValue reflect.Value, reflectedType reflect.Type) (reflect.Value, reflect.Type) {
+	if reflectedType.Kind() != reflect.Slice {
+		return reflectedValue, reflectedType
+	}
+	elemType := reflectedType.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	return reflect.New(elemType).Elem(), elemType
+}
+
 // mapSelectColumns maps the selectColumn field name in the soql tag to their
 // corresponding field name in the struct needed by marshalOrderByClause
 func mapSelectColumns(mappings map[string]string, parent string, gusParent string, v interface{}) error {
@@ -503,6 +850,7 @@ func mapSelectColumns(mappings map[string]string, parent string, gusParent strin
 	if err != nil {
 		return ErrInvalidSelectColumnOrderByClause
 	}
+	reflectedValue, reflectedType = getSelectStructValueAndType(reflectedValue, reflectedType)
 
 	for i := 0; i < reflectedType.NumField(); i++ {
 		field := reflectedType.Field(i)
@@ -510,8 +858,10 @@ func mapSelectColumns(mappings map[string]string, parent string, gusParent strin
 		if tag == "" {
 			continue
 		}
-		// skip all fields that are not tagged as selectColumn
-		if getClauseKey(tag) != SelectColumn {
+		clauseKey := getClauseKey(tag)
+		_, isAggregate := aggregateFunctions[clauseKey]
+		// skip all fields that are not tagged as selectColumn or aggregate columns
+		if clauseKey != SelectColumn && !isAggregate {
 			continue
 		}
 
@@ -524,6 +874,18 @@ func mapSelectColumns(mappings map[string]string, parent string, gusParent strin
 			gusFieldName = gusParent + period + gusFieldName
 		}
 
+		if isAggregate {
+			// aggregate columns can be referenced by their alias as well
+			gusFieldName = getAggregateColumn(clauseKey, gusFieldName)
+			if alias := getTagValue(tag, Alias, ""); alias != "" {
+				if _, ok := mappings[alias]; !ok {
+					mappings[alias] = gusFieldName
+				}
+			}
+			mappings[fieldName] = gusFieldName
+			continue
+		}
+
 		fieldValue := reflectedValue.Field(i)
 
 		// the mapping for a struct field should be added regardless, to cover
@@ -560,18 +922,13 @@ func marshalOrderByClause(v interface{}, tableName string, s interface{}) (strin
 	if err != nil {
 		return "", err
 	}
+	_, sReflectedType = getSelectStructValueAndType(sReflectedValue, sReflectedType)
 
 	if sReflectedType.Kind() != reflect.Struct {
 		return "", ErrInvalidSelectColumnOrderByClause
 	}
 
-	columnMappings := make(map[string]string)
-
-	err = mapSelectColumns(columnMappings, "", "", sReflectedValue.Interface())
-	if err != nil {
-		return "", err
-	}
-
+	columnMappings := getColumnMappings(sReflectedType)
 	if len(columnMappings) == 0 {
 		return "", ErrInvalidSelectColumnOrderByClause
 	}
@@ -592,7 +949,7 @@ func marshalOrderByClause(v interface{}, tableName string, s interface{}) (strin
 		}
 
 		if tableName != "" {
-			columnName = tableName + period + columnName
+			columnName = prefixColumn(tableName, columnName)
 		}
 		orderString := ascKeyword
 		if order.IsDesc {
@@ -609,6 +966,124 @@ func marshalOrderByClause(v interface{}, tableName string, s interface{}) (strin
 
 }
 
+// getAggregateColumn returns the aggregate function of clauseKey applied on fieldName, e.g. COUNT(Id)
+func getAggregateColumn(clauseKey, fieldName string) string {
+	return aggregateFunctions[clauseKey] + openBrace + fieldName + closeBrace
+}
+
+func isAggregateColumn(columnName string) bool {
+	return strings.HasSuffix(columnName, closeBrace)
+}
+
+// prefixColumn prepends tableName to columnName. For aggregate columns tableName is prepended to the
+// field inside the function, e.g. COUNT(Case.Id)
+func prefixColumn(tableName, columnName string) string {
+	if indx := strings.Index(columnName, openBrace); indx >= 0 && isAggregateColumn(columnName) {
+		return columnName[:indx+1] + tableName + period + columnName[indx+1:]
+	}
+	return tableName + period + columnName
+}
+
+// v is the slice of names of fields of the selectClause struct to group by
+// groupByType is the value of type parameter of groupByClause
+// s is the struct value containing fields with the selectColumn tag
+func marshalGroupByClause(v interface{}, groupByType, tableName string, s interface{}) (string, error) {
+	fields, ok := v.([]string)
+	if !ok {
+		return "", ErrInvalidGroupByClause
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	var openFunction string
+	switch strings.ToLower(groupByType) {
+	case "":
+	case Rollup:
+		openFunction = "ROLLUP("
+	case Cube:
+		openFunction = "CUBE("
+	default:
+		return "", ErrInvalidGroupByClause
+	}
+
+	sReflectedValue, sReflectedType, err := getReflectedValueAndType(s)
+	if err != nil {
+		return "", ErrInvalidSelectColumnOrderByClause
+	}
+	_, sReflectedType = getSelectStructValueAndType(sReflectedValue, sReflectedType)
+	columnMappings := getColumnMappings(sReflectedType)
+
+	var buff strings.Builder
+	buff.WriteString(openFunction)
+	for indx, fieldName := range fields {
+		columnName, ok := columnMappings[fieldName]
+		if !ok || isAggregateColumn(columnName) {
+			return "", ErrInvalidGroupByClause
+		}
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		if tableName != "" {
+			columnName = prefixColumn(tableName, columnName)
+		}
+		buff.WriteString(columnName)
+	}
+	if openFunction != "" {
+		buff.WriteString(closeBrace)
+	}
+	return buff.String(), nil
+}
+
+// marshalWithClause returns the security mode v, which is empty if v is empty. Child queries only support
+// SecurityEnforced.
+func marshalWithClause(v interface{}, isChild bool) (string, error) {
+	mode, ok := v.(SecurityMode)
+	if !ok {
+		return "", ErrInvalidWithClause
+	}
+	if mode == "" {
+		return "", nil
+	}
+	mode = SecurityMode(strings.ToUpper(string(mode)))
+	if !securityModes[mode] || (isChild && mode != SecurityEnforced) {
+		return "", ErrInvalidWithClause
+	}
+	return string(mode), nil
+}
+
+// marshalForClause returns the mode v, which is empty if v is empty. Child queries do not support for clause
+// and ordered queries do not support ForUpdate.
+func marshalForClause(v interface{}, isChild, isOrdered bool) (string, error) {
+	mode, ok := v.(ForMode)
+	if !ok {
+		return "", ErrInvalidForClause
+	}
+	if mode == "" {
+		return "", nil
+	}
+	mode = ForMode(strings.ToUpper(string(mode)))
+	if !forModes[mode] || isChild || (isOrdered && mode == ForUpdate) {
+		return "", ErrInvalidForClause
+	}
+	return string(mode), nil
+}
+
+// marshalUsingScope returns the scope v as it is written in queries, which is empty if v is empty
+func marshalUsingScope(v interface{}) (string, error) {
+	scope, ok := v.(FilterScope)
+	if !ok {
+		return "", ErrInvalidUsingScope
+	}
+	if scope == "" {
+		return "", nil
+	}
+	scope, ok = filterScopes[FilterScope(strings.ToLower(string(scope)))]
+	if !ok {
+		return "", ErrInvalidUsingScope
+	}
+	return string(scope), nil
+}
+
 // v is the limit value provided
 func marshalLimitClause(v interface{}) (string, error) {
 	s, err := marshalIntValue(v)
@@ -679,71 +1154,61 @@ func marshalWhereClause(v interface{}, tableName, joiner string) (string, error)
 	var buff strings.Builder
 	reflectedValue, reflectedType, err := getReflectedValueAndType(v)
 	if err != nil {
-		return "", err
+		return "", &MarshalError{Type: reflect.TypeOf(v), Err: err}
 	}
 	previousConditionExists := false
-	for i := 0; i < reflectedValue.NumField(); i++ {
-		field := reflectedValue.Field(i)
-		fieldType := reflectedType.Field(i)
-		clauseTag := fieldType.Tag.Get(SoqlTag)
-		if clauseTag == "" {
-			continue
+	for _, f := range getWherePlan(reflectedType) {
+		if f.err != nil {
+			return "", newMarshalError(f.err, reflectedType, f.index)
 		}
-		clauseKey := getClauseKey(clauseTag)
+		field := reflectedValue.Field(f.index)
 		var partialClause string
-		if clauseKey == Subquery {
-			if field.Kind() != reflect.Struct && field.Kind() != reflect.Ptr {
-				return "", ErrInvalidTag
-			}
-			if field.Kind() == reflect.Ptr {
-				if reflect.ValueOf(field.Interface()).IsNil() {
-					continue
-				}
+		if f.clauseKey == Subquery {
+			if field.Kind() == reflect.Ptr && field.IsNil() || field.Kind() == reflect.Slice && field.Len() == 0 {
+				continue
 			}
-			joiner, err := getJoiner(clauseTag)
-			if err != nil {
-				return "", err
+			if f.joinErr != nil {
+				return "", newMarshalError(f.joinErr, reflectedType, f.index)
 			}
-			if joiner == inOperator || joiner == notInOperator {
-				fieldName := getFieldName(clauseTag, "")
-				if fieldName == "" {
-					return "", ErrInvalidTag
+			if f.isSlice {
+				partialClause, err = marshalWhereGroups(field, tableName, f.joiner)
+				if err != nil {
+					return "", newMarshalError(err, reflectedType, f.index)
 				}
+			} else if f.joiner == inOperator || f.joiner == notInOperator {
 				partialJoinQuery, err := Marshal(field.Interface())
 				if err != nil {
-					return "", err
+					return "", newMarshalError(err, reflectedType, f.index)
 				}
 
 				var queryBuff strings.Builder
-				queryBuff.WriteString(fieldName)
-				queryBuff.WriteString(joiner)
+				queryBuff.WriteString(f.joinFieldName)
+				queryBuff.WriteString(f.joiner)
 				queryBuff.WriteString(openBrace)
 				queryBuff.WriteString(partialJoinQuery)
 				queryBuff.WriteString(closeBrace)
 				partialClause = queryBuff.String()
 			} else {
-				partialClause, err = marshalWhereClause(field.Interface(), tableName, joiner)
+				partialClause, err = marshalWhereClause(field.Interface(), tableName, f.joiner)
 				if err != nil {
-					return "", err
+					return "", newMarshalError(err, reflectedType, f.index)
+				}
+				// Groups without conditions are left out rather than written as ()
+				if partialClause != "" {
+					partialClause = openBrace + partialClause + closeBrace
 				}
-				partialClause = openBrace + partialClause + closeBrace
-			}
-		} else {
-			fieldName := getFieldName(clauseTag, fieldType.Name)
-			if fieldName == "" {
-				return "", ErrInvalidTag
 			}
-			fn, ok := clauseBuilderMap[clauseKey]
-			if !ok {
-				return "", ErrInvalidTag
+			if f.not && partialClause != "" {
+				partialClause = openBrace + notOperator + partialClause + closeBrace
 			}
-			columnName := fieldName
+		} else {
+			columnName := f.fieldName
 			if tableName != "" {
-				columnName = tableName + period + fieldName
+				columnName = tableName + period + f.fieldName
 			}
-			partialClause, err = fn(field.Interface(), columnName, getTagParameterMap(clauseTag))
+			partialClause, err = f.builder(field.Interface(), columnName, f.tags)
 			if err != nil {
-				return "", err
+				return "", newMarshalError(err, reflectedType, f.index)
 			}
 		}
 		if partialClause != "" {
@@ -757,6 +1222,36 @@ func marshalWhereClause(v interface{}, tableName, joiner string) (string, error)
 	return buff.String(), nil
 }
 
+// marshalWhereGroups returns the conditions of the structs in slice v, joined by joiner within every struct and
+// ORed between the structs, e.g. ((A AND B) OR (C AND D)). Nil pointers and structs without conditions are
+// skipped, so it returns empty string when none of the structs has conditions.
+func marshalWhereGroups(v reflect.Value, tableName, joiner string) (string, error) {
+	var buff strings.Builder
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr && elem.IsNil() {
+			continue
+		}
+		partialClause, err := marshalWhereClause(elem.Interface(), tableName, joiner)
+		if err != nil {
+			return "", err
+		}
+		if partialClause == "" {
+			continue
+		}
+		if buff.Len() > 0 {
+			buff.WriteString(orCondition)
+		}
+		buff.WriteString(openBrace)
+		buff.WriteString(partialClause)
+		buff.WriteString(closeBrace)
+	}
+	if buff.Len() == 0 {
+		return "", nil
+	}
+	return openBrace + buff.String() + closeBrace, nil
+}
+
 // MarshalWhereClause returns the string with all conditions that applies for SOQL where clause.
 // As part of soql tag, you will need to specify the operator (one of the operators listed below) and
 // then specify the name of the field using fieldName parameter.
@@ -771,6 +1266,8 @@ func marshalWhereClause(v interface{}, tableName, joiner string) (string, error)
 // 8. GREATER THAN OR EQUALS TO: Greater than or equals to operator. E.g. Num_of_CPU_Cores__c >= 16. Use greaterThanOrEqualsToOperator in soql tag
 // 9. LESS THAN: Less than operator. E.g. Last_Discovered_Date__c < 2006-01-02T15:04:05.000-0700. Use lessThanOperator in soql tag
 // 10. LESS THAN OR EQUALS TO: Less than or equals to operator. E.g. Num_of_CPU_Cores__c <= 16. Use lessThanOrEqualsToOperator in soql tag
+// 11. INCLUDES: Includes operator for multi-select picklists. E.g. Roles__c INCLUDES ('db;app','web'). Use includesOperator in soql tag
+// 12. EXCLUDES: Excludes operator for multi-select picklists. E.g. Roles__c EXCLUDES ('db'). Use excludesOperator in soql tag
 // Consider following go struct
 // type TestQueryCriteria struct {
 // 	IncludeNamePattern          []string  `soql:"likeOperator,fieldName=Host_Name__c"`
@@ -800,6 +1297,17 @@ func MarshalWhereClause(v interface{}) (string, error) {
 	return marshalWhereClause(v, "", andCondition)
 }
 
+// BuildCondition returns the condition for value v of a member of whereClause struct tagged with operator, e.g.
+// EqualsOperator, and fieldName parameter, as Marshal constructs it. tags are the parameters of the soql tag,
+// of which only format is used. It is used by the code generated by soqlmarshal command.
+func BuildCondition(operator string, v interface{}, fieldName string, tags map[string]string) (string, error) {
+	builder, ok := clauseBuilderMap[operator]
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	return builder(v, fieldName, tags)
+}
+
 func getClauseKey(clauseTag string) string {
 	tagItems := strings.Split(clauseTag, ",")
 	return tagItems[0]
@@ -821,6 +1329,35 @@ func getJoiner(clauseTag string) (string, error) {
 	}
 }
 
+// getFields returns the value of fields parameter of selectClause in upper case, which is empty if it is not
+// set
+func getFields(clauseTag string) (string, error) {
+	tag := getTagValue(clauseTag, Fields, "")
+	switch strings.ToLower(tag) {
+	case FieldsStandard, FieldsCustom, FieldsAll:
+		return strings.ToUpper(tag), nil
+	case "":
+		return "", nil
+	default:
+		return "", ErrInvalidTag
+	}
+}
+
+// requiresLimit reports whether fields, which is the value returned by getFields, needs limitClause of at
+// most MaxFieldsLimit
+func requiresLimit(fields string) bool {
+	return fields == "CUSTOM" || fields == "ALL"
+}
+
+// checkFieldsLimit returns ErrInvalidFieldsLimit if limit, which is the value of limitClause, is not a pointer
+// to int of at most MaxFieldsLimit
+func checkFieldsLimit(limit interface{}) error {
+	if v, ok := limit.(*int); !ok || v == nil || *v > MaxFieldsLimit {
+		return ErrInvalidFieldsLimit
+	}
+	return nil
+}
+
 func parseTagString(tagString string) (string, string) {
 	delimInd := strings.Index(tagString, "=")
 	if delimInd == -1 {
@@ -849,6 +1386,17 @@ func getDateFormat(tags map[string]string) string {
 	return DateTimeFormat
 }
 
+// hasTagOption reports whether clauseTag has option, which is a parameter without value like not
+func hasTagOption(clauseTag, option string) bool {
+	tagItems := strings.Split(clauseTag, ",")
+	for _, tagItem := range tagItems[1:] {
+		if tagItem == option {
+			return true
+		}
+	}
+	return false
+}
+
 func getTagValue(clauseTag, key, defaultValue string) string {
 	tagItems := strings.Split(clauseTag, ",")
 	for _, tagItem := range tagItems {
@@ -928,64 +1476,120 @@ func getTableName(clauseTag, defaultTableName string) string {
 // This will print selectClause as:
 // Id,Name__c,(SELCT SM_Application_Versions__c.Version__c FROM Application_Versions__r)
 func MarshalSelectClause(v interface{}, relationShipName string) (string, error) {
-	var buff strings.Builder
 	prefix := relationShipName
 	if prefix != "" {
 		prefix += period
 	}
 	val, t, err := getReflectedValueAndType(v)
 	if err != nil {
-		return "", err
+		return "", &MarshalError{Type: reflect.TypeOf(v), Err: err}
 	}
-	if t.Kind() == reflect.Struct {
-		totalFields := t.NumField()
-		for i := 0; i < totalFields; i++ {
-			field := t.Field(i)
-			clauseTag := field.Tag.Get(SoqlTag)
-			if clauseTag == "" {
-				continue
+	val, t = getSelectStructValueAndType(val, t)
+	if t.Kind() != reflect.Struct {
+		return "", &MarshalError{Type: reflect.TypeOf(v), Err: ErrInvalidTag}
+	}
+	return marshalSelectClause(val, t, prefix)
+}
+
+func marshalSelectClause(val reflect.Value, t reflect.Type, prefix string) (string, error) {
+	var buff strings.Builder
+	for _, f := range getSelectPlan(t) {
+		if f.err != nil {
+			return "", newMarshalError(f.err, t, f.index)
+		}
+		if _, isAggregate := aggregateFunctions[f.clauseKey]; isAggregate {
+			buff.WriteString(getAggregateColumn(f.clauseKey, prefix+f.fieldName))
+			if f.alias != "" {
+				buff.WriteString(space)
+				buff.WriteString(f.alias)
 			}
-			clauseKey := getClauseKey(clauseTag)
-			isChildRelation := false
-			switch clauseKey {
-			case SelectColumn:
-				isChildRelation = false
-			case SelectChild:
-				isChildRelation = true
-			default:
-				return "", ErrInvalidTag
+		} else if f.clauseKey == SelectChild {
+			subStr, err := marshal(val.Field(f.index), f.fieldType, prefix+f.fieldName)
+			if err != nil {
+				return "", newMarshalError(err, t, f.index)
 			}
-			fieldName := getFieldName(clauseTag, field.Name)
-			if fieldName == "" {
-				return "", ErrInvalidTag
+			buff.WriteString(subStr)
+		} else if f.clauseKey == SelectTypeOf {
+			subStr, err := marshalTypeOf(f.fieldType, f.fieldName)
+			if err != nil {
+				return "", newMarshalError(err, t, f.index)
 			}
-			if isChildRelation {
-				subStr, err := marshal(val.Field(i), field.Type, prefix+fieldName)
-				if err != nil {
-					return "", err
-				}
-				buff.WriteString(subStr)
-			} else {
-				if field.Type.Kind() == reflect.Struct {
-					v := reflect.New(field.Type)
-					subStr, err := MarshalSelectClause(v.Elem().Interface(), prefix+fieldName)
-					if err != nil {
-						return "", err
-					}
-					buff.WriteString(subStr)
-				} else {
-					buff.WriteString(prefix)
-					buff.WriteString(fieldName)
-				}
+			buff.WriteString(subStr)
+		} else if f.isNested {
+			subStr, err := marshalSelectClause(reflect.Zero(f.fieldType), f.fieldType, prefix+f.fieldName+period)
+			if err != nil {
+				return "", newMarshalError(err, t, f.index)
 			}
-			buff.WriteString(comma)
+			buff.WriteString(subStr)
+		} else {
+			buff.WriteString(prefix)
+			buff.WriteString(f.fieldName)
 		}
-	} else {
-		return "", ErrInvalidTag
+		buff.WriteString(comma)
 	}
 	return strings.TrimRight(buff.String(), comma), nil
 }
 
+// marshalTypeOf returns TYPEOF expression of polymorphic relationship fieldName, which selects the columns of
+// selectWhen members of struct t for their sObjects and the columns of selectElse member for other sObjects:
+// TYPEOF What WHEN Account THEN Name,Industry ELSE Name END
+// The relationship is written without prefix, since TYPEOF cannot be used in parent relationships.
+func marshalTypeOf(t reflect.Type, fieldName string) (string, error) {
+	plan := getTypeOfPlan(t)
+	var buff strings.Builder
+	buff.WriteString(typeOfKeyword)
+	buff.WriteString(fieldName)
+	var elseColumns string
+	for _, branch := range plan.branches {
+		if branch.err != nil {
+			return "", newMarshalError(branch.err, t, branch.index)
+		}
+		columns, err := marshalTypeOfColumns(branch.structType)
+		if err != nil {
+			return "", newMarshalError(err, t, branch.index)
+		}
+		if branch.clauseKey == SelectElse {
+			elseColumns = columns
+			continue
+		}
+		buff.WriteString(space)
+		buff.WriteString(whenKeyword)
+		buff.WriteString(branch.sObject)
+		buff.WriteString(thenKeyword)
+		buff.WriteString(columns)
+	}
+	if plan.err != nil {
+		return "", plan.err
+	}
+	if elseColumns != "" {
+		buff.WriteString(elseKeyword)
+		buff.WriteString(elseColumns)
+	}
+	buff.WriteString(endKeyword)
+	return buff.String(), nil
+}
+
+// marshalTypeOfColumns returns the columns of a branch of TYPEOF, which can only select columns and parent
+// relationships of the sObject
+func marshalTypeOfColumns(t reflect.Type) (string, error) {
+	for _, f := range getSelectPlan(t) {
+		if f.err != nil {
+			break
+		}
+		if f.clauseKey != SelectColumn {
+			return "", newMarshalError(ErrInvalidTag, t, f.index)
+		}
+	}
+	columns, err := marshalSelectClause(reflect.Zero(t), t, "")
+	if err != nil {
+		return "", err
+	}
+	if columns == "" {
+		return "", ErrInvalidTag
+	}
+	return columns, nil
+}
+
 func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRelationName string) (string, error) {
 	var buff strings.Builder
 	if reflectedType.Kind() == reflect.Struct {
@@ -994,12 +1598,18 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 			// Empty struct
 			return "", nil
 		}
-		soqlTagPresent := false
+		plan := getQueryPlan(reflectedType)
 		selectClausePresent := false
 		whereClausePresent := false
 		orderByClausePresent := false
 		limitClausePresent := false
 		offsetClausePresent := false
+		groupByClausePresent := false
+		havingClausePresent := false
+		withClausePresent := false
+		forClausePresent := false
+		usingScopePresent := false
+		isOrdered := false
 		var selectSubString strings.Builder
 		var selectValue interface{}
 		var whereValue interface{}
@@ -1007,23 +1617,25 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 		var orderByValue interface{}
 		var limitValue interface{}
 		var offsetValue interface{}
+		var groupByValue interface{}
+		var groupByType string
+		var havingValue interface{}
+		var havingJoiner string
+		var withValue interface{}
+		var forValue interface{}
+		var usingScopeValue interface{}
+		var fields string
 		tableName := ""
-		for i := 0; i < totalFields; i++ {
-			field := reflectedType.Field(i)
-			clauseTag := field.Tag.Get(SoqlTag)
-			if clauseTag == "" {
-				continue
+		for _, clause := range plan.clauses {
+			if clause.err != nil {
+				return "", newMarshalError(clause.err, reflectedType, clause.index)
 			}
-			soqlTagPresent = true
-			clauseKey := getClauseKey(clauseTag)
-			switch clauseKey {
+			fieldValue := reflectedValue.Field(clause.index)
+			switch clause.clauseKey {
 			case SelectClause:
-				if selectClausePresent {
-					return "", ErrMultipleSelectClause
-				}
 				selectClausePresent = true
-				selectValue = reflectedValue.Field(i).Interface()
-				tableName = getTableName(clauseTag, field.Name)
+				selectValue = fieldValue.Interface()
+				tableName = clause.tableName
 				var relationName string
 				if childRelationName == "" {
 					relationName = ""
@@ -1031,11 +1643,21 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 					// This is child struct and we should use tableName as prefix for columns in select clause
 					relationName = tableName
 				}
-				subStr, err := MarshalSelectClause(reflectedValue.Field(i).Interface(), relationName)
+				subStr, err := MarshalSelectClause(selectValue, relationName)
 				if err != nil {
-					return "", err
+					return "", newMarshalError(err, reflectedType, clause.index)
 				}
+				fields = clause.fields
 				selectSubString.WriteString(selectKeyword)
+				if fields != "" {
+					selectSubString.WriteString(fieldsFunction)
+					selectSubString.WriteString(openBrace)
+					selectSubString.WriteString(fields)
+					selectSubString.WriteString(closeBrace)
+					if subStr != "" {
+						selectSubString.WriteString(comma)
+					}
+				}
 				selectSubString.WriteString(subStr)
 				selectSubString.WriteString(fromKeyword)
 				if childRelationName == "" {
@@ -1046,45 +1668,54 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 					selectSubString.WriteString(childRelationName)
 				}
 			case WhereClause:
-				if whereClausePresent {
-					return "", ErrMultipleWhereClause
-				}
 				whereClausePresent = true
-				whereValue = reflectedValue.Field(i).Interface()
-				var err error
-				whereJoiner, err = getJoiner(clauseTag)
-				if err != nil {
-					return "", err
-				}
+				whereValue = fieldValue.Interface()
+				whereJoiner = clause.joiner
 			case OrderByClause:
-				if orderByClausePresent {
-					return "", ErrMultipleOrderByClause
-				}
-				orderByValue = reflectedValue.Field(i).Interface()
+				orderByValue = fieldValue.Interface()
 				orderByClausePresent = true
 			case LimitClause:
-				if limitClausePresent {
-					return "", ErrMultipleLimitClause
-				}
-				limitValue = reflectedValue.Field(i).Interface()
+				limitValue = fieldValue.Interface()
 				limitClausePresent = true
 			case OffsetClause:
-				if offsetClausePresent {
-					return "", ErrMultipleOffsetClause
-				}
-				offsetValue = reflectedValue.Field(i).Interface()
+				offsetValue = fieldValue.Interface()
 				offsetClausePresent = true
-			default:
-				return "", ErrInvalidTag
+			case GroupByClause:
+				groupByValue = fieldValue.Interface()
+				groupByType = clause.groupByType
+				groupByClausePresent = true
+			case HavingClause:
+				havingValue = fieldValue.Interface()
+				havingJoiner = clause.joiner
+				havingClausePresent = true
+			case WithClause:
+				withValue = fieldValue.Interface()
+				withClausePresent = true
+			case ForClause:
+				forValue = fieldValue.Interface()
+				forClausePresent = true
+			case UsingScope:
+				usingScopeValue = fieldValue.Interface()
+				usingScopePresent = true
 			}
 		}
-		if !selectClausePresent && soqlTagPresent {
-			return "", ErrNoSelectClause
+		if !selectClausePresent && plan.soqlTagPresent {
+			return "", &MarshalError{Type: reflectedType, Err: ErrNoSelectClause}
 		}
 		if childRelationName != "" {
 			buff.WriteString(openBrace)
 		}
 		buff.WriteString(selectSubString.String())
+		if usingScopePresent {
+			subStr, err := marshalUsingScope(usingScopeValue)
+			if err != nil {
+				return "", newMarshalError(err, reflectedType, plan.indexes[UsingScope])
+			}
+			if subStr != "" {
+				buff.WriteString(usingScopeKeyword)
+				buff.WriteString(subStr)
+			}
+		}
 		if whereClausePresent {
 			relationName := ""
 			if childRelationName != "" {
@@ -1093,13 +1724,48 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 			}
 			subStr, err := marshalWhereClause(whereValue, relationName, whereJoiner)
 			if err != nil {
-				return "", err
+				return "", newMarshalError(err, reflectedType, plan.indexes[WhereClause])
 			}
 			if subStr != "" {
 				buff.WriteString(whereKeyword)
 				buff.WriteString(subStr)
 			}
 		}
+		if withClausePresent {
+			subStr, err := marshalWithClause(withValue, childRelationName != "")
+			if err != nil {
+				return "", newMarshalError(err, reflectedType, plan.indexes[WithClause])
+			}
+			if subStr != "" {
+				buff.WriteString(withKeyword)
+				buff.WriteString(subStr)
+			}
+		}
+		if groupByClausePresent {
+			relationName := ""
+			if childRelationName != "" {
+				// This is child struct and we should use tableName as prefix for columns in group by clause
+				relationName = tableName
+			}
+			subStr, err := marshalGroupByClause(groupByValue, groupByType, relationName, selectValue)
+			if err != nil {
+				return "", newMarshalError(err, reflectedType, plan.indexes[GroupByClause])
+			}
+			if subStr != "" {
+				buff.WriteString(groupByKeyword)
+				buff.WriteString(subStr)
+			}
+		}
+		if havingClausePresent {
+			subStr, err := marshalWhereClause(havingValue, "", havingJoiner)
+			if err != nil {
+				return "", newMarshalError(err, reflectedType, plan.indexes[HavingClause])
+			}
+			if subStr != "" {
+				buff.WriteString(havingKeyword)
+				buff.WriteString(subStr)
+			}
+		}
 		if orderByClausePresent {
 			relationName := ""
 			if childRelationName != "" {
@@ -1108,17 +1774,18 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 			}
 			subStr, err := marshalOrderByClause(orderByValue, relationName, selectValue)
 			if err != nil {
-				return "", err
+				return "", newMarshalError(err, reflectedType, plan.indexes[OrderByClause])
 			}
 			if subStr != "" {
 				buff.WriteString(orderByKeyword)
 				buff.WriteString(subStr)
+				isOrdered = true
 			}
 		}
 		if limitClausePresent {
 			subStr, err := marshalLimitClause(limitValue)
 			if err != nil {
-				return "", err
+				return "", newMarshalError(err, reflectedType, plan.indexes[LimitClause])
 			}
 			if subStr != "" {
 				buff.WriteString(limitKeyword)
@@ -1128,13 +1795,32 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 		if offsetClausePresent {
 			subStr, err := marshalOffsetClause(offsetValue)
 			if err != nil {
-				return "", err
+				return "", newMarshalError(err, reflectedType, plan.indexes[OffsetClause])
 			}
 			if subStr != "" {
 				buff.WriteString(offsetKeyword)
 				buff.WriteString(subStr)
 			}
 		}
+		if requiresLimit(fields) {
+			index, ok := plan.indexes[LimitClause]
+			if !ok {
+				return "", newMarshalError(ErrInvalidFieldsLimit, reflectedType, plan.indexes[SelectClause])
+			}
+			if err := checkFieldsLimit(limitValue); err != nil {
+				return "", newMarshalError(err, reflectedType, index)
+			}
+		}
+		if forClausePresent {
+			subStr, err := marshalForClause(forValue, childRelationName != "", isOrdered)
+			if err != nil {
+				return "", newMarshalError(err, reflectedType, plan.indexes[ForClause])
+			}
+			if subStr != "" {
+				buff.WriteString(forKeyword)
+				buff.WriteString(subStr)
+			}
+		}
 		if childRelationName != "" {
 			buff.WriteString(closeBrace)
 		}
@@ -1183,10 +1869,14 @@ func marshal(reflectedValue reflect.Value, reflectedType reflect.Type, childRela
 // fmt.Println(str)
 // This will print soql query as:
 // SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c WHERE (Host_Name__c LIKE '%-db%' OR Host_Name__c LIKE '%-dbmgmt%') AND Role__r.Name IN ('db','dbmgmt')
+// If v implements Marshaler, its MarshalSOQL method is used instead of reflection.
 func Marshal(v interface{}) (string, error) {
 	rv, rt, err := getReflectedValueAndType(v)
 	if err != nil {
-		return "", err
+		return "", &MarshalError{Type: reflect.TypeOf(v), Err: err}
+	}
+	if m, ok := v.(Marshaler); ok {
+		return m.MarshalSOQL()
 	}
 	return marshal(rv, rt, "")
 }
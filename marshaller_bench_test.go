@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+func benchmarkSoqlStruct() aggregateSoqlStruct {
+	isClosed := false
+	minCaseCount := 5
+	limit := 10
+	return aggregateSoqlStruct{
+		WhereClause:   aggregateFilter{IsClosed: &isClosed},
+		GroupByClause: []string{"OwnerName", "Status"},
+		HavingClause:  aggregateHaving{MinCaseCount: &minCaseCount},
+		OrderByClause: []soql.Order{{Field: "cnt", IsDesc: true}, {Field: "OwnerName"}},
+		LimitClause:   &limit,
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	soqlStruct := benchmarkSoqlStruct()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := soql.Marshal(soqlStruct); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalParallel(b *testing.B) {
+	soqlStruct := benchmarkSoqlStruct()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := soql.Marshal(soqlStruct); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkMarshalSelectClause(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := soql.MarshalSelectClause(NestedStruct{}, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalWhereClause(b *testing.B) {
+	currentTime := time.Date(2019, 3, 1, 17, 43, 53, 0, time.UTC)
+	memory := 1e+21
+	criteria := QueryCriteriaWithMixedDataTypesAndOperators{
+		BIOSType:          "98.7.654a",
+		NumOfCPUCores:     32,
+		NUMAEnabled:       true,
+		PvtTestFailCount:  256,
+		CreatedDate:       currentTime,
+		UpdatedDate:       &currentTime,
+		AllocationLatency: 10.5,
+		LastRestart:       currentTime,
+		Memory:            &memory,
+		ClosedDate:        5,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := soql.MarshalWhereClause(criteria); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -7,12 +7,15 @@
 package soql_test
 
 import (
+	"errors"
+	"reflect"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
-	. "github.com/forcedotcom/go-soql"
+	"github.com/forcedotcom/go-soql"
 )
 
 var _ = Describe("Marshaller", func() {
@@ -28,7 +31,7 @@ var _ = Describe("Marshaller", func() {
 			)
 
 			JustBeforeEach(func() {
-				clause, err = MarshalWhereClause(critetria)
+				clause, err = soql.MarshalWhereClause(critetria)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -367,12 +370,12 @@ var _ = Describe("Marshaller", func() {
 			)
 
 			JustBeforeEach(func() {
-				clause, err = MarshalWhereClause(critetria)
+				clause, err = soql.MarshalWhereClause(critetria)
 			})
 
 			Context("when nil is passed as argument", func() {
 				It("returns empty where clause", func() {
-					Expect(err).To(Equal(ErrNilValue))
+					Expect(err).To(matchMarshalError(soql.ErrNilValue))
 					Expect(clause).To(BeEmpty())
 				})
 			})
@@ -415,7 +418,7 @@ var _ = Describe("Marshaller", func() {
 				expectedClause = "CreatedDate > NEXT_N_DAYS:5 AND ClosedDate < NEXT_N_DAYS:10"
 			})
 			It("returns appropriate where clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(clause).To(Equal(expectedClause))
 			})
 		})
@@ -430,7 +433,7 @@ var _ = Describe("Marshaller", func() {
 				expectedClause = "CreatedDate > NEXT_N_DAYS:5 AND ClosedDate < NEXT_N_DAYS:10"
 			})
 			It("returns appropriate where clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(clause).To(Equal(expectedClause))
 			})
 		})
@@ -446,8 +449,8 @@ var _ = Describe("Marshaller", func() {
 				}
 			})
 			It("returns error", func() {
-				clause, err = MarshalWhereClause(criteria)
-				Expect(err).To(Equal(ErrInvalidTag))
+				clause, err = soql.MarshalWhereClause(criteria)
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 			})
 		})
 
@@ -462,7 +465,7 @@ var _ = Describe("Marshaller", func() {
 					expectedClause = "CreatedDate > NEXT_N_DAYS:5"
 				})
 				It("returns appropriate where clause", func() {
-					clause, err = MarshalWhereClause(criteria)
+					clause, err = soql.MarshalWhereClause(criteria)
 					Expect(clause).To(Equal(expectedClause))
 				})
 			})
@@ -475,7 +478,7 @@ var _ = Describe("Marshaller", func() {
 					expectedClause = "DeliveredDate >= NEXT_N_DAYS:5"
 				})
 				It("returns appropriate where clause", func() {
-					clause, err = MarshalWhereClause(criteria)
+					clause, err = soql.MarshalWhereClause(criteria)
 					Expect(clause).To(Equal(expectedClause))
 				})
 			})
@@ -488,7 +491,7 @@ var _ = Describe("Marshaller", func() {
 					expectedClause = "OtherDate = NEXT_N_DAYS:5"
 				})
 				It("returns appropriate where clause", func() {
-					clause, err = MarshalWhereClause(criteria)
+					clause, err = soql.MarshalWhereClause(criteria)
 					Expect(clause).To(Equal(expectedClause))
 				})
 			})
@@ -501,7 +504,7 @@ var _ = Describe("Marshaller", func() {
 					expectedClause = "ClosedDate < NEXT_N_DAYS:10"
 				})
 				It("returns appropriate where clause", func() {
-					clause, err = MarshalWhereClause(criteria)
+					clause, err = soql.MarshalWhereClause(criteria)
 					Expect(clause).To(Equal(expectedClause))
 				})
 			})
@@ -514,7 +517,7 @@ var _ = Describe("Marshaller", func() {
 					expectedClause = "ScheduledDate <= NEXT_N_DAYS:10"
 				})
 				It("returns appropriate where clause", func() {
-					clause, err = MarshalWhereClause(criteria)
+					clause, err = soql.MarshalWhereClause(criteria)
 					Expect(clause).To(Equal(expectedClause))
 				})
 			})
@@ -535,7 +538,7 @@ var _ = Describe("Marshaller", func() {
 					expectedClause = "CreatedDate > NEXT_N_DAYS:5 AND OtherDate = NEXT_N_DAYS:15 AND ClosedDate < NEXT_N_DAYS:10 AND ScheduledDate <= NEXT_N_DAYS:20 AND DeliveredDate >= NEXT_N_DAYS:25"
 				})
 				It("returns appropriate where clause", func() {
-					clause, err = MarshalWhereClause(criteria)
+					clause, err = soql.MarshalWhereClause(criteria)
 					Expect(clause).To(Equal(expectedClause))
 				})
 			})
@@ -552,7 +555,7 @@ var _ = Describe("Marshaller", func() {
 					expectedClause = "ClosedDate < LAST_N_DAYS:10"
 				})
 				It("returns appropriate where clause", func() {
-					clause, err = MarshalWhereClause(criteria)
+					clause, err = soql.MarshalWhereClause(criteria)
 					Expect(clause).To(Equal(expectedClause))
 				})
 				Context("when there is only lessOrEqualLastNDaysOperator operator", func() {
@@ -564,7 +567,7 @@ var _ = Describe("Marshaller", func() {
 						expectedClause = "ScheduledDate <= LAST_N_DAYS:10"
 					})
 					It("returns appropriate where clause", func() {
-						clause, err = MarshalWhereClause(criteria)
+						clause, err = soql.MarshalWhereClause(criteria)
 						Expect(clause).To(Equal(expectedClause))
 					})
 				})
@@ -577,7 +580,7 @@ var _ = Describe("Marshaller", func() {
 						expectedClause = "CreatedDate > LAST_N_DAYS:5"
 					})
 					It("returns appropriate where clause", func() {
-						clause, err = MarshalWhereClause(criteria)
+						clause, err = soql.MarshalWhereClause(criteria)
 						Expect(clause).To(Equal(expectedClause))
 					})
 				})
@@ -590,7 +593,7 @@ var _ = Describe("Marshaller", func() {
 						expectedClause = "DeliveredDate >= LAST_N_DAYS:5"
 					})
 					It("returns appropriate where clause", func() {
-						clause, err = MarshalWhereClause(criteria)
+						clause, err = soql.MarshalWhereClause(criteria)
 						Expect(clause).To(Equal(expectedClause))
 					})
 				})
@@ -603,7 +606,7 @@ var _ = Describe("Marshaller", func() {
 						expectedClause = "OtherDate = LAST_N_DAYS:5"
 					})
 					It("returns appropriate where clause", func() {
-						clause, err = MarshalWhereClause(criteria)
+						clause, err = soql.MarshalWhereClause(criteria)
 						Expect(clause).To(Equal(expectedClause))
 					})
 				})
@@ -624,7 +627,7 @@ var _ = Describe("Marshaller", func() {
 						expectedClause = "CreatedDate > LAST_N_DAYS:5 AND OtherDate = LAST_N_DAYS:15 AND ClosedDate < LAST_N_DAYS:10 AND ScheduledDate <= LAST_N_DAYS:20 AND DeliveredDate >= LAST_N_DAYS:25"
 					})
 					It("returns appropriate where clause", func() {
-						clause, err = MarshalWhereClause(criteria)
+						clause, err = soql.MarshalWhereClause(criteria)
 						Expect(clause).To(Equal(expectedClause))
 					})
 				})
@@ -646,7 +649,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause joined by AND clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -667,7 +670,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause joined by AND clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -685,7 +688,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause joined by AND clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -703,7 +706,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause joined by skipping nil values", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -721,7 +724,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause joined by AND clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -739,7 +742,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause by skipping nil values", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -757,7 +760,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause without error§q", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -772,11 +775,11 @@ var _ = Describe("Marshaller", func() {
 					CreatedDate: currentTime,
 				}
 
-				expectedClause = "CreatedDate = " + currentTime.Format(DateTimeFormat)
+				expectedClause = "CreatedDate = " + currentTime.Format(soql.DateTimeFormat)
 			})
 
 			It("returns properly formed clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -792,11 +795,11 @@ var _ = Describe("Marshaller", func() {
 					ResolvedDate: nil,
 				}
 
-				expectedClause = "CreatedDate = " + currentTime.Format(DateTimeFormat)
+				expectedClause = "CreatedDate = " + currentTime.Format(soql.DateTimeFormat)
 			})
 
 			It("returns properly formed clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -825,11 +828,11 @@ var _ = Describe("Marshaller", func() {
 					NumHardDrives:                    &numHardDrives,
 				}
 
-				expectedClause = "BIOS_Type__c = '98.7.654a' AND Num_of_CPU_Cores__c > 32 AND NUMA_Enabled__c = true AND Pvt_Test_Fail_Count__c <= 256 AND Physical_CPU_Count__c >= 4 AND CreatedDate = " + currentTime.Format(TestDateFormat) + " AND UpdatedDate = " + currentTime.Format(TestDateFormat) + " AND Disable_Alerts__c = false AND Allocation_Latency__c < 10.5 AND Major_OS_Version__c = '20' AND Number_Of_Successive_Puppet_Run_Failures__c = 0 AND Last_Restart__c > " + currentTime.Format(DateTimeFormat) + " AND NumHardDrives__c = 2 AND ClosedDate > NEXT_N_DAYS:5"
+				expectedClause = "BIOS_Type__c = '98.7.654a' AND Num_of_CPU_Cores__c > 32 AND NUMA_Enabled__c = true AND Pvt_Test_Fail_Count__c <= 256 AND Physical_CPU_Count__c >= 4 AND CreatedDate = " + currentTime.Format(TestDateFormat) + " AND UpdatedDate = " + currentTime.Format(TestDateFormat) + " AND Disable_Alerts__c = false AND Allocation_Latency__c < 10.5 AND Major_OS_Version__c = '20' AND Number_Of_Successive_Puppet_Run_Failures__c = 0 AND Last_Restart__c > " + currentTime.Format(soql.DateTimeFormat) + " AND NumHardDrives__c = 2 AND ClosedDate > NEXT_N_DAYS:5"
 			})
 
 			It("returns properly formed clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -849,12 +852,167 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause", func() {
-				clause, err = MarshalWhereClause(criteria)
+				clause, err = soql.MarshalWhereClause(criteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
 		})
 
+		Context("when clauses have date literal values", func() {
+			var criteria QueryCriteriaDateLiteralValues
+			BeforeEach(func() {
+				yesterday := soql.Yesterday
+				criteria = QueryCriteriaDateLiteralValues{
+					CreatedDate:      soql.LastNWeeks.N(4),
+					LastModifiedDate: &yesterday,
+					CloseDate:        []soql.DateLiteral{soql.Today, soql.NextNFiscalQuarters.N(2)},
+					ScheduledDate:    []soql.DateLiteral{soql.ThisQuarter},
+				}
+
+				expectedClause = "CreatedDate >= LAST_N_WEEKS:4 AND LastModifiedDate < YESTERDAY AND CloseDate IN (TODAY,NEXT_N_FISCAL_QUARTERS:2) AND Scheduled_Date__c NOT IN (THIS_QUARTER)"
+			})
+
+			It("returns properly formed clause without quotes around literals", func() {
+				clause, err = soql.MarshalWhereClause(criteria)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(clause).To(Equal(expectedClause))
+			})
+		})
+
+		Context("when date literal is not valid", func() {
+			It("returns ErrInvalidDateLiteral error", func() {
+				invalidCriteria := []QueryCriteriaDateLiteralValues{
+					{CreatedDate: "SOMEDAY"},
+					{CreatedDate: soql.LastNWeeks},
+					{CreatedDate: soql.Today.N(1)},
+					{CreatedDate: soql.NDaysAgo.N(-1)},
+					{CreatedDate: "N_DAYS_AGO:x"},
+					{CloseDate: []soql.DateLiteral{soql.Today, "today'"}},
+				}
+				for _, criteria := range invalidCriteria {
+					_, err = soql.MarshalWhereClause(criteria)
+					Expect(err).To(matchMarshalError(soql.ErrInvalidDateLiteral), string(criteria.CreatedDate))
+				}
+			})
+		})
+
+		Context("when clauses contain includes and excludes operators", func() {
+			var criteria QueryCriteriaMultiSelectPicklist
+			BeforeEach(func() {
+				criteria = QueryCriteriaMultiSelectPicklist{
+					IncludesAny:  []string{"db", "app's"},
+					IncludesAll:  [][]string{{"db", "app"}, {}, {"web"}},
+					ExcludesAny:  []string{"core"},
+					ExcludesNone: []string{},
+				}
+
+				expectedClause = "Roles__c INCLUDES ('db','app\\'s') AND Roles__c INCLUDES ('db;app','web') AND Roles__c EXCLUDES ('core')"
+			})
+
+			It("returns properly formed clause", func() {
+				clause, err = soql.MarshalWhereClause(criteria)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(clause).To(Equal(expectedClause))
+			})
+		})
+
+		Context("when clauses have values of custom types", func() {
+			var criteria QueryCriteriaCustomValues
+			BeforeEach(func() {
+				minAmount := Amount(120050)
+				enabled := Enabled(false)
+				criteria = QueryCriteriaCustomValues{
+					HostID:     "a0B'1",
+					HostIDs:    []HostID{"a0B1", "a0B2"},
+					Priority:   2,
+					Priorities: []Priority{0, 1},
+					MinAmount:  &minAmount,
+					ClosedOn:   Day{Year: 2020, Month: time.March, Day: 5},
+					Regions:    []Region{{Code: "emea"}, {Code: "na_1"}},
+					Roles:      []RoleName{"db", "app's"},
+					AllRoles:   [][]Region{{{Code: "emea"}, {Code: "apac"}}},
+					Recent:     7,
+					Enabled:    &enabled,
+				}
+				expectedClause = "Id = 'a0B\\'1' AND Host__c IN ('a0B1','a0B2') AND Priority != 'High' AND Priority NOT IN ('Low','Medium') AND " +
+					"Amount >= 1200.50 AND CloseDate < 2020-03-05 AND (Region__c LIKE '%EMEA%' OR Region__c LIKE '%NA\\_1%') AND " +
+					"Roles__c INCLUDES ('db','app\\'s') AND Regions__c EXCLUDES ('EMEA;APAC') AND CreatedDate > LAST_N_DAYS:7 AND Enabled__c != null"
+			})
+
+			It("returns properly formed clause", func() {
+				clause, err = soql.MarshalWhereClause(criteria)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(clause).To(Equal(expectedClause))
+			})
+
+			Context("when values look like dates", func() {
+				It("writes SOQLValuer unquoted and Stringer quoted", func() {
+					clause, err = soql.MarshalWhereClause(QueryCriteriaDates{
+						ClosedAfter: CivilDate{Year: 2024, Month: time.January, Day: 2},
+						ClosedOn:    []CivilDate{{Year: 2024, Month: time.February, Day: 3}, {Year: 2024, Month: time.March, Day: 4}},
+						Release:     Release{Name: "2024-01-01"},
+						Releases:    []Release{{Name: "2024-01-01"}},
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(clause).To(Equal("CloseDate > 2024-01-02 AND CloseDate IN (2024-02-03,2024-03-04) AND " +
+						"Release__c = '2024-01-01' AND Release__c LIKE '%2024-01-01%'"))
+				})
+			})
+
+			Context("when MarshalText fails", func() {
+				It("returns its error", func() {
+					criteria.Regions = []Region{{Code: "emea"}, {}}
+					_, err = soql.MarshalWhereClause(criteria)
+					Expect(err).To(matchMarshalError(errEmptyRegion))
+				})
+			})
+
+			Context("when IN list has nil pointer", func() {
+				type QueryCriteriaWithNilItem struct {
+					Amounts []*Amount `soql:"inOperator,fieldName=Amount"`
+				}
+
+				It("returns ErrNilValue error", func() {
+					amount := Amount(100)
+					_, err = soql.MarshalWhereClause(QueryCriteriaWithNilItem{Amounts: []*Amount{&amount, nil}})
+					Expect(err).To(matchMarshalError(soql.ErrNilValue))
+				})
+			})
+
+			Context("when SOQLValuer returns unquoted literal for likeOperator", func() {
+				type QueryCriteriaWithUnquotedLike struct {
+					Amounts []Amount `soql:"likeOperator,fieldName=Amount"`
+				}
+
+				It("returns ErrInvalidTag error", func() {
+					_, err = soql.MarshalWhereClause(QueryCriteriaWithUnquotedLike{Amounts: []Amount{100}})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+				})
+			})
+
+			Context("when named type is not an integer for N days operator", func() {
+				type QueryCriteriaWithNamedFloatDays struct {
+					Recent Weight `soql:"greaterLastNDaysOperator,fieldName=CreatedDate"`
+				}
+
+				It("returns ErrInvalidTag error", func() {
+					_, err = soql.MarshalWhereClause(QueryCriteriaWithNamedFloatDays{Recent: 2})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+				})
+			})
+
+			Context("when member is pointer to named string", func() {
+				type QueryCriteriaWithStringPointer struct {
+					HostID *HostID `soql:"equalsOperator,fieldName=Id"`
+				}
+
+				It("returns ErrInvalidTag error", func() {
+					_, err = soql.MarshalWhereClause(QueryCriteriaWithStringPointer{})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+				})
+			})
+		})
+
 		Context("when no fieldName parameter is specified in tag", func() {
 			var defaultFieldNameCriteria DefaultFieldNameQueryCriteria
 			BeforeEach(func() {
@@ -866,7 +1024,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns properly formed clause joined by AND clause", func() {
-				clause, err = MarshalWhereClause(defaultFieldNameCriteria)
+				clause, err = soql.MarshalWhereClause(defaultFieldNameCriteria)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(clause).To(Equal(expectedClause))
 			})
@@ -880,8 +1038,8 @@ var _ = Describe("Marshaller", func() {
 				}
 
 				It("returns ErrInvalidTag error", func() {
-					str, err := MarshalWhereClause(InvalidCriteriaStruct{})
-					Expect(err).To(Equal(ErrInvalidTag))
+					str, err := soql.MarshalWhereClause(InvalidCriteriaStruct{})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 					Expect(str).To(BeEmpty())
 				})
 			})
@@ -893,11 +1051,11 @@ var _ = Describe("Marshaller", func() {
 				}
 
 				It("returns ErrInvalidTag error", func() {
-					str, err := MarshalWhereClause(MissingFieldName{
+					str, err := soql.MarshalWhereClause(MissingFieldName{
 						SomePattern:      []string{"test"},
 						SomeOtherPattern: "foo",
 					})
-					Expect(err).To(Equal(ErrInvalidTag))
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 					Expect(str).To(BeEmpty())
 				})
 			})
@@ -907,8 +1065,8 @@ var _ = Describe("Marshaller", func() {
 					IncludeNamePattern []bool `soql:"likeOperator,fieldName=Host_Name__c"`
 				}
 				It("returns error", func() {
-					_, err := MarshalWhereClause(QueryCriteriaWithInvalidLikeOperator{})
-					Expect(err).To(Equal(ErrInvalidTag))
+					_, err := soql.MarshalWhereClause(QueryCriteriaWithInvalidLikeOperator{})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 				})
 			})
 
@@ -917,8 +1075,8 @@ var _ = Describe("Marshaller", func() {
 					ExcludeNamePattern []bool `soql:"notLikeOperator,fieldName=Host_Name__c"`
 				}
 				It("returns error", func() {
-					_, err := MarshalWhereClause(QueryCriteriaWithInvalidNotLikeOperator{})
-					Expect(err).To(Equal(ErrInvalidTag))
+					_, err := soql.MarshalWhereClause(QueryCriteriaWithInvalidNotLikeOperator{})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 				})
 			})
 
@@ -927,8 +1085,18 @@ var _ = Describe("Marshaller", func() {
 					Roles int `soql:"inOperator,fieldName=Role__c"`
 				}
 				It("returns error", func() {
-					_, err := MarshalWhereClause(QueryCriteriaWithInvalidInOperator{})
-					Expect(err).To(Equal(ErrInvalidTag))
+					_, err := soql.MarshalWhereClause(QueryCriteriaWithInvalidInOperator{})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+				})
+			})
+
+			Context("when struct has invalid type for includesOperator", func() {
+				type QueryCriteriaWithInvalidIncludesOperator struct {
+					Roles string `soql:"includesOperator,fieldName=Roles__c"`
+				}
+				It("returns error", func() {
+					_, err := soql.MarshalWhereClause(QueryCriteriaWithInvalidIncludesOperator{})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 				})
 			})
 
@@ -937,8 +1105,8 @@ var _ = Describe("Marshaller", func() {
 					Roles []int `soql:"lessThanOperator,fieldName=Role__c"`
 				}
 				It("returns error", func() {
-					_, err := MarshalWhereClause(QueryCriteriaWithInvalidComparisonOperator{})
-					Expect(err).To(Equal(ErrInvalidTag))
+					_, err := soql.MarshalWhereClause(QueryCriteriaWithInvalidComparisonOperator{})
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 				})
 			})
 		})
@@ -948,7 +1116,7 @@ var _ = Describe("Marshaller", func() {
 		Context("when valid Order slice passed as argument", func() {
 			Context("when an empty Order by slice is passed", func() {
 				It("returns empty order by clause", func() {
-					clause, err := MarshalOrderByClause([]Order{}, struct {
+					clause, err := soql.MarshalOrderByClause([]soql.Order{}, struct {
 						NumOfCPUCores int `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 					}{})
 					Expect(err).ToNot(HaveOccurred())
@@ -958,8 +1126,8 @@ var _ = Describe("Marshaller", func() {
 
 			Context("when an Order slice with single order by column desc is passed", func() {
 				It("returns a column desc partial clause", func() {
-					desc := Order{Field: "NumOfCPUCores", IsDesc: true}
-					clause, err := MarshalOrderByClause([]Order{desc}, struct {
+					desc := soql.Order{Field: "NumOfCPUCores", IsDesc: true}
+					clause, err := soql.MarshalOrderByClause([]soql.Order{desc}, struct {
 						NumOfCPUCores int `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 					}{})
 					Expect(err).ToNot(HaveOccurred())
@@ -969,8 +1137,8 @@ var _ = Describe("Marshaller", func() {
 
 			Context("when an Order slice with single order by column asc is passed", func() {
 				It("returns a column asc partial clause", func() {
-					asc := Order{Field: "NumOfCPUCores", IsDesc: false}
-					clause, err := MarshalOrderByClause([]Order{asc}, struct {
+					asc := soql.Order{Field: "NumOfCPUCores", IsDesc: false}
+					clause, err := soql.MarshalOrderByClause([]soql.Order{asc}, struct {
 						NumOfCPUCores int `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 					}{})
 					Expect(err).ToNot(HaveOccurred())
@@ -980,10 +1148,10 @@ var _ = Describe("Marshaller", func() {
 
 			Context("when an Order slice with multiple order by column ASC is passed", func() {
 				It("returns multiple columns asc partial clause", func() {
-					col1 := Order{Field: "MajorOSVersion", IsDesc: false}
-					col2 := Order{Field: "NumOfCPUCores", IsDesc: false}
-					col3 := Order{Field: "PhysicalCPUCount", IsDesc: false}
-					clause, err := MarshalOrderByClause([]Order{col1, col2, col3}, struct {
+					col1 := soql.Order{Field: "MajorOSVersion", IsDesc: false}
+					col2 := soql.Order{Field: "NumOfCPUCores", IsDesc: false}
+					col3 := soql.Order{Field: "PhysicalCPUCount", IsDesc: false}
+					clause, err := soql.MarshalOrderByClause([]soql.Order{col1, col2, col3}, struct {
 						MajorOSVersion   string    `soql:"selectColumn,fieldName=Major_OS_Version__c"`
 						NumOfCPUCores    int       `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 						PhysicalCPUCount uint8     `soql:"selectColumn,fieldName=Physical_CPU_Count__c"`
@@ -996,10 +1164,10 @@ var _ = Describe("Marshaller", func() {
 
 			Context("when an Order slice with multiple order by column DESC is passed", func() {
 				It("returns multiple columns asc partial clause", func() {
-					col1 := Order{Field: "MajorOSVersion", IsDesc: true}
-					col2 := Order{Field: "NumOfCPUCores", IsDesc: true}
-					col3 := Order{Field: "LastRestart", IsDesc: true}
-					clause, err := MarshalOrderByClause([]Order{col1, col2, col3}, struct {
+					col1 := soql.Order{Field: "MajorOSVersion", IsDesc: true}
+					col2 := soql.Order{Field: "NumOfCPUCores", IsDesc: true}
+					col3 := soql.Order{Field: "LastRestart", IsDesc: true}
+					clause, err := soql.MarshalOrderByClause([]soql.Order{col1, col2, col3}, struct {
 						MajorOSVersion   string    `soql:"selectColumn,fieldName=Major_OS_Version__c"`
 						NumOfCPUCores    int       `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 						PhysicalCPUCount uint8     `soql:"selectColumn,fieldName=Physical_CPU_Count__c"`
@@ -1012,11 +1180,11 @@ var _ = Describe("Marshaller", func() {
 
 			Context("when an Order slice with multiple order by column with mixed order is passed", func() {
 				It("returns a valid partial clause", func() {
-					col1 := Order{Field: "MajorOSVersion", IsDesc: true}
-					col2 := Order{Field: "NumOfCPUCores", IsDesc: false}
-					col3 := Order{Field: "PhysicalCPUCount", IsDesc: true}
-					col4 := Order{Field: "LastRestart", IsDesc: false}
-					clause, err := MarshalOrderByClause([]Order{col1, col2, col3, col4}, struct {
+					col1 := soql.Order{Field: "MajorOSVersion", IsDesc: true}
+					col2 := soql.Order{Field: "NumOfCPUCores", IsDesc: false}
+					col3 := soql.Order{Field: "PhysicalCPUCount", IsDesc: true}
+					col4 := soql.Order{Field: "LastRestart", IsDesc: false}
+					clause, err := soql.MarshalOrderByClause([]soql.Order{col1, col2, col3, col4}, struct {
 						MajorOSVersion   string    `soql:"selectColumn,fieldName=Major_OS_Version__c"`
 						NumOfCPUCores    int       `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 						PhysicalCPUCount uint8     `soql:"selectColumn,fieldName=Physical_CPU_Count__c"`
@@ -1031,20 +1199,20 @@ var _ = Describe("Marshaller", func() {
 		Context("when invalid order by is passed as argument", func() {
 			Context("when a slice that is not of Order type is passed as argument", func() {
 				It("returns error", func() {
-					_, err := MarshalOrderByClause([]string{"test"}, struct {
+					_, err := soql.MarshalOrderByClause([]string{"test"}, struct {
 						NumOfCPUCores int `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 					}{})
-					Expect(err).To(Equal(ErrInvalidOrderByClause))
+					Expect(err).To(Equal(soql.ErrInvalidOrderByClause))
 				})
 			})
 
 			Context("when an Order slice containing incorrect field name is passed as argument", func() {
 				It("returns error", func() {
-					col1 := Order{Field: "MajorOSVersion", IsDesc: true}
-					_, err := MarshalOrderByClause([]Order{col1}, struct {
+					col1 := soql.Order{Field: "MajorOSVersion", IsDesc: true}
+					_, err := soql.MarshalOrderByClause([]soql.Order{col1}, struct {
 						NumOfCPUCores int `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
 					}{})
-					Expect(err).To(Equal(ErrInvalidOrderByClause))
+					Expect(err).To(Equal(soql.ErrInvalidOrderByClause))
 				})
 			})
 		})
@@ -1052,19 +1220,19 @@ var _ = Describe("Marshaller", func() {
 		Context("when invalid selectColumn struct is passed as argument", func() {
 			Context("when a struct with no selectColumn is passed as argument", func() {
 				It("returns error", func() {
-					col1 := Order{Field: "MajorOSVersion", IsDesc: true}
-					_, err := MarshalOrderByClause([]Order{col1}, struct {
+					col1 := soql.Order{Field: "MajorOSVersion", IsDesc: true}
+					_, err := soql.MarshalOrderByClause([]soql.Order{col1}, struct {
 						NumOfCPUCores int `soql:"fieldName=Num_of_CPU_Cores__c"`
 					}{})
-					Expect(err).To(Equal(ErrInvalidSelectColumnOrderByClause))
+					Expect(err).To(Equal(soql.ErrInvalidSelectColumnOrderByClause))
 				})
 			})
 
 			Context("when a non-struct is passed as argument", func() {
 				It("returns error", func() {
-					col1 := Order{Field: "MajorOSVersion", IsDesc: true}
-					_, err := MarshalOrderByClause([]Order{col1}, "dummy")
-					Expect(err).To(Equal(ErrInvalidSelectColumnOrderByClause))
+					col1 := soql.Order{Field: "MajorOSVersion", IsDesc: true}
+					_, err := soql.MarshalOrderByClause([]soql.Order{col1}, "dummy")
+					Expect(err).To(Equal(soql.ErrInvalidSelectColumnOrderByClause))
 				})
 			})
 		})
@@ -1075,7 +1243,7 @@ var _ = Describe("Marshaller", func() {
 			Context("when no relationship name is passed", func() {
 				Context("when no nested struct is passed", func() {
 					It("returns just the json tag names of fields concatenanted by comma", func() {
-						str, err := MarshalSelectClause(NonNestedStruct{}, "")
+						str, err := soql.MarshalSelectClause(NonNestedStruct{}, "")
 						Expect(err).ToNot(HaveOccurred())
 						Expect(str).To(Equal("Name,SomeValue__c"))
 					})
@@ -1083,7 +1251,7 @@ var _ = Describe("Marshaller", func() {
 
 				Context("when no fieldName parameter is specified in tag", func() {
 					It("returns propery resolved list of field names by using defaults", func() {
-						str, err := MarshalSelectClause(DefaultFieldNameStruct{}, "")
+						str, err := soql.MarshalSelectClause(DefaultFieldNameStruct{}, "")
 						Expect(err).ToNot(HaveOccurred())
 						Expect(str).To(Equal("DefaultName,Description__c"))
 					})
@@ -1091,7 +1259,7 @@ var _ = Describe("Marshaller", func() {
 
 				Context("when nested struct is passed", func() {
 					It("returns properly resolved list of field names", func() {
-						str, err := MarshalSelectClause(NestedStruct{}, "")
+						str, err := soql.MarshalSelectClause(NestedStruct{}, "")
 						Expect(err).ToNot(HaveOccurred())
 						Expect(str).To(Equal("Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c"))
 					})
@@ -1101,7 +1269,7 @@ var _ = Describe("Marshaller", func() {
 			Context("when relationship name is passed", func() {
 				Context("when no nested struct is passed", func() {
 					It("returns just the json tag names of fields concatenanted by comma and prefixed by relationship name", func() {
-						str, err := MarshalSelectClause(NonNestedStruct{}, "Role__r")
+						str, err := soql.MarshalSelectClause(NonNestedStruct{}, "Role__r")
 						Expect(err).ToNot(HaveOccurred())
 						Expect(str).To(Equal("Role__r.Name,Role__r.SomeValue__c"))
 					})
@@ -1115,8 +1283,8 @@ var _ = Describe("Marshaller", func() {
 				}
 
 				It("returns ErrInvalidTag error", func() {
-					str, err := MarshalSelectClause(InvalidStruct{}, "")
-					Expect(err).To(Equal(ErrInvalidTag))
+					str, err := soql.MarshalSelectClause(InvalidStruct{}, "")
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 					Expect(str).To(BeEmpty())
 				})
 			})
@@ -1128,8 +1296,8 @@ var _ = Describe("Marshaller", func() {
 				}
 
 				It("returns ErrInvalidTag error", func() {
-					str, err := MarshalSelectClause(MissingFieldName{}, "")
-					Expect(err).To(Equal(ErrInvalidTag))
+					str, err := soql.MarshalSelectClause(MissingFieldName{}, "")
+					Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 					Expect(str).To(BeEmpty())
 				})
 			})
@@ -1137,7 +1305,7 @@ var _ = Describe("Marshaller", func() {
 			Context("when struct has child relationship", func() {
 				Context("when child struct has select clause only", func() {
 					It("returns properly constructed select clause", func() {
-						str, err := MarshalSelectClause(ParentStruct{}, "")
+						str, err := soql.MarshalSelectClause(ParentStruct{}, "")
 						Expect(err).ToNot(HaveOccurred())
 						Expect(str).To(Equal("Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c,(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r)"))
 					})
@@ -1145,7 +1313,7 @@ var _ = Describe("Marshaller", func() {
 
 				Context("when child struct has select clause and where clause", func() {
 					It("returns properly constructed select clause", func() {
-						str, err := MarshalSelectClause(ParentStruct{
+						str, err := soql.MarshalSelectClause(ParentStruct{
 							ChildStruct: TestChildStruct{
 								WhereClause: ChildQueryCriteria{
 									Name: "sfdc-release",
@@ -1159,7 +1327,7 @@ var _ = Describe("Marshaller", func() {
 
 				Context("when selectChild tag does not have fieldName parameter", func() {
 					It("returns properly constructed select clause", func() {
-						str, err := MarshalSelectClause(DefaultFieldNameParentStruct{
+						str, err := soql.MarshalSelectClause(DefaultFieldNameParentStruct{
 							ChildStruct: TestChildStruct{
 								WhereClause: ChildQueryCriteria{
 									Name: "sfdc-release",
@@ -1173,22 +1341,69 @@ var _ = Describe("Marshaller", func() {
 
 				Context("when child struct does not have select clause", func() {
 					It("returns error", func() {
-						_, err := MarshalSelectClause(InvalidParentStruct{}, "")
-						Expect(err).To(Equal(ErrNoSelectClause))
+						_, err := soql.MarshalSelectClause(InvalidParentStruct{}, "")
+						Expect(err).To(matchMarshalError(soql.ErrNoSelectClause))
 					})
 				})
 
 				Context("when selectChild is used on non struct member", func() {
 					It("returns error", func() {
-						_, err := MarshalSelectClause(InvalidSelectChildClause{}, "")
-						Expect(err).To(Equal(ErrInvalidTag))
+						_, err := soql.MarshalSelectClause(InvalidSelectChildClause{}, "")
+						Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 					})
 				})
 
 				Context("when selectChild tag is applied to non struct member", func() {
 					It("returns error", func() {
-						_, err := MarshalSelectClause(ChildTagToNonStruct{}, "")
-						Expect(err).To(Equal(ErrInvalidTag))
+						_, err := soql.MarshalSelectClause(ChildTagToNonStruct{}, "")
+						Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+					})
+				})
+
+				Context("when aggregate columns are present", func() {
+					It("returns aggregate functions with aliases", func() {
+						str, err := soql.MarshalSelectClause(ownerCaseCount{}, "")
+						Expect(err).ToNot(HaveOccurred())
+						Expect(str).To(Equal("Owner.Name,Status,COUNT(Id) cnt,MAX(CreatedDate),COUNT_DISTINCT(AccountId),GROUPING(Owner.Name) grpOwner"))
+					})
+				})
+
+				Context("when selectTypeOf is used on polymorphic relationship", func() {
+					It("returns TYPEOF with the columns of each sObject and ELSE last", func() {
+						str, err := soql.MarshalSelectClause(task{}, "")
+						Expect(err).ToNot(HaveOccurred())
+						Expect(str).To(Equal("Id,TYPEOF What WHEN Account THEN Name,Industry WHEN Opportunity THEN Amount,Owner.Name ELSE Name END,Subject"))
+					})
+
+					It("returns the query with TYPEOF in select list", func() {
+						str, err := soql.Marshal(taskSoqlStruct{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(str).To(Equal("SELECT Id,TYPEOF What WHEN Account THEN Name,Industry WHEN Opportunity THEN Amount,Owner.Name ELSE Name END,Subject FROM Task"))
+					})
+
+					It("returns ErrInvalidTag for invalid branches", func() {
+						var marshalErr *soql.MarshalError
+						_, err := soql.MarshalSelectClause(typeOfWithoutWhen{}, "")
+						Expect(errors.As(err, &marshalErr)).To(BeTrue())
+						Expect(marshalErr.Err).To(Equal(soql.ErrInvalidTag))
+						Expect(marshalErr.Field).To(Equal("What"))
+						_, err = soql.MarshalSelectClause(typeOfWithTwoElses{}, "")
+						Expect(errors.As(err, &marshalErr)).To(BeTrue())
+						Expect(marshalErr.Field).To(Equal("What.Default"))
+						_, err = soql.MarshalSelectClause(typeOfWithChild{}, "")
+						Expect(errors.As(err, &marshalErr)).To(BeTrue())
+						Expect(marshalErr.Field).To(Equal("What.Account.Versions"))
+						Expect(marshalErr.Tag).To(Equal("selectChild,fieldName=Application_Versions__r"))
+						_, err = soql.MarshalSelectClause(typeOfWithoutColumns{}, "")
+						Expect(errors.As(err, &marshalErr)).To(BeTrue())
+						Expect(marshalErr.Field).To(Equal("What.Account"))
+						_, err = soql.MarshalSelectClause(typeOfWithColumn{}, "")
+						Expect(errors.As(err, &marshalErr)).To(BeTrue())
+						Expect(marshalErr.Field).To(Equal("What.Account"))
+						_, err = soql.MarshalSelectClause(typeOfOnColumn{}, "")
+						Expect(errors.As(err, &marshalErr)).To(BeTrue())
+						Expect(marshalErr.Field).To(Equal("What"))
+						Expect(marshalErr.Err).To(Equal(soql.ErrInvalidTag))
 					})
 				})
 			})
@@ -1198,15 +1413,15 @@ var _ = Describe("Marshaller", func() {
 			Context("when nil is passed", func() {
 				It("returns ErrNilValue error", func() {
 					var r *NestedStruct
-					str, err := MarshalSelectClause(r, "")
-					Expect(err).To(Equal(ErrNilValue))
+					str, err := soql.MarshalSelectClause(r, "")
+					Expect(err).To(matchMarshalError(soql.ErrNilValue))
 					Expect(str).To(BeEmpty())
 				})
 			})
 
 			Context("when nested struct is passed", func() {
 				It("returns properly resolved list of field names", func() {
-					str, err := MarshalSelectClause(&NestedStruct{}, "")
+					str, err := soql.MarshalSelectClause(&NestedStruct{}, "")
 					Expect(err).ToNot(HaveOccurred())
 					Expect(str).To(Equal("Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c"))
 				})
@@ -1223,7 +1438,7 @@ var _ = Describe("Marshaller", func() {
 		)
 
 		JustBeforeEach(func() {
-			actualQuery, err = Marshal(soqlStruct)
+			actualQuery, err = soql.Marshal(soqlStruct)
 		})
 
 		Context("when empty struct is passed as argument", func() {
@@ -1293,7 +1508,7 @@ var _ = Describe("Marshaller", func() {
 						LastRestart:                      currentTime,
 					},
 				}
-				expectedQuery = "SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c WHERE BIOS_Type__c = '98.7.654a' AND Num_of_CPU_Cores__c > 32 AND NUMA_Enabled__c = true AND Pvt_Test_Fail_Count__c <= 256 AND Physical_CPU_Count__c >= 4 AND CreatedDate = " + currentTime.Format(TestDateFormat) + " AND UpdatedDate = " + currentTime.Format(TestDateFormat) + " AND Disable_Alerts__c = false AND Allocation_Latency__c < 10.5 AND Major_OS_Version__c = '20' AND Number_Of_Successive_Puppet_Run_Failures__c = 0 AND Last_Restart__c > " + currentTime.Format(DateTimeFormat) + " AND ClosedDate > NEXT_N_DAYS:5"
+				expectedQuery = "SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c WHERE BIOS_Type__c = '98.7.654a' AND Num_of_CPU_Cores__c > 32 AND NUMA_Enabled__c = true AND Pvt_Test_Fail_Count__c <= 256 AND Physical_CPU_Count__c >= 4 AND CreatedDate = " + currentTime.Format(TestDateFormat) + " AND UpdatedDate = " + currentTime.Format(TestDateFormat) + " AND Disable_Alerts__c = false AND Allocation_Latency__c < 10.5 AND Major_OS_Version__c = '20' AND Number_Of_Successive_Puppet_Run_Failures__c = 0 AND Last_Restart__c > " + currentTime.Format(soql.DateTimeFormat) + " AND ClosedDate > NEXT_N_DAYS:5"
 			})
 
 			It("returns properly constructed soql query", func() {
@@ -1337,7 +1552,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrMultipleSelectClause))
+				Expect(err).To(matchMarshalError(soql.ErrMultipleSelectClause))
 			})
 		})
 
@@ -1347,7 +1562,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidTag))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 			})
 		})
 
@@ -1357,7 +1572,23 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrMultipleWhereClause))
+				Expect(err).To(matchMarshalError(soql.ErrMultipleWhereClause))
+			})
+		})
+
+		Context("when member of nested struct has invalid tag", func() {
+			BeforeEach(func() {
+				soqlStruct = invalidNestedSoqlStruct{}
+			})
+
+			It("returns MarshalError with path of the member", func() {
+				Expect(errors.Is(err, soql.ErrInvalidTag)).To(BeTrue())
+				var marshalErr *soql.MarshalError
+				Expect(errors.As(err, &marshalErr)).To(BeTrue())
+				Expect(marshalErr.Type).To(Equal(reflect.TypeOf(invalidNestedSoqlStruct{})))
+				Expect(marshalErr.Field).To(Equal("WhereClause.Position.Roles"))
+				Expect(marshalErr.Tag).To(Equal("inOperator,fieldName="))
+				Expect(err.Error()).To(Equal(`ErrInvalidTag: soql_test.invalidNestedSoqlStruct.WhereClause.Position.Roles with soql tag "inOperator,fieldName="`))
 			})
 		})
 
@@ -1373,7 +1604,8 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrNoSelectClause))
+				Expect(err).To(matchMarshalError(soql.ErrNoSelectClause))
+				Expect(err.Error()).To(Equal("ErrNoSelectClause: soql_test.OnlyWhereClause"))
 			})
 		})
 
@@ -1401,7 +1633,29 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns ErrNilValue error", func() {
-				Expect(err).To(Equal(ErrNilValue))
+				Expect(err).To(matchMarshalError(soql.ErrNilValue))
+			})
+		})
+
+		Context("when value implements Marshaler", func() {
+			BeforeEach(func() {
+				soqlStruct = &marshalerSoqlStruct{Name: "db"}
+			})
+
+			It("returns query of MarshalSOQL method", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal("SELECT Id FROM SM_Logical_Host__c WHERE Name__c = 'db'"))
+			})
+		})
+
+		Context("when nil pointer implementing Marshaler is passed", func() {
+			BeforeEach(func() {
+				var ptr *marshalerSoqlStruct
+				soqlStruct = ptr
+			})
+
+			It("returns ErrNilValue error", func() {
+				Expect(err).To(matchMarshalError(soql.ErrNilValue))
 			})
 		})
 
@@ -1411,7 +1665,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidTag))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 			})
 		})
 
@@ -1439,7 +1693,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrMultipleOrderByClause))
+				Expect(err).To(matchMarshalError(soql.ErrMultipleOrderByClause))
 			})
 		})
 
@@ -1449,16 +1703,16 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrNoSelectClause))
+				Expect(err).To(matchMarshalError(soql.ErrNoSelectClause))
 			})
 		})
 
 		Context("when a struct with mixed order by columns at top query level is passed", func() {
 			BeforeEach(func() {
-				soqlStruct = TestSoqlOrderByStruct{OrderByClause: []Order{
-					Order{Field: "ID", IsDesc: false},
-					Order{Field: "Name", IsDesc: true},
-					Order{Field: "NonNestedStruct.SomeValue", IsDesc: false},
+				soqlStruct = TestSoqlOrderByStruct{OrderByClause: []soql.Order{
+					soql.Order{Field: "ID", IsDesc: false},
+					soql.Order{Field: "Name", IsDesc: true},
+					soql.Order{Field: "NonNestedStruct.SomeValue", IsDesc: false},
 				}}
 				expectedQuery = "SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c ORDER BY Id ASC,Name__c DESC,NonNestedStruct__r.SomeValue__c ASC"
 			})
@@ -1471,11 +1725,11 @@ var _ = Describe("Marshaller", func() {
 
 		Context("when a struct with order by inside a child relation is passed", func() {
 			BeforeEach(func() {
-				col1 := Order{Field: "Version", IsDesc: true}
+				col1 := soql.Order{Field: "Version", IsDesc: true}
 				soqlStruct = TestSoqlChildRelationOrderByStruct{
 					SelectClause: OrderByParentStruct{
 						ChildStruct: TestChildWithOrderByStruct{
-							OrderByClause: []Order{col1},
+							OrderByClause: []soql.Order{col1},
 						},
 					},
 				}
@@ -1490,16 +1744,16 @@ var _ = Describe("Marshaller", func() {
 
 		Context("when a struct with order by clause in top level struct and child relation is passed", func() {
 			BeforeEach(func() {
-				col1 := Order{Field: "Version", IsDesc: true}
-				col2 := Order{Field: "ID", IsDesc: true}
-				col3 := Order{Field: "Name", IsDesc: false}
+				col1 := soql.Order{Field: "Version", IsDesc: true}
+				col2 := soql.Order{Field: "ID", IsDesc: true}
+				col3 := soql.Order{Field: "Name", IsDesc: false}
 				soqlStruct = TestSoqlChildRelationOrderByStruct{
 					SelectClause: OrderByParentStruct{
 						ChildStruct: TestChildWithOrderByStruct{
-							OrderByClause: []Order{col1},
+							OrderByClause: []soql.Order{col1},
 						},
 					},
-					OrderByClause: []Order{col2, col3},
+					OrderByClause: []soql.Order{col2, col3},
 				}
 				expectedQuery = "SELECT Id,Name__c,(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r ORDER BY SM_Application_Versions__c.Version__c DESC) FROM SM_Logical_Host__c ORDER BY Id DESC,Name__c ASC"
 			})
@@ -1581,7 +1835,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidLimitClause))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidLimitClause))
 			})
 		})
 
@@ -1599,7 +1853,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidLimitClause))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidLimitClause))
 			})
 		})
 
@@ -1618,7 +1872,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrMultipleLimitClause))
+				Expect(err).To(matchMarshalError(soql.ErrMultipleLimitClause))
 			})
 		})
 
@@ -1733,7 +1987,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidOffsetClause))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidOffsetClause))
 			})
 		})
 
@@ -1751,7 +2005,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidOffsetClause))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidOffsetClause))
 			})
 		})
 
@@ -1770,7 +2024,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrMultipleOffsetClause))
+				Expect(err).To(matchMarshalError(soql.ErrMultipleOffsetClause))
 			})
 		})
 
@@ -1796,6 +2050,214 @@ var _ = Describe("Marshaller", func() {
 			})
 		})
 
+		Context("when a struct with for clause is passed", func() {
+			BeforeEach(func() {
+				inputLimit := 15
+				inputOffset := 5
+				soqlStruct = TestSoqlLimitAndOffsetStruct{
+					SelectClause: NestedStruct{},
+					WhereClause:  TestQueryCriteria{Roles: []string{"db"}},
+					Limit:        &inputLimit,
+					Offset:       &inputOffset,
+					For:          "view",
+				}
+				expectedQuery = "SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c WHERE Role__r.Name IN ('db') LIMIT 15 OFFSET 5 FOR VIEW"
+			})
+
+			It("writes for clause at the end of the query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when for clause has unknown mode", func() {
+			BeforeEach(func() {
+				soqlStruct = TestSoqlLimitAndOffsetStruct{For: "SHARE"}
+			})
+
+			It("returns ErrInvalidForClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidForClause))
+			})
+		})
+
+		Context("when for update is passed", func() {
+			BeforeEach(func() {
+				soqlStruct = TestSoqlChildRelationOrderByStruct{OrderByClause: []soql.Order{}, ForClause: soql.ForUpdate}
+				expectedQuery = "SELECT Id,Name__c,(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r) FROM SM_Logical_Host__c FOR UPDATE"
+			})
+
+			It("returns properly constructed soql query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+
+			Context("when query has order by clause", func() {
+				BeforeEach(func() {
+					soqlStruct = TestSoqlChildRelationOrderByStruct{
+						OrderByClause: []soql.Order{{Field: "Name"}},
+						ForClause:     soql.ForUpdate,
+					}
+				})
+
+				It("returns ErrInvalidForClause", func() {
+					Expect(err).To(matchMarshalError(soql.ErrInvalidForClause))
+					Expect(err.(*soql.MarshalError).Field).To(Equal("ForClause"))
+				})
+			})
+		})
+
+		Context("when child relation has for clause", func() {
+			BeforeEach(func() {
+				soqlStruct = TestSoqlChildRelationOrderByStruct{
+					SelectClause: OrderByParentStruct{
+						ChildStruct: TestChildWithOrderByStruct{ForClause: soql.ForView},
+					},
+				}
+			})
+
+			It("returns ErrInvalidForClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidForClause))
+			})
+		})
+
+		Context("when for clause is not soql.ForMode", func() {
+			BeforeEach(func() {
+				mode := soql.ForView
+				soqlStruct = invalidForSoqlStruct{ForClause: &mode}
+			})
+
+			It("returns ErrInvalidForClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidForClause))
+			})
+		})
+
+		Context("when struct has multiple for clauses", func() {
+			BeforeEach(func() {
+				soqlStruct = multipleForSoqlStruct{}
+			})
+
+			It("returns ErrMultipleForClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrMultipleForClause))
+			})
+		})
+
+		Context("when a struct with using scope is passed", func() {
+			BeforeEach(func() {
+				inputLimit := 15
+				soqlStruct = TestSoqlLimitAndOffsetStruct{
+					SelectClause: NestedStruct{},
+					WhereClause:  TestQueryCriteria{Roles: []string{"db"}},
+					Limit:        &inputLimit,
+					Scope:        "MINE_AND_MY_GROUPS",
+				}
+				expectedQuery = "SELECT Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c FROM SM_Logical_Host__c USING SCOPE mine_and_my_groups WHERE Role__r.Name IN ('db') LIMIT 15"
+			})
+
+			It("writes using scope clause after the table name", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when using scope is unknown", func() {
+			BeforeEach(func() {
+				soqlStruct = TestSoqlLimitAndOffsetStruct{Scope: "yours"}
+			})
+
+			It("returns ErrInvalidUsingScope", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidUsingScope))
+				Expect(err.(*soql.MarshalError).Field).To(Equal("Scope"))
+			})
+		})
+
+		Context("when using scope is not soql.FilterScope", func() {
+			BeforeEach(func() {
+				soqlStruct = invalidScopeSoqlStruct{UsingScope: "mine"}
+			})
+
+			It("returns ErrInvalidUsingScope", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidUsingScope))
+			})
+		})
+
+		Context("when struct has multiple using scopes", func() {
+			BeforeEach(func() {
+				soqlStruct = multipleScopeSoqlStruct{}
+			})
+
+			It("returns ErrMultipleUsingScope", func() {
+				Expect(err).To(matchMarshalError(soql.ErrMultipleUsingScope))
+			})
+		})
+
+		Context("when select clause has fields=all", func() {
+			BeforeEach(func() {
+				limit := 200
+				soqlStruct = allFieldsSoqlStruct{LimitClause: &limit}
+				expectedQuery = "SELECT FIELDS(ALL),Id,Name FROM Account LIMIT 200"
+			})
+
+			It("selects FIELDS(ALL) before the columns", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+
+			Context("when limit clause is nil", func() {
+				BeforeEach(func() {
+					soqlStruct = allFieldsSoqlStruct{}
+				})
+
+				It("returns ErrInvalidFieldsLimit", func() {
+					Expect(err).To(matchMarshalError(soql.ErrInvalidFieldsLimit))
+					Expect(err.(*soql.MarshalError).Field).To(Equal("LimitClause"))
+				})
+			})
+
+			Context("when limit clause is greater than MaxFieldsLimit", func() {
+				BeforeEach(func() {
+					limit := soql.MaxFieldsLimit + 1
+					soqlStruct = allFieldsSoqlStruct{LimitClause: &limit}
+				})
+
+				It("returns ErrInvalidFieldsLimit", func() {
+					Expect(err).To(matchMarshalError(soql.ErrInvalidFieldsLimit))
+				})
+			})
+		})
+
+		Context("when select clause has fields=standard and no columns", func() {
+			BeforeEach(func() {
+				soqlStruct = standardFieldsSoqlStruct{}
+				expectedQuery = "SELECT FIELDS(STANDARD) FROM Account"
+			})
+
+			It("selects FIELDS(STANDARD) without limit clause", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when select clause has fields=custom and struct has no limit clause", func() {
+			BeforeEach(func() {
+				soqlStruct = customFieldsSoqlStruct{}
+			})
+
+			It("returns ErrInvalidFieldsLimit", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidFieldsLimit))
+				Expect(err.(*soql.MarshalError).Field).To(Equal("SelectClause"))
+			})
+		})
+
+		Context("when select clause has invalid fields parameter", func() {
+			BeforeEach(func() {
+				soqlStruct = invalidFieldsSoqlStruct{}
+			})
+
+			It("returns ErrInvalidTag", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+			})
+		})
+
 		Context("when a struct with where clause with joiner=OR passed", func() {
 			BeforeEach(func() {
 				soqlStruct = orSOQLQuery{
@@ -1858,7 +2320,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidTag))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 			})
 		})
 
@@ -1900,7 +2362,7 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns error", func() {
-				Expect(err).To(Equal(ErrInvalidTag))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
 			})
 		})
 
@@ -1960,6 +2422,87 @@ var _ = Describe("Marshaller", func() {
 				Expect(actualQuery).To(Equal(expectedQuery))
 			})
 		})
+		Context("when a struct with where clause with not group passed", func() {
+			BeforeEach(func() {
+				soqlStruct = soqlGroupTestStruct{
+					WhereClause: groupCriteria{
+						Type: "Client",
+						Excluded: &deptManagerCriteria{
+							Department: "Accounting",
+							Title:      []string{"Intern", "Temp"},
+						},
+					},
+				}
+				expectedQuery = "SELECT Name,Email,Phone FROM Contact WHERE Type = 'Client' AND (NOT (Department = 'Accounting' OR (Title LIKE '%Intern%' OR Title LIKE '%Temp%')))"
+			})
+
+			It("returns properly constructed soql query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when a struct with where clause with slice of groups passed", func() {
+			BeforeEach(func() {
+				soqlStruct = soqlGroupTestStruct{
+					WhereClause: groupCriteria{
+						Positions: []deptManagerCriteria{
+							{Department: "Accounting", Title: []string{"Manager"}},
+							{},
+							{Department: "Sales"},
+						},
+						NotPositions: []*deptManagerCriteria{nil, {Department: "Legal"}},
+					},
+				}
+				expectedQuery = "SELECT Name,Email,Phone FROM Contact WHERE ((Department = 'Accounting' AND Title LIKE '%Manager%') OR (Department = 'Sales')) AND (NOT ((Department = 'Legal')))"
+			})
+
+			It("returns properly constructed soql query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when a struct with where clause with groups without conditions passed", func() {
+			BeforeEach(func() {
+				soqlStruct = soqlGroupTestStruct{
+					WhereClause: groupCriteria{
+						Type:         "Client",
+						Excluded:     &deptManagerCriteria{},
+						Positions:    []deptManagerCriteria{{}, {}},
+						NotPositions: []*deptManagerCriteria{nil},
+					},
+				}
+				expectedQuery = "SELECT Name,Email,Phone FROM Contact WHERE Type = 'Client'"
+			})
+
+			It("leaves the groups out", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when a struct with where clause with invalid groups passed", func() {
+			It("returns ErrInvalidTag for slices of other types than structs", func() {
+				_, err = soql.MarshalWhereClause(stringsGroupCriteria{})
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+			})
+
+			It("returns ErrInvalidTag for semi-joins with not option or in slices", func() {
+				var marshalErr *soql.MarshalError
+				_, err = soql.MarshalWhereClause(invalidGroupCriteria{InFrauds: []soqlFraudStruct{}})
+				Expect(err).ToNot(HaveOccurred())
+				_, err = soql.MarshalWhereClause(invalidGroupCriteria{NotInFraud: &soqlFraudStruct{}})
+				Expect(errors.As(err, &marshalErr)).To(BeTrue())
+				Expect(marshalErr.Field).To(Equal("NotInFraud"))
+				Expect(marshalErr.Err).To(Equal(soql.ErrInvalidTag))
+				_, err = soql.MarshalWhereClause(invalidGroupCriteria{InFrauds: []soqlFraudStruct{{}}})
+				Expect(errors.As(err, &marshalErr)).To(BeTrue())
+				Expect(marshalErr.Field).To(Equal("InFrauds"))
+				Expect(marshalErr.Err).To(Equal(soql.ErrInvalidTag))
+			})
+		})
+
 		Context("when a struct with where clause with subquery and joiner=not in passed", func() {
 			BeforeEach(func() {
 				soqlStruct = soqlSubQueryInTestStruct{
@@ -2017,7 +2560,263 @@ var _ = Describe("Marshaller", func() {
 			})
 
 			It("returns ErrInvalidTag", func() {
-				Expect(err).To(Equal(ErrInvalidTag))
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+			})
+		})
+
+		Context("when structs are marshaled concurrently", func() {
+			It("returns the same queries as sequential calls", func() {
+				soqlStructs := []interface{}{
+					TestSoqlStruct{WhereClause: TestQueryCriteria{Roles: []string{"db"}}},
+					soqlSubQueryTestStruct{WhereClause: queryCriteria{Position: positionCriteria{Title: "Manager"}}},
+					aggregateSoqlStruct{GroupByClause: []string{"OwnerName"}},
+					multipleGroupBySoqlStruct{},
+				}
+				var expectedQueries []string
+				for _, soqlStruct := range soqlStructs {
+					query, _ := soql.Marshal(soqlStruct)
+					expectedQueries = append(expectedQueries, query)
+				}
+				var wg sync.WaitGroup
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wg.Done()
+						for indx, soqlStruct := range soqlStructs {
+							query, _ := soql.Marshal(soqlStruct)
+							Expect(query).To(Equal(expectedQueries[indx]))
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		})
+
+		Context("when a struct with aggregate columns, group by and having clause is passed", func() {
+			BeforeEach(func() {
+				isClosed := false
+				minCaseCount := 5
+				limit := 10
+				soqlStruct = aggregateSoqlStruct{
+					WhereClause:   aggregateFilter{IsClosed: &isClosed},
+					GroupByClause: []string{"OwnerName", "Status"},
+					HavingClause:  aggregateHaving{MinCaseCount: &minCaseCount},
+					OrderByClause: []soql.Order{{Field: "cnt", IsDesc: true}, {Field: "OwnerName"}},
+					LimitClause:   &limit,
+				}
+				expectedQuery = "SELECT Owner.Name,Status,COUNT(Id) cnt,MAX(CreatedDate),COUNT_DISTINCT(AccountId),GROUPING(Owner.Name) grpOwner FROM Case WHERE IsClosed = false GROUP BY Owner.Name,Status HAVING COUNT(Id) > 5 ORDER BY COUNT(Id) DESC,Owner.Name ASC LIMIT 10"
+			})
+
+			It("returns properly constructed soql query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when struct has with clause", func() {
+			BeforeEach(func() {
+				isClosed := false
+				soqlStruct = aggregateSoqlStruct{
+					WhereClause:   aggregateFilter{IsClosed: &isClosed},
+					WithClause:    "user_mode",
+					GroupByClause: []string{"Status"},
+					OrderByClause: []soql.Order{{Field: "Status"}},
+				}
+				expectedQuery = "SELECT Owner.Name,Status,COUNT(Id) cnt,MAX(CreatedDate),COUNT_DISTINCT(AccountId),GROUPING(Owner.Name) grpOwner FROM Case WHERE IsClosed = false WITH USER_MODE GROUP BY Status ORDER BY Status ASC"
+			})
+
+			It("writes security mode between where and group by clause", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when with clause has unknown security mode", func() {
+			BeforeEach(func() {
+				soqlStruct = aggregateSoqlStruct{WithClause: "SHARING"}
+			})
+
+			It("returns ErrInvalidWithClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidWithClause))
+			})
+		})
+
+		Context("when with clause is not soql.SecurityMode", func() {
+			BeforeEach(func() {
+				soqlStruct = invalidWithSoqlStruct{WithClause: "USER_MODE"}
+			})
+
+			It("returns ErrInvalidWithClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidWithClause))
+			})
+		})
+
+		Context("when struct has multiple with clauses", func() {
+			BeforeEach(func() {
+				soqlStruct = multipleWithSoqlStruct{}
+			})
+
+			It("returns ErrMultipleWithClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrMultipleWithClause))
+			})
+		})
+
+		Context("when order by clause references aggregate column by field name", func() {
+			BeforeEach(func() {
+				soqlStruct = aggregateSoqlStruct{
+					GroupByClause: []string{"OwnerName"},
+					OrderByClause: []soql.Order{{Field: "LastCreated"}},
+				}
+				expectedQuery = "SELECT Owner.Name,Status,COUNT(Id) cnt,MAX(CreatedDate),COUNT_DISTINCT(AccountId),GROUPING(Owner.Name) grpOwner FROM Case GROUP BY Owner.Name ORDER BY MAX(CreatedDate) ASC"
+			})
+
+			It("returns properly constructed soql query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when group by and having clause are empty", func() {
+			BeforeEach(func() {
+				soqlStruct = aggregateSoqlStruct{}
+				expectedQuery = "SELECT Owner.Name,Status,COUNT(Id) cnt,MAX(CreatedDate),COUNT_DISTINCT(AccountId),GROUPING(Owner.Name) grpOwner FROM Case"
+			})
+
+			It("omits group by and having clause", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when group by clause has type=rollup", func() {
+			BeforeEach(func() {
+				soqlStruct = rollupSoqlStruct{GroupByClause: []string{"OwnerName", "Status"}}
+				expectedQuery = "SELECT Owner.Name,Status,COUNT(Id) cnt,MAX(CreatedDate),COUNT_DISTINCT(AccountId),GROUPING(Owner.Name) grpOwner FROM Case GROUP BY ROLLUP(Owner.Name,Status)"
+			})
+
+			It("returns properly constructed soql query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when group by clause has type=cube", func() {
+			BeforeEach(func() {
+				soqlStruct = cubeSoqlStruct{GroupByClause: []string{"Status"}}
+				expectedQuery = "SELECT Owner.Name,Status,COUNT(Id) cnt,MAX(CreatedDate),COUNT_DISTINCT(AccountId),GROUPING(Owner.Name) grpOwner FROM Case GROUP BY CUBE(Status)"
+			})
+
+			It("returns properly constructed soql query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when child relationship has group by clause", func() {
+			BeforeEach(func() {
+				soqlStruct = accountCaseCountSoqlStruct{
+					SelectClause: accountCaseCount{
+						Cases: caseCountByStatus{
+							GroupByClause: []string{"Status"},
+							OrderByClause: []soql.Order{{Field: "Count", IsDesc: true}},
+						},
+					},
+				}
+				expectedQuery = "SELECT Name,(SELECT Case.Status,COUNT(Case.Id) FROM Cases GROUP BY Case.Status ORDER BY COUNT(Case.Id) DESC) FROM Account"
+			})
+
+			It("prefixes grouped columns with table name", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+		})
+
+		Context("when child relationship has with clause", func() {
+			BeforeEach(func() {
+				soqlStruct = accountCaseCountSoqlStruct{
+					SelectClause: accountCaseCount{
+						Cases: caseCountByStatus{WithClause: soql.SecurityEnforced, GroupByClause: []string{"Status"}},
+					},
+				}
+				expectedQuery = "SELECT Name,(SELECT Case.Status,COUNT(Case.Id) FROM Cases WITH SECURITY_ENFORCED GROUP BY Case.Status) FROM Account"
+			})
+
+			It("writes security mode in subquery", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actualQuery).To(Equal(expectedQuery))
+			})
+
+			Context("when security mode is not SECURITY_ENFORCED", func() {
+				BeforeEach(func() {
+					soqlStruct = accountCaseCountSoqlStruct{
+						SelectClause: accountCaseCount{Cases: caseCountByStatus{WithClause: soql.UserMode}},
+					}
+				})
+
+				It("returns ErrInvalidWithClause", func() {
+					Expect(err).To(matchMarshalError(soql.ErrInvalidWithClause))
+				})
+			})
+		})
+
+		Context("when group by clause references unknown field", func() {
+			BeforeEach(func() {
+				soqlStruct = aggregateSoqlStruct{GroupByClause: []string{"Owner"}}
+			})
+
+			It("returns ErrInvalidGroupByClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidGroupByClause))
+			})
+		})
+
+		Context("when group by clause references aggregate column", func() {
+			BeforeEach(func() {
+				soqlStruct = aggregateSoqlStruct{GroupByClause: []string{"CaseCount"}}
+			})
+
+			It("returns ErrInvalidGroupByClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidGroupByClause))
+			})
+		})
+
+		Context("when group by clause has invalid type", func() {
+			BeforeEach(func() {
+				soqlStruct = invalidGroupByTypeSoqlStruct{GroupByClause: []string{"Status"}}
+			})
+
+			It("returns ErrInvalidGroupByClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidGroupByClause))
+			})
+		})
+
+		Context("when group by clause is not a slice of strings", func() {
+			BeforeEach(func() {
+				soqlStruct = invalidGroupByValueSoqlStruct{GroupByClause: "Status"}
+			})
+
+			It("returns ErrInvalidGroupByClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidGroupByClause))
+			})
+		})
+
+		Context("when struct has multiple group by clauses", func() {
+			BeforeEach(func() {
+				soqlStruct = multipleGroupBySoqlStruct{}
+			})
+
+			It("returns ErrMultipleGroupByClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrMultipleGroupByClause))
+			})
+		})
+
+		Context("when struct has multiple having clauses", func() {
+			BeforeEach(func() {
+				soqlStruct = multipleHavingSoqlStruct{}
+			})
+
+			It("returns ErrMultipleHavingClause", func() {
+				Expect(err).To(matchMarshalError(soql.ErrMultipleHavingClause))
 			})
 		})
 	})
@@ -0,0 +1,706 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDateTime
+	tokenOperator
+	tokenOpenBrace
+	tokenCloseBrace
+	tokenComma
+	tokenColon
+)
+
+type token struct {
+	kind tokenKind
+	// text is the token as it appears in the query. For strings it is the text between the quotes.
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return fmt.Sprintf("'%s'", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// SyntaxError is returned by Parse when the query is not valid SOQL
+type SyntaxError struct {
+	// Offset is the byte offset in the query at which the error was detected
+	Offset int
+	// Line and Column are the 1-based position of Offset in the query
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+func newSyntaxError(query string, offset int, format string, args ...interface{}) *SyntaxError {
+	line := 1 + strings.Count(query[:offset], newLine)
+	column := offset + 1
+	if indx := strings.LastIndex(query[:offset], newLine); indx >= 0 {
+		column = offset - indx
+	}
+	return &SyntaxError{
+		Offset: offset,
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+var (
+	dateTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?)?`)
+	numberPattern   = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?`)
+)
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// tokenize splits query into tokens. The last token is always tokenEOF.
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for {
+		for pos < len(query) && strings.IndexByte(" \t\r\n", query[pos]) >= 0 {
+			pos++
+		}
+		if pos == len(query) {
+			tokens = append(tokens, token{kind: tokenEOF, pos: pos})
+			return tokens, nil
+		}
+		start := pos
+		c := query[pos]
+		switch {
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpenBrace, text: openBrace, pos: start})
+			pos++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenCloseBrace, text: closeBrace, pos: start})
+			pos++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: comma, pos: start})
+			pos++
+		case c == ':':
+			tokens = append(tokens, token{kind: tokenColon, text: ":", pos: start})
+			pos++
+		case c == '=':
+			tokens = append(tokens, token{kind: tokenOperator, text: "=", pos: start})
+			pos++
+		case c == '!' || c == '<' || c == '>':
+			pos++
+			if pos < len(query) && (query[pos] == '=' || (c == '<' && query[pos] == '>')) {
+				pos++
+			}
+			if query[start:pos] == "!" {
+				return nil, newSyntaxError(query, start, "unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: query[start:pos], pos: start})
+		case c == '\'':
+			pos++
+			for pos < len(query) && query[pos] != '\'' {
+				if query[pos] == '\\' {
+					pos++
+				}
+				pos++
+			}
+			if pos >= len(query) {
+				return nil, newSyntaxError(query, start, "unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokenString, text: query[start+1 : pos], pos: start})
+			pos++
+		case isIdentStart(c):
+			for pos < len(query) && (isIdentPart(query[pos]) || (query[pos] == '.' && pos+1 < len(query) && isIdentStart(query[pos+1]))) {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: query[start:pos], pos: start})
+		default:
+			if match := dateTimePattern.FindString(query[pos:]); match != "" {
+				tokens = append(tokens, token{kind: tokenDateTime, text: match, pos: start})
+				pos += len(match)
+				continue
+			}
+			if match := numberPattern.FindString(query[pos:]); match != "" {
+				tokens = append(tokens, token{kind: tokenNumber, text: match, pos: start})
+				pos += len(match)
+				continue
+			}
+			return nil, newSyntaxError(query, start, "unexpected character %q", c)
+		}
+	}
+}
+
+type parser struct {
+	query  string
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return newSyntaxError(p.query, t.pos, format, args...)
+}
+
+// isKeyword reports whether next token is the given keyword. Keywords are case insensitive.
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.errorf(p.peek(), "expected %s, found %s", keyword, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) expect(kind tokenKind, description string) (token, error) {
+	t := p.peek()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s, found %s", description, t)
+	}
+	return p.advance(), nil
+}
+
+func (p *parser) expectEOF() error {
+	if t := p.peek(); t.kind != tokenEOF {
+		return p.errorf(t, "unexpected %s", t)
+	}
+	return nil
+}
+
+// Parse parses SOQL query into its abstract syntax tree. It returns *SyntaxError if the query is not valid.
+// Queries generated by Marshal can always be parsed and the String method of the returned Query
+// reproduces them exactly.
+// query := "SELECT Id,Role__r.Name FROM SM_Logical_Host__c WHERE Host_Name__c LIKE '%-db%' LIMIT 5"
+// q, err := Parse(query)
+// if err != nil {
+//		log.Warn("Error in parsing soql")
+// }
+// fmt.Println(q.From.Name)
+// This will print SM_Logical_Host__c
+func Parse(query string) (*Query, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{query: query, tokens: tokens}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// ParseWhereClause parses the conditions of a where clause, like the ones returned by MarshalWhereClause,
+// into its abstract syntax tree. It returns *SyntaxError if the conditions are not valid.
+func ParseWhereClause(clause string) (Expr, error) {
+	tokens, err := tokenize(clause)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{query: clause, tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{Position: p.peek().pos}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		q.Fields = append(q.Fields, item)
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.advance()
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	from, err := p.parseFieldRef("object name")
+	if err != nil {
+		return nil, err
+	}
+	q.From = from
+	if p.acceptKeyword("USING") {
+		if err := p.expectKeyword("SCOPE"); err != nil {
+			return nil, err
+		}
+		scope, err := p.expect(tokenIdent, "filter scope")
+		if err != nil {
+			return nil, err
+		}
+		q.Scope = scope.text
+	}
+	if p.acceptKeyword("WHERE") {
+		if q.Where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("WITH") {
+		mode, err := p.expect(tokenIdent, "security mode")
+		if err != nil {
+			return nil, err
+		}
+		q.With = mode.text
+	}
+	if p.isKeyword("GROUP") {
+		if q.GroupBy, err = p.parseGroupBy(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("HAVING") {
+		if q.Having, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("ORDER") {
+		if q.OrderBy, err = p.parseOrderBy(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		if q.Limit, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("OFFSET") {
+		if q.Offset, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("FOR") {
+		mode, err := p.expect(tokenIdent, "VIEW, REFERENCE or UPDATE")
+		if err != nil {
+			return nil, err
+		}
+		q.For = mode.text
+	}
+	return q, nil
+}
+
+func (p *parser) parseSelectItem() (SelectItem, error) {
+	t := p.peek()
+	if t.kind == tokenOpenBrace {
+		p.advance()
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenCloseBrace, closeBrace); err != nil {
+			return nil, err
+		}
+		return &SubqueryItem{Position: t.pos, Query: q}, nil
+	}
+	if p.isKeyword("TYPEOF") && p.tokens[p.next+1].kind == tokenIdent {
+		return p.parseTypeOf()
+	}
+	field, err := p.parseFieldExpr()
+	if err != nil {
+		return nil, err
+	}
+	if function, ok := field.(*FunctionCall); ok {
+		if p.peek().kind == tokenIdent && !p.isKeyword("FROM") {
+			function.Alias = p.advance().text
+		}
+		return function, nil
+	}
+	return field.(*FieldRef), nil
+}
+
+// parseTypeOf parses TYPEOF expression like TYPEOF What WHEN Account THEN Name,Industry ELSE Name END
+func (p *parser) parseTypeOf() (*TypeOfItem, error) {
+	item := &TypeOfItem{Position: p.advance().pos}
+	field, err := p.parseFieldRef("relationship name")
+	if err != nil {
+		return nil, err
+	}
+	item.Field = field
+	for p.isKeyword("WHEN") {
+		when := &TypeOfWhen{Position: p.advance().pos}
+		t, err := p.expect(tokenIdent, "sObject name")
+		if err != nil {
+			return nil, err
+		}
+		when.Type = t.text
+		if err := p.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+		if when.Fields, err = p.parseFieldRefs(); err != nil {
+			return nil, err
+		}
+		item.Whens = append(item.Whens, when)
+	}
+	if len(item.Whens) == 0 {
+		return nil, p.errorf(p.peek(), "expected WHEN, found %s", p.peek())
+	}
+	if p.acceptKeyword("ELSE") {
+		if item.Else, err = p.parseFieldRefs(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("END"); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// parseFieldRefs parses field names separated by commas
+func (p *parser) parseFieldRefs() ([]*FieldRef, error) {
+	var fields []*FieldRef
+	for {
+		field, err := p.parseFieldRef("field name")
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		if p.peek().kind != tokenComma {
+			return fields, nil
+		}
+		p.advance()
+	}
+}
+
+// parseFieldExpr parses a field name or a function call like COUNT(Id)
+func (p *parser) parseFieldExpr() (FieldExpr, error) {
+	field, err := p.parseFieldRef("field name")
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenOpenBrace {
+		return field, nil
+	}
+	p.advance()
+	function := &FunctionCall{Position: field.Position, Name: field.Name}
+	for p.peek().kind != tokenCloseBrace {
+		if len(function.Args) > 0 {
+			if _, err := p.expect(tokenComma, comma); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseFieldExpr()
+		if err != nil {
+			return nil, err
+		}
+		function.Args = append(function.Args, arg)
+	}
+	p.advance()
+	return function, nil
+}
+
+func (p *parser) parseFieldRef(description string) (*FieldRef, error) {
+	t, err := p.expect(tokenIdent, description)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldRef{Position: t.pos, Name: t.text}, nil
+}
+
+func (p *parser) parseOrderBy() ([]*OrderItem, error) {
+	p.advance()
+	if err := p.expectKeyword("BY"); err != nil {
+		return nil, err
+	}
+	var items []*OrderItem
+	for {
+		field, err := p.parseFieldExpr()
+		if err != nil {
+			return nil, err
+		}
+		item := &OrderItem{Position: field.Pos(), Field: field}
+		if p.isKeyword("ASC") || p.isKeyword("DESC") {
+			item.Direction = strings.ToUpper(p.advance().text)
+		}
+		if p.acceptKeyword("NULLS") {
+			if !p.isKeyword("FIRST") && !p.isKeyword("LAST") {
+				return nil, p.errorf(p.peek(), "expected FIRST or LAST, found %s", p.peek())
+			}
+			item.Nulls = strings.ToUpper(p.advance().text)
+		}
+		items = append(items, item)
+		if p.peek().kind != tokenComma {
+			return items, nil
+		}
+		p.advance()
+	}
+}
+
+func (p *parser) parseGroupBy() (*GroupBy, error) {
+	p.advance()
+	if err := p.expectKeyword("BY"); err != nil {
+		return nil, err
+	}
+	group := &GroupBy{Position: p.peek().pos}
+	if (p.isKeyword("ROLLUP") || p.isKeyword("CUBE")) && p.tokens[p.next+1].kind == tokenOpenBrace {
+		group.Type = strings.ToUpper(p.advance().text)
+		p.advance()
+	}
+	for {
+		field, err := p.parseFieldExpr()
+		if err != nil {
+			return nil, err
+		}
+		group.Fields = append(group.Fields, field)
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.advance()
+	}
+	if group.Type != "" {
+		if _, err := p.expect(tokenCloseBrace, closeBrace); err != nil {
+			return nil, err
+		}
+	}
+	return group, nil
+}
+
+func (p *parser) parseInt() (*int, error) {
+	t, err := p.expect(tokenNumber, "number")
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(t.text)
+	if err != nil || n < 0 {
+		return nil, p.errorf(t, "expected non negative integer, found %s", t)
+	}
+	return &n, nil
+}
+
+// parseExpr parses conditions combined with AND or OR
+func (p *parser) parseExpr() (Expr, error) {
+	start := p.peek()
+	first, err := p.parseUnaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	logical := &LogicalExpr{Position: start.pos, Operands: []Expr{first}}
+	for p.isKeyword("AND") || p.isKeyword("OR") {
+		t := p.advance()
+		operator := strings.ToUpper(t.text)
+		if logical.Operator != "" && logical.Operator != operator {
+			return nil, p.errorf(t, "%s cannot be mixed with %s without parentheses", operator, logical.Operator)
+		}
+		logical.Operator = operator
+		operand, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		logical.Operands = append(logical.Operands, operand)
+	}
+	if len(logical.Operands) == 1 {
+		return first, nil
+	}
+	return logical, nil
+}
+
+func (p *parser) parseUnaryExpr() (Expr, error) {
+	t := p.peek()
+	if p.acceptKeyword("NOT") {
+		operand, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Position: t.pos, Operand: operand}, nil
+	}
+	if t.kind == tokenOpenBrace {
+		p.advance()
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenCloseBrace, closeBrace); err != nil {
+			return nil, err
+		}
+		return &ParenExpr{Position: t.pos, Inner: inner}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	field, err := p.parseFieldExpr()
+	if err != nil {
+		return nil, err
+	}
+	comparison := &ComparisonExpr{Position: field.Pos(), Field: field}
+	t := p.peek()
+	switch {
+	case t.kind == tokenOperator:
+		p.advance()
+		comparison.Operator = t.text
+		comparison.Value, err = p.parseValue()
+	case p.isKeyword("LIKE"):
+		p.advance()
+		comparison.Operator = "LIKE"
+		var pattern token
+		if pattern, err = p.expect(tokenString, "string"); err == nil {
+			comparison.Value = &StringLiteral{Position: pattern.pos, Raw: pattern.text}
+		}
+	case p.isKeyword("IN"):
+		p.advance()
+		comparison.Operator = "IN"
+		comparison.Value, err = p.parseListOrSubquery()
+	case p.isKeyword("INCLUDES") || p.isKeyword("EXCLUDES"):
+		comparison.Operator = strings.ToUpper(p.advance().text)
+		var open token
+		if open, err = p.expect(tokenOpenBrace, openBrace); err == nil {
+			comparison.Value, err = p.parseList(open)
+		}
+	case p.isKeyword("NOT"):
+		p.advance()
+		if err = p.expectKeyword("IN"); err == nil {
+			comparison.Operator = "NOT IN"
+			comparison.Value, err = p.parseListOrSubquery()
+		}
+	default:
+		err = p.errorf(t, "expected comparison operator, found %s", t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return comparison, nil
+}
+
+func (p *parser) parseListOrSubquery() (Value, error) {
+	open, err := p.expect(tokenOpenBrace, openBrace)
+	if err != nil {
+		return nil, err
+	}
+	if p.isKeyword("SELECT") {
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenCloseBrace, closeBrace); err != nil {
+			return nil, err
+		}
+		return &SubqueryValue{Position: open.pos, Query: q}, nil
+	}
+	return p.parseList(open)
+}
+
+// parseList parses values of a list up to the closing parenthesis. open is the opening parenthesis.
+func (p *parser) parseList(open token) (Value, error) {
+	list := &ListValue{Position: open.pos}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list.Values = append(list.Values, value)
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.advance()
+	}
+	if _, err := p.expect(tokenCloseBrace, closeBrace); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (p *parser) parseValue() (Value, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokenString:
+		return &StringLiteral{Position: t.pos, Raw: t.text}, nil
+	case tokenNumber:
+		return &NumberLiteral{Position: t.pos, Raw: t.text}, nil
+	case tokenDateTime:
+		return &DateTimeLiteral{Position: t.pos, Raw: t.text}, nil
+	case tokenIdent:
+		name := strings.ToUpper(t.text)
+		switch name {
+		case "TRUE", "FALSE":
+			return &BooleanLiteral{Position: t.pos, Value: name == "TRUE"}, nil
+		case "NULL":
+			return &NullLiteral{Position: t.pos}, nil
+		}
+		hasParameter, ok := dateLiterals[DateLiteral(name)]
+		if !ok {
+			return nil, p.errorf(t, "unknown date literal %s", t)
+		}
+		literal := &RelativeDateLiteral{Position: t.pos, Name: t.text}
+		if !hasParameter {
+			return literal, nil
+		}
+		if _, err := p.expect(tokenColon, ":"); err != nil {
+			return nil, err
+		}
+		n, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		literal.N = n
+		return literal, nil
+	default:
+		return nil, p.errorf(t, "expected value, found %s", t)
+	}
+}
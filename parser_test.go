@@ -0,0 +1,364 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var _ = Describe("Parser", func() {
+	Describe("Parse", func() {
+		var (
+			query  string
+			parsed *soql.Query
+			err    error
+		)
+
+		JustBeforeEach(func() {
+			parsed, err = soql.Parse(query)
+		})
+
+		Context("when query has all the clauses", func() {
+			BeforeEach(func() {
+				query = "SELECT Id,Role__r.Name,(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r WHERE SM_Application_Versions__c.Name__c = 'v1') FROM SM_Logical_Host__c WHERE (Host_Name__c LIKE '%-db%' OR Host_Name__c LIKE '%-dbmgmt%') AND (NOT Status__c IN ('DOWN','RETIRED')) ORDER BY Id DESC,Role__r.Name ASC NULLS LAST LIMIT 15 OFFSET 5"
+			})
+
+			It("returns the abstract syntax tree", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.From.Name).To(Equal("SM_Logical_Host__c"))
+				Expect(parsed.Fields).To(HaveLen(3))
+				Expect(parsed.Fields[1]).To(Equal(&soql.FieldRef{Position: 10, Name: "Role__r.Name"}))
+				child, ok := parsed.Fields[2].(*soql.SubqueryItem)
+				Expect(ok).To(BeTrue())
+				Expect(child.Query.From.Name).To(Equal("Application_Versions__r"))
+				Expect(child.Query.Where.String()).To(Equal("SM_Application_Versions__c.Name__c = 'v1'"))
+
+				where, ok := parsed.Where.(*soql.LogicalExpr)
+				Expect(ok).To(BeTrue())
+				Expect(where.Operator).To(Equal("AND"))
+				Expect(where.Operands).To(HaveLen(2))
+				like, ok := where.Operands[0].(*soql.ParenExpr).Inner.(*soql.LogicalExpr)
+				Expect(ok).To(BeTrue())
+				Expect(like.Operator).To(Equal("OR"))
+				Expect(like.Operands[0].(*soql.ComparisonExpr).Operator).To(Equal("LIKE"))
+				not, ok := where.Operands[1].(*soql.ParenExpr).Inner.(*soql.NotExpr)
+				Expect(ok).To(BeTrue())
+				in := not.Operand.(*soql.ComparisonExpr)
+				Expect(in.Operator).To(Equal("IN"))
+				Expect(in.Value.(*soql.ListValue).Values).To(HaveLen(2))
+
+				Expect(parsed.OrderBy).To(HaveLen(2))
+				Expect(parsed.OrderBy[0].Direction).To(Equal("DESC"))
+				Expect(parsed.OrderBy[1].Nulls).To(Equal("LAST"))
+				Expect(*parsed.Limit).To(Equal(15))
+				Expect(*parsed.Offset).To(Equal(5))
+			})
+
+			It("renders the same query", func() {
+				Expect(parsed.String()).To(Equal(query))
+			})
+		})
+
+		Context("when keywords are lower case", func() {
+			BeforeEach(func() {
+				query = "select Id from Account where Name != null order by Id limit 10"
+			})
+
+			It("parses the query", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.String()).To(Equal("SELECT Id FROM Account WHERE Name != null ORDER BY Id LIMIT 10"))
+			})
+		})
+
+		Context("when query has aggregate functions", func() {
+			BeforeEach(func() {
+				query = "SELECT Owner.Name,COUNT(Id) cnt,MAX(CreatedDate) FROM Case GROUP BY ROLLUP(Owner.Name) HAVING COUNT(Id) > 5 ORDER BY COUNT(Id) DESC"
+			})
+
+			It("returns the function calls", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.Fields[1]).To(Equal(&soql.FunctionCall{Position: 18, Name: "COUNT", Args: []soql.FieldExpr{&soql.FieldRef{Position: 24, Name: "Id"}}, Alias: "cnt"}))
+				Expect(parsed.Fields[2].(*soql.FunctionCall).Alias).To(BeEmpty())
+				Expect(parsed.GroupBy.Type).To(Equal("ROLLUP"))
+				Expect(parsed.GroupBy.Fields).To(Equal([]soql.FieldExpr{&soql.FieldRef{Position: 75, Name: "Owner.Name"}}))
+				having := parsed.Having.(*soql.ComparisonExpr)
+				Expect(having.Field.(*soql.FunctionCall).Name).To(Equal("COUNT"))
+				Expect(parsed.OrderBy[0].Field.String()).To(Equal("COUNT(Id)"))
+				Expect(parsed.String()).To(Equal(query))
+			})
+		})
+
+		Context("when query has literals of all types", func() {
+			BeforeEach(func() {
+				query = "SELECT Id FROM Account WHERE Name = 'O\\'Brien' AND NumberOfEmployees >= -4.5 AND IsDeleted = false AND CreatedDate > 2019-03-01T17:43:53.000-0700 AND CloseDate = 2019-03-01 AND LastModifiedDate < NEXT_N_DAYS:5 AND SystemModstamp = TODAY AND OwnerId NOT IN (SELECT Id FROM User)"
+			})
+
+			It("returns the values", func() {
+				Expect(err).ToNot(HaveOccurred())
+				operands := parsed.Where.(*soql.LogicalExpr).Operands
+				Expect(operands[0].(*soql.ComparisonExpr).Value.(*soql.StringLiteral).Value()).To(Equal("O'Brien"))
+				Expect(operands[1].(*soql.ComparisonExpr).Value).To(Equal(&soql.NumberLiteral{Position: 72, Raw: "-4.5"}))
+				Expect(operands[2].(*soql.ComparisonExpr).Value.(*soql.BooleanLiteral).Value).To(BeFalse())
+				createdDate, err := operands[3].(*soql.ComparisonExpr).Value.(*soql.DateTimeLiteral).Time()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(createdDate.Equal(time.Date(2019, 3, 2, 0, 43, 53, 0, time.UTC))).To(BeTrue())
+				closeDate, err := operands[4].(*soql.ComparisonExpr).Value.(*soql.DateTimeLiteral).Time()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(closeDate).To(Equal(time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)))
+				nextNDays := operands[5].(*soql.ComparisonExpr).Value.(*soql.RelativeDateLiteral)
+				Expect(nextNDays.Name).To(Equal("NEXT_N_DAYS"))
+				Expect(*nextNDays.N).To(Equal(5))
+				Expect(operands[6].(*soql.ComparisonExpr).Value.(*soql.RelativeDateLiteral).N).To(BeNil())
+				subquery := operands[7].(*soql.ComparisonExpr)
+				Expect(subquery.Operator).To(Equal("NOT IN"))
+				Expect(subquery.Value.(*soql.SubqueryValue).Query.From.Name).To(Equal("User"))
+				Expect(parsed.String()).To(Equal(query))
+			})
+		})
+
+		Context("when query has TYPEOF", func() {
+			BeforeEach(func() {
+				query = "SELECT Id,TYPEOF What WHEN Account THEN Name,Industry WHEN Opportunity THEN Amount ELSE Name END FROM Task"
+			})
+
+			It("returns the fields of each sObject", func() {
+				Expect(err).ToNot(HaveOccurred())
+				typeOf, ok := parsed.Fields[1].(*soql.TypeOfItem)
+				Expect(ok).To(BeTrue())
+				Expect(typeOf.Position).To(Equal(10))
+				Expect(typeOf.Field).To(Equal(&soql.FieldRef{Position: 17, Name: "What"}))
+				Expect(typeOf.Whens).To(HaveLen(2))
+				Expect(typeOf.Whens[0].Position).To(Equal(22))
+				Expect(typeOf.Whens[0].Type).To(Equal("Account"))
+				Expect(typeOf.Whens[0].Fields).To(HaveLen(2))
+				Expect(typeOf.Whens[1].String()).To(Equal("WHEN Opportunity THEN Amount"))
+				Expect(typeOf.Else).To(HaveLen(1))
+				Expect(parsed.String()).To(Equal(query))
+			})
+		})
+
+		Context("when query has with clause", func() {
+			BeforeEach(func() {
+				query = "SELECT Id FROM Account WHERE Name = 'a' WITH SECURITY_ENFORCED ORDER BY Name ASC"
+			})
+
+			It("returns the security mode", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.With).To(Equal("SECURITY_ENFORCED"))
+				Expect(parsed.String()).To(Equal(query))
+			})
+		})
+
+		Context("when query has using scope clause", func() {
+			BeforeEach(func() {
+				query = "SELECT Id FROM Account USING SCOPE mine WHERE Name = 'a'"
+			})
+
+			It("returns the scope", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.Scope).To(Equal("mine"))
+				Expect(parsed.String()).To(Equal(query))
+			})
+		})
+
+		Context("when query has for clause", func() {
+			BeforeEach(func() {
+				query = "SELECT Id FROM Account LIMIT 10 FOR UPDATE"
+			})
+
+			It("returns the mode", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.For).To(Equal("UPDATE"))
+				Expect(parsed.String()).To(Equal(query))
+			})
+		})
+
+		Context("when query is not valid", func() {
+			It("returns positioned syntax error", func() {
+				invalidQueries := map[string]soql.SyntaxError{
+					"":                                       {Offset: 0, Line: 1, Column: 1, Msg: "expected SELECT, found end of query"},
+					"SELECT Id Account":                      {Offset: 10, Line: 1, Column: 11, Msg: `expected FROM, found "Account"`},
+					"SELECT Id,\nFROM Account":               {Offset: 16, Line: 2, Column: 6, Msg: `expected FROM, found "Account"`},
+					"SELECT Id FROM Account WHERE Name":      {Offset: 33, Line: 1, Column: 34, Msg: "expected comparison operator, found end of query"},
+					"SELECT Id FROM Account WHERE Name = 'a": {Offset: 36, Line: 1, Column: 37, Msg: "unterminated string"},
+					"SELECT Id FROM Account WHERE A = 1 AND B = 2 OR C = 3": {Offset: 45, Line: 1, Column: 46, Msg: "OR cannot be mixed with AND without parentheses"},
+					"SELECT Id FROM Account WHERE A = SOMEDAY":              {Offset: 33, Line: 1, Column: 34, Msg: `unknown date literal "SOMEDAY"`},
+					"SELECT Id FROM Account WHERE A = NEXT_N_DAYS":          {Offset: 44, Line: 1, Column: 45, Msg: "expected :, found end of query"},
+					"SELECT Id FROM Account WHERE A LIKE 5":                 {Offset: 36, Line: 1, Column: 37, Msg: `expected string, found "5"`},
+					"SELECT Id FROM Account WHERE (A = 1":                   {Offset: 35, Line: 1, Column: 36, Msg: "expected ), found end of query"},
+					"SELECT Id FROM Account LIMIT -1":                       {Offset: 29, Line: 1, Column: 30, Msg: `expected non negative integer, found "-1"`},
+					"SELECT Id FROM Account ORDER Id":                       {Offset: 29, Line: 1, Column: 30, Msg: `expected BY, found "Id"`},
+					"SELECT Id FROM Account WHERE A ! 1":                    {Offset: 31, Line: 1, Column: 32, Msg: `unexpected character '!'`},
+					"SELECT Id FROM Account GROUP":                          {Offset: 28, Line: 1, Column: 29, Msg: "expected BY, found end of query"},
+					"SELECT TYPEOF What ELSE Name END FROM Task":            {Offset: 19, Line: 1, Column: 20, Msg: `expected WHEN, found "ELSE"`},
+					"SELECT TYPEOF What WHEN Account THEN Name FROM Task":   {Offset: 42, Line: 1, Column: 43, Msg: `expected END, found "FROM"`},
+					"SELECT Id FROM Account WITH":                           {Offset: 27, Line: 1, Column: 28, Msg: "expected security mode, found end of query"},
+					"SELECT Id FROM Account FOR 'UPDATE'":                   {Offset: 27, Line: 1, Column: 28, Msg: "expected VIEW, REFERENCE or UPDATE, found 'UPDATE'"},
+					"SELECT Id FROM Account USING mine":                     {Offset: 29, Line: 1, Column: 30, Msg: `expected SCOPE, found "mine"`},
+				}
+				for invalidQuery, expectedErr := range invalidQueries {
+					_, err := soql.Parse(invalidQuery)
+					Expect(err).To(HaveOccurred(), invalidQuery)
+					syntaxErr, ok := err.(*soql.SyntaxError)
+					Expect(ok).To(BeTrue(), invalidQuery)
+					Expect(*syntaxErr).To(Equal(expectedErr), invalidQuery)
+				}
+			})
+		})
+
+		Context("when query is generated by Marshal", func() {
+			It("round trips through the parser", func() {
+				currentTime := time.Now()
+				limit := 15
+				offset := 5
+				allowNull := false
+				memory := 1e+21
+				soqlStructs := []interface{}{
+					TestSoqlStruct{
+						WhereClause: TestQueryCriteria{
+							IncludeNamePattern:          []string{"-db", "-dbmgmt"},
+							Roles:                       []string{"db", "db'mgmt"},
+							ExcludeNamePattern:          []string{"-core", "-drp"},
+							AssetType:                   "SER\\VER\n",
+							Status:                      "In_Active%",
+							AllowNullLastDiscoveredDate: &allowNull,
+							ExcludeIDs:                  []string{"123", "456"},
+						},
+					},
+					TestSoqlMixedDataAndOperatorStruct{
+						WhereClause: QueryCriteriaWithMixedDataTypesAndOperators{
+							BIOSType:          "98.7.654a",
+							NumOfCPUCores:     -32,
+							NUMAEnabled:       true,
+							PvtTestFailCount:  256,
+							CreatedDate:       currentTime,
+							UpdatedDate:       &currentTime,
+							AllocationLatency: 10.5,
+							LastRestart:       currentTime,
+							Memory:            &memory,
+							ClosedDate:        5,
+						},
+					},
+					TestSoqlLimitAndOffsetStruct{
+						WhereClause: TestQueryCriteria{
+							IncludeNamePattern: []string{"-db"},
+						},
+						Limit:  &limit,
+						Offset: &offset,
+					},
+					TestSoqlChildRelationOrderByStruct{
+						SelectClause: OrderByParentStruct{
+							ChildStruct: TestChildWithOrderByStruct{
+								OrderByClause: []soql.Order{{Field: "Version", IsDesc: true}},
+							},
+						},
+						OrderByClause: []soql.Order{{Field: "ID", IsDesc: true}, {Field: "Name"}},
+					},
+					TestSoqlStruct{
+						SelectClause: NestedStruct{},
+					},
+					soqlSubQueryTestStruct{
+						WhereClause: queryCriteria{
+							Position: positionCriteria{
+								Title: "Purchasing Manager",
+								DepartmentManager: deptManagerCriteria{
+									Department: "Accounting",
+									Title:      []string{"Manager"},
+								},
+							},
+						},
+					},
+					soqlSubQueryInTestStruct{
+						WhereClause: inSubqueryCriteria{
+							Type:            "Client",
+							NotInFraudTable: &soqlFraudStruct{WhereClause: fraudCriteria{IsFraud: true}},
+							InFraudTable:    &soqlFraudStruct{},
+						},
+					},
+					orSOQLQuery{
+						WhereClause: positionOrDeptCriteria{Title: "Manager", Department: "Accounting"},
+					},
+					aggregateSoqlStruct{
+						WhereClause:   aggregateFilter{IsClosed: &allowNull},
+						WithClause:    soql.SystemMode,
+						GroupByClause: []string{"OwnerName", "Status"},
+						HavingClause:  aggregateHaving{MinCaseCount: &limit, MaxCaseCount: &limit},
+						OrderByClause: []soql.Order{{Field: "cnt", IsDesc: true}},
+						LimitClause:   &limit,
+					},
+					cubeSoqlStruct{GroupByClause: []string{"OwnerName", "Status"}},
+					taskSoqlStruct{},
+					accountCaseCountSoqlStruct{
+						SelectClause: accountCaseCount{Cases: caseCountByStatus{WithClause: soql.SecurityEnforced}},
+					},
+					TestSoqlLimitAndOffsetStruct{Limit: &limit, Offset: &offset, For: soql.ForReference},
+					TestSoqlLimitAndOffsetStruct{WhereClause: TestQueryCriteria{Roles: []string{"db"}}, Scope: soql.ScopeScopingRule},
+					allFieldsSoqlStruct{LimitClause: &limit},
+					standardFieldsSoqlStruct{},
+				}
+				for _, soqlStruct := range soqlStructs {
+					soqlQuery, err := soql.Marshal(soqlStruct)
+					Expect(err).ToNot(HaveOccurred())
+					parsed, err := soql.Parse(soqlQuery)
+					Expect(err).ToNot(HaveOccurred(), soqlQuery)
+					Expect(parsed.String()).To(Equal(soqlQuery))
+				}
+			})
+		})
+	})
+
+	Describe("ParseWhereClause", func() {
+		Context("when clause is generated by MarshalWhereClause", func() {
+			It("round trips through the parser", func() {
+				nextNDays := 20
+				criteria := []interface{}{
+					TestQueryCriteria{
+						IncludeNamePattern: []string{"-db", "-dbmgmt"},
+						ExcludeNamePattern: []string{"-core"},
+					},
+					QueryCriteriaDateLiteralsOperatorsPtr{
+						CreatedDate:   &nextNDays,
+						ScheduledDate: &nextNDays,
+					},
+					QueryCriteriaWithIntegerTypes{
+						NumOfCPUCores:    16,
+						PvtTestFailCount: 9223372036854775807,
+					},
+					QueryCriteriaDateLiteralValues{
+						CreatedDate:  soql.NFiscalYearsAgo.N(3),
+						CloseDate:    []soql.DateLiteral{soql.Today, soql.LastNMonths.N(6)},
+						ResolvedDate: soql.ThisWeek,
+					},
+					QueryCriteriaMultiSelectPicklist{
+						IncludesAny: []string{"db", "app's"},
+						IncludesAll: [][]string{{"db", "app"}},
+						ExcludesAny: []string{"core"},
+					},
+				}
+				for _, c := range criteria {
+					clause, err := soql.MarshalWhereClause(c)
+					Expect(err).ToNot(HaveOccurred())
+					expr, err := soql.ParseWhereClause(clause)
+					Expect(err).ToNot(HaveOccurred(), clause)
+					Expect(expr.String()).To(Equal(clause))
+				}
+			})
+		})
+
+		Context("when clause is not valid", func() {
+			It("returns syntax error", func() {
+				_, err := soql.ParseWhereClause("Name = 'a' LIMIT 5")
+				Expect(err).To(Equal(&soql.SyntaxError{Offset: 11, Line: 1, Column: 12, Msg: `unexpected "LIMIT"`}))
+			})
+		})
+	})
+})
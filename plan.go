@@ -0,0 +1,320 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Marshaling the same struct type over and over again parses the same soql tags every time. Instead, the
+// tags of every struct type are compiled once into a plan which is cached and reused by all subsequent
+// calls. Plans only capture what can be derived from the type. Errors found while compiling are stored in
+// the plan at the position of the offending member, so that they are returned in the same order as if the
+// tags were parsed while walking the value.
+
+// queryClause is the compiled soql tag of a member of the struct passed to Marshal
+type queryClause struct {
+	index     int
+	clauseKey string
+	// tableName is set for selectClause
+	tableName string
+	// joiner is set for whereClause and havingClause
+	joiner string
+	// groupByType is the value of type parameter of groupByClause
+	groupByType string
+	// fields is the value of fields parameter of selectClause in upper case
+	fields string
+	err    error
+}
+
+type queryPlan struct {
+	soqlTagPresent bool
+	clauses        []queryClause
+	// indexes maps the clause keys to the index of the member tagged with them
+	indexes map[string]int
+}
+
+// selectField is the compiled soql tag of a member of the struct tagged with selectClause
+type selectField struct {
+	index     int
+	clauseKey string
+	fieldName string
+	alias     string
+	// isNested is set for selectColumn used on structs, which are parent relationships
+	isNested  bool
+	fieldType reflect.Type
+	err       error
+}
+
+// typeOfPlan is the compiled struct tagged with selectTypeOf
+type typeOfPlan struct {
+	branches []typeOfBranch
+	// err is returned after the branches when the struct has no selectWhen member
+	err error
+}
+
+// typeOfBranch is the compiled soql tag of a member of the struct tagged with selectTypeOf
+type typeOfBranch struct {
+	index     int
+	clauseKey string
+	// sObject is the value of tableName parameter of selectWhen or the name of the member if it is not set
+	sObject string
+	// structType is the type of the member or the type it points to
+	structType reflect.Type
+	err        error
+}
+
+// whereField is the compiled soql tag of a member of the struct tagged with whereClause
+type whereField struct {
+	index     int
+	clauseKey string
+	// fieldName is the value of fieldName parameter or the name of the member if parameter is not set
+	fieldName string
+	// joinFieldName is the value of fieldName parameter used by subqueries with joiner=in and joiner=not in
+	joinFieldName string
+	joiner        string
+	// not is set for subqueries with not option, which negates the group
+	not bool
+	// isSlice is set for subqueries on slices of structs, every struct of which is a group ORed with the others
+	isSlice bool
+	builder func(v interface{}, fieldName string, tags map[string]string) (string, error)
+	tags    map[string]string
+	// err is returned before the value of the member is looked at
+	err error
+	// joinErr is returned only for subqueries that are neither nil nor empty slices
+	joinErr error
+}
+
+var (
+	queryPlans     sync.Map // map[reflect.Type]*queryPlan
+	selectPlans    sync.Map // map[reflect.Type][]selectField
+	wherePlans     sync.Map // map[reflect.Type][]whereField
+	typeOfPlans    sync.Map // map[reflect.Type]*typeOfPlan
+	columnMappings sync.Map // map[reflect.Type]map[string]string
+)
+
+func getQueryPlan(reflectedType reflect.Type) *queryPlan {
+	if plan, ok := queryPlans.Load(reflectedType); ok {
+		return plan.(*queryPlan)
+	}
+	plan, _ := queryPlans.LoadOrStore(reflectedType, compileQueryPlan(reflectedType))
+	return plan.(*queryPlan)
+}
+
+func compileQueryPlan(reflectedType reflect.Type) *queryPlan {
+	plan := &queryPlan{indexes: make(map[string]int)}
+	multipleClauseErrors := map[string]error{
+		SelectClause:  ErrMultipleSelectClause,
+		WhereClause:   ErrMultipleWhereClause,
+		OrderByClause: ErrMultipleOrderByClause,
+		LimitClause:   ErrMultipleLimitClause,
+		OffsetClause:  ErrMultipleOffsetClause,
+		GroupByClause: ErrMultipleGroupByClause,
+		HavingClause:  ErrMultipleHavingClause,
+		WithClause:    ErrMultipleWithClause,
+		ForClause:     ErrMultipleForClause,
+		UsingScope:    ErrMultipleUsingScope,
+	}
+	for i := 0; i < reflectedType.NumField(); i++ {
+		field := reflectedType.Field(i)
+		clauseTag := field.Tag.Get(SoqlTag)
+		if clauseTag == "" {
+			continue
+		}
+		plan.soqlTagPresent = true
+		clause := queryClause{index: i, clauseKey: getClauseKey(clauseTag)}
+		multipleClauseErr, ok := multipleClauseErrors[clause.clauseKey]
+		switch {
+		case !ok:
+			clause.err = ErrInvalidTag
+		default:
+			if _, present := plan.indexes[clause.clauseKey]; present {
+				clause.err = multipleClauseErr
+			} else {
+				plan.indexes[clause.clauseKey] = i
+			}
+		}
+		switch clause.clauseKey {
+		case SelectClause:
+			clause.tableName = getTableName(clauseTag, field.Name)
+			fields, err := getFields(clauseTag)
+			if clause.err == nil {
+				clause.fields, clause.err = fields, err
+			}
+		case WhereClause, HavingClause:
+			joiner, err := getJoiner(clauseTag)
+			if clause.err == nil {
+				clause.joiner, clause.err = joiner, err
+			}
+		case GroupByClause:
+			clause.groupByType = getTagValue(clauseTag, GroupByType, "")
+		}
+		plan.clauses = append(plan.clauses, clause)
+		if clause.err != nil {
+			// Members after the first error are never looked at
+			break
+		}
+	}
+	return plan
+}
+
+func getSelectPlan(reflectedType reflect.Type) []selectField {
+	if plan, ok := selectPlans.Load(reflectedType); ok {
+		return plan.([]selectField)
+	}
+	plan, _ := selectPlans.LoadOrStore(reflectedType, compileSelectPlan(reflectedType))
+	return plan.([]selectField)
+}
+
+func compileSelectPlan(reflectedType reflect.Type) []selectField {
+	var plan []selectField
+	for i := 0; i < reflectedType.NumField(); i++ {
+		field := reflectedType.Field(i)
+		clauseTag := field.Tag.Get(SoqlTag)
+		if clauseTag == "" {
+			continue
+		}
+		f := selectField{
+			index:     i,
+			clauseKey: getClauseKey(clauseTag),
+			fieldName: getFieldName(clauseTag, field.Name),
+			alias:     getTagValue(clauseTag, Alias, ""),
+			isNested:  field.Type.Kind() == reflect.Struct && field.Type != timeType,
+			fieldType: field.Type,
+		}
+		_, isAggregate := aggregateFunctions[f.clauseKey]
+		if (f.clauseKey != SelectColumn && f.clauseKey != SelectChild && f.clauseKey != SelectTypeOf && !isAggregate) ||
+			f.fieldName == "" {
+			f.err = ErrInvalidTag
+		}
+		if f.clauseKey == SelectTypeOf {
+			// TYPEOF is not a parent relationship, its members are the branches
+			f.isNested = false
+			if field.Type.Kind() != reflect.Struct {
+				f.err = ErrInvalidTag
+			}
+		}
+		plan = append(plan, f)
+	}
+	return plan
+}
+
+func getWherePlan(reflectedType reflect.Type) []whereField {
+	if plan, ok := wherePlans.Load(reflectedType); ok {
+		return plan.([]whereField)
+	}
+	plan, _ := wherePlans.LoadOrStore(reflectedType, compileWherePlan(reflectedType))
+	return plan.([]whereField)
+}
+
+func compileWherePlan(reflectedType reflect.Type) []whereField {
+	var plan []whereField
+	for i := 0; i < reflectedType.NumField(); i++ {
+		field := reflectedType.Field(i)
+		clauseTag := field.Tag.Get(SoqlTag)
+		if clauseTag == "" {
+			continue
+		}
+		f := whereField{
+			index:     i,
+			clauseKey: getClauseKey(clauseTag),
+			fieldName: getFieldName(clauseTag, field.Name),
+		}
+		if f.clauseKey == Subquery {
+			switch field.Type.Kind() {
+			case reflect.Struct, reflect.Ptr:
+			case reflect.Slice:
+				f.isSlice = true
+				elem := field.Type.Elem()
+				if elem.Kind() == reflect.Ptr {
+					elem = elem.Elem()
+				}
+				if elem.Kind() != reflect.Struct {
+					f.err = ErrInvalidTag
+				}
+			default:
+				f.err = ErrInvalidTag
+			}
+			f.not = hasTagOption(clauseTag, NotOption)
+			f.joiner, f.joinErr = getJoiner(clauseTag)
+			if f.joiner == inOperator || f.joiner == notInOperator {
+				f.joinFieldName = getFieldName(clauseTag, "")
+				// Semi-joins are negated with joiner=not in and can not be listed in slices
+				if f.joinFieldName == "" || f.not || f.isSlice {
+					f.joinErr = ErrInvalidTag
+				}
+			}
+		} else {
+			var ok bool
+			f.builder, ok = clauseBuilderMap[f.clauseKey]
+			if f.fieldName == "" || !ok {
+				f.err = ErrInvalidTag
+			}
+			f.tags = getTagParameterMap(clauseTag)
+		}
+		plan = append(plan, f)
+	}
+	return plan
+}
+
+func getTypeOfPlan(reflectedType reflect.Type) *typeOfPlan {
+	if plan, ok := typeOfPlans.Load(reflectedType); ok {
+		return plan.(*typeOfPlan)
+	}
+	plan, _ := typeOfPlans.LoadOrStore(reflectedType, compileTypeOfPlan(reflectedType))
+	return plan.(*typeOfPlan)
+}
+
+func compileTypeOfPlan(reflectedType reflect.Type) *typeOfPlan {
+	plan := &typeOfPlan{err: ErrInvalidTag}
+	hasElse := false
+	for i := 0; i < reflectedType.NumField(); i++ {
+		field := reflectedType.Field(i)
+		clauseTag := field.Tag.Get(SoqlTag)
+		if clauseTag == "" {
+			continue
+		}
+		branch := typeOfBranch{index: i, clauseKey: getClauseKey(clauseTag), structType: field.Type}
+		if branch.structType.Kind() == reflect.Ptr {
+			branch.structType = branch.structType.Elem()
+		}
+		switch branch.clauseKey {
+		case SelectWhen:
+			branch.sObject = getTableName(clauseTag, field.Name)
+			if branch.sObject == "" {
+				branch.err = ErrInvalidTag
+			}
+			plan.err = nil
+		case SelectElse:
+			if hasElse {
+				branch.err = ErrInvalidTag
+			}
+			hasElse = true
+		default:
+			branch.err = ErrInvalidTag
+		}
+		if branch.structType.Kind() != reflect.Struct || branch.structType == timeType {
+			branch.err = ErrInvalidTag
+		}
+		plan.branches = append(plan.branches, branch)
+	}
+	return plan
+}
+
+// getColumnMappings returns the mappings of names of members of the struct with selectColumn tags to the names
+// of columns as created by mapSelectColumns. The returned map should not be modified.
+func getColumnMappings(reflectedType reflect.Type) map[string]string {
+	if mappings, ok := columnMappings.Load(reflectedType); ok {
+		return mappings.(map[string]string)
+	}
+	mappings := make(map[string]string)
+	// mapSelectColumns only fails for nil pointers, which a zero struct does not have
+	_ = mapSelectColumns(mappings, "", "", reflect.Zero(reflectedType).Interface())
+	stored, _ := columnMappings.LoadOrStore(reflectedType, mappings)
+	return stored.(map[string]string)
+}
@@ -0,0 +1,428 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrUnknownSObject error is returned by Validate when tableName of selectClause is not described in Schema
+	// or is not the sObject of the child relationship, and when tableName of selectWhen is not an sObject the
+	// polymorphic relationship of selectTypeOf refers to
+	ErrUnknownSObject = errors.New("ErrUnknownSObject")
+	// ErrUnknownField error is returned by Validate when the sObject has no field with the name
+	ErrUnknownField = errors.New("ErrUnknownField")
+	// ErrUnknownRelationship error is returned by Validate when the sObject has no parent relationship used in
+	// the field path, no child relationship used with selectChild or no polymorphic relationship used with
+	// selectTypeOf
+	ErrUnknownRelationship = errors.New("ErrUnknownRelationship")
+	// ErrNotFilterable error is returned by Validate when the field used in whereClause cannot be filtered on
+	ErrNotFilterable = errors.New("ErrNotFilterable")
+	// ErrNotSortable error is returned by Validate when the field used in orderByClause cannot be sorted on
+	ErrNotSortable = errors.New("ErrNotSortable")
+)
+
+// SObjectDescribe is the part of the response of sobjects/<Name>/describe resource of Salesforce REST API used
+// by Schema
+type SObjectDescribe struct {
+	Name               string                      `json:"name"`
+	Fields             []FieldDescribe             `json:"fields"`
+	ChildRelationships []ChildRelationshipDescribe `json:"childRelationships"`
+}
+
+// FieldDescribe is the describe metadata of a field of sObject
+type FieldDescribe struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Filterable bool   `json:"filterable"`
+	Sortable   bool   `json:"sortable"`
+	// RelationshipName is the name of parent relationship of lookup fields, e.g. Role__r for Role__c
+	RelationshipName string `json:"relationshipName"`
+	// ReferenceTo are the sObjects the lookup field refers to. Polymorphic fields refer to more than one.
+	ReferenceTo []string `json:"referenceTo"`
+}
+
+// ChildRelationshipDescribe is the describe metadata of a child relationship of sObject
+type ChildRelationshipDescribe struct {
+	ChildSObject     string `json:"childSObject"`
+	Field            string `json:"field"`
+	RelationshipName string `json:"relationshipName"`
+}
+
+// sObjectSchema indexes the fields and relationships of sObject by their lower case names, since SOQL is
+// case insensitive
+type sObjectSchema struct {
+	name          string
+	fields        map[string]*FieldDescribe
+	relationships map[string]*FieldDescribe
+	children      map[string]*ChildRelationshipDescribe
+}
+
+// Schema is the describe metadata of sObjects used to validate query structs before they are sent to
+// Salesforce
+type Schema struct {
+	sObjects map[string]*sObjectSchema
+}
+
+// NewSchema returns the Schema of sObjects described by describes
+func NewSchema(describes ...SObjectDescribe) *Schema {
+	schema := &Schema{sObjects: make(map[string]*sObjectSchema)}
+	for indx := range describes {
+		describe := &describes[indx]
+		sObject := &sObjectSchema{
+			name:          describe.Name,
+			fields:        make(map[string]*FieldDescribe),
+			relationships: make(map[string]*FieldDescribe),
+			children:      make(map[string]*ChildRelationshipDescribe),
+		}
+		for i := range describe.Fields {
+			field := &describe.Fields[i]
+			sObject.fields[strings.ToLower(field.Name)] = field
+			if field.RelationshipName != "" {
+				sObject.relationships[strings.ToLower(field.RelationshipName)] = field
+			}
+		}
+		for i := range describe.ChildRelationships {
+			child := &describe.ChildRelationships[i]
+			if child.RelationshipName != "" {
+				sObject.children[strings.ToLower(child.RelationshipName)] = child
+			}
+		}
+		schema.sObjects[strings.ToLower(describe.Name)] = sObject
+	}
+	return schema
+}
+
+// LoadSchema returns the Schema of sObjects described by the JSON files at paths, each of which is the
+// response of sobjects/<Name>/describe resource
+func LoadSchema(paths ...string) (*Schema, error) {
+	var describes []SObjectDescribe
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var describe SObjectDescribe
+		if err := json.Unmarshal(data, &describe); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		describes = append(describes, describe)
+	}
+	return NewSchema(describes...), nil
+}
+
+func (s *Schema) sObject(name string) *sObjectSchema {
+	return s.sObjects[strings.ToLower(name)]
+}
+
+// SchemaError describes a field name used in the struct passed to Validate that does not match Schema
+type SchemaError struct {
+	// Type is the type of the struct passed to Validate
+	Type reflect.Type
+	// Field is the path of the offending member within Type, e.g. SelectClause.Role.Name
+	Field string
+	// SObject is the sObject the name was looked up in
+	SObject string
+	// Name is the field name as used in the query, e.g. Role__r.Name
+	Name string
+	// Err is one of ErrUnknownSObject, ErrUnknownField, ErrUnknownRelationship, ErrNotFilterable and
+	// ErrNotSortable
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %s.%s uses %s of %s", e.Err, e.Type, e.Field, e.Name, e.SObject)
+}
+
+// Unwrap returns the sentinel error so that SchemaError can be used with errors.Is
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaErrors are all the mismatches found by Validate
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for indx, err := range e {
+		messages[indx] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks every field name the query built by Marshal from v would use against Schema. It checks
+// columns of selectClause, including parent relationship paths like Role__r.Name and nested parent structs,
+// child relationships used with selectChild along with the child queries, branches of selectTypeOf, fields of
+// whereClause and havingClause, semi-join subqueries and the fields used in orderByClause. Fields of
+// whereClause must be filterable and fields of orderByClause sortable. Paths through polymorphic relationships or to sObjects
+// that are not in Schema cannot be checked and are skipped.
+// If v cannot be marshaled, the error returned by Marshal is returned. Otherwise all mismatches are returned
+// together as SchemaErrors, or nil if there are none.
+// Consider following go struct
+// type TestSoqlStruct struct {
+// 	SelectClause NestedStruct `soql:"selectClause,tableName=SM_Logical_Host__c"`
+// }
+// type NestedStruct struct {
+// 	ID   string `soql:"selectColumn,fieldName=Id"`
+// 	Name string `soql:"selectColumn,fieldName=Host_Nmae__c"`
+// }
+// schema, err := LoadSchema("describe/SM_Logical_Host__c.json")
+// err = schema.Validate(TestSoqlStruct{})
+// fmt.Println(err)
+// This will print err as:
+// ErrUnknownField: main.TestSoqlStruct.SelectClause.Name uses Host_Nmae__c of SM_Logical_Host__c
+func (s *Schema) Validate(v interface{}) error {
+	if _, err := Marshal(v); err != nil {
+		return err
+	}
+	rv, rt, _ := getReflectedValueAndType(v)
+	val := &validator{schema: s, reflectedType: rt}
+	val.validateQuery(rv, "", nil)
+	if len(val.errs) == 0 {
+		return nil
+	}
+	return val.errs
+}
+
+type validator struct {
+	schema        *Schema
+	reflectedType reflect.Type
+	errs          SchemaErrors
+}
+
+func (val *validator) report(path, sObject, name string, err error) {
+	val.errs = append(val.errs, &SchemaError{Type: val.reflectedType, Field: path, SObject: sObject, Name: name, Err: err})
+}
+
+// structValue returns the struct v points to. Nil pointers are replaced by zero structs, so that the tags of
+// members that are not set are checked as well.
+func structValue(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Struct
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + period + name
+}
+
+// validateQuery checks the query struct rv. sObject is nil for top level queries, which are looked up by
+// tableName, and is the sObject of the relationship for child queries.
+func (val *validator) validateQuery(rv reflect.Value, path string, sObject *sObjectSchema) {
+	rv, ok := structValue(rv)
+	if !ok {
+		return
+	}
+	rt := rv.Type()
+	plan := getQueryPlan(rt)
+	selectIndex, ok := plan.indexes[SelectClause]
+	if !ok {
+		return
+	}
+	var tableName string
+	for _, clause := range plan.clauses {
+		if clause.clauseKey == SelectClause {
+			tableName = clause.tableName
+		}
+	}
+	selectPath := joinPath(path, rt.Field(selectIndex).Name)
+	if sObject == nil {
+		sObject = val.schema.sObject(tableName)
+		if sObject == nil {
+			val.report(selectPath, tableName, tableName, ErrUnknownSObject)
+			return
+		}
+	} else if !strings.EqualFold(sObject.name, tableName) {
+		// Columns of child queries are prefixed with tableName
+		val.report(selectPath, sObject.name, tableName, ErrUnknownSObject)
+		return
+	}
+	selectValue, selectType := getSelectStructValueAndType(rv.Field(selectIndex), rt.Field(selectIndex).Type)
+	selectValue, ok = structValue(selectValue)
+	if !ok {
+		return
+	}
+	val.validateSelect(selectValue, selectPath, sObject, "")
+
+	for _, clause := range plan.clauses {
+		fieldPath := joinPath(path, rt.Field(clause.index).Name)
+		switch clause.clauseKey {
+		case WhereClause:
+			val.validateWhere(rv.Field(clause.index), fieldPath, sObject, true)
+		case HavingClause:
+			val.validateWhere(rv.Field(clause.index), fieldPath, sObject, false)
+		case OrderByClause:
+			// Marshal succeeded, so every order refers to a column of selectClause, which is reported already if
+			// it does not exist
+			orders, _ := rv.Field(clause.index).Interface().([]Order)
+			columns := getColumnMappings(selectType)
+			for _, order := range orders {
+				column := columns[order.Field]
+				if isAggregateColumn(column) {
+					continue
+				}
+				field, _ := val.schema.lookup(sObject, column)
+				if field != nil && !field.Sortable {
+					val.report(fieldPath, sObject.name, column, ErrNotSortable)
+				}
+			}
+		}
+	}
+}
+
+// validateSelect checks the columns of selectClause struct rv. prefix is the path of parent relationships of
+// nested structs.
+func (val *validator) validateSelect(rv reflect.Value, path string, sObject *sObjectSchema, prefix string) {
+	rt := rv.Type()
+	for _, f := range getSelectPlan(rt) {
+		fieldPath := joinPath(path, rt.Field(f.index).Name)
+		switch {
+		case f.clauseKey == SelectChild:
+			child, ok := sObject.children[strings.ToLower(f.fieldName)]
+			if !ok || prefix != "" {
+				val.report(fieldPath, sObject.name, prefix+f.fieldName, ErrUnknownRelationship)
+				continue
+			}
+			if childSObject := val.schema.sObject(child.ChildSObject); childSObject != nil {
+				val.validateQuery(rv.Field(f.index), fieldPath, childSObject)
+			}
+		case f.clauseKey == SelectTypeOf:
+			val.validateTypeOf(f.fieldType, fieldPath, sObject, prefix+f.fieldName)
+		case f.isNested:
+			val.validateSelect(reflect.Zero(f.fieldType), fieldPath, sObject, prefix+f.fieldName+period)
+		default:
+			val.resolve(fieldPath, sObject, prefix+f.fieldName)
+		}
+	}
+}
+
+// validateTypeOf checks the branches of selectTypeOf struct rt for polymorphic relationship name of sObject.
+// The sObjects of selectWhen members must be among those the relationship refers to, and their columns are
+// checked against them. Columns of selectElse member cannot be checked.
+func (val *validator) validateTypeOf(rt reflect.Type, path string, sObject *sObjectSchema, name string) {
+	relationship, ok := sObject.relationships[strings.ToLower(name)]
+	if !ok {
+		val.report(path, sObject.name, name, ErrUnknownRelationship)
+		return
+	}
+	for _, branch := range getTypeOfPlan(rt).branches {
+		if branch.clauseKey != SelectWhen {
+			continue
+		}
+		branchPath := joinPath(path, rt.Field(branch.index).Name)
+		if !containsFold(relationship.ReferenceTo, branch.sObject) {
+			val.report(branchPath, sObject.name, branch.sObject, ErrUnknownSObject)
+			continue
+		}
+		if branchSObject := val.schema.sObject(branch.sObject); branchSObject != nil {
+			val.validateSelect(reflect.Zero(branch.structType), branchPath, branchSObject, "")
+		}
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWhere checks the conditions of whereClause or havingClause struct rv. Fields of whereClause must be
+// filterable, while havingClause filters on aggregate functions like COUNT(Id).
+func (val *validator) validateWhere(rv reflect.Value, path string, sObject *sObjectSchema, isWhere bool) {
+	rv, ok := structValue(rv)
+	if !ok {
+		return
+	}
+	rt := rv.Type()
+	for _, f := range getWherePlan(rt) {
+		if f.err != nil {
+			continue
+		}
+		fieldPath := joinPath(path, rt.Field(f.index).Name)
+		if f.clauseKey != Subquery {
+			val.validateCondition(fieldPath, sObject, f.fieldName, isWhere)
+			continue
+		}
+		if f.joinErr != nil {
+			continue
+		}
+		if f.isSlice {
+			// Elements of slices share their type, so a zero element checks all of them
+			val.validateWhere(reflect.Zero(rt.Field(f.index).Type.Elem()), fieldPath, sObject, isWhere)
+		} else if f.joiner == inOperator || f.joiner == notInOperator {
+			val.validateCondition(fieldPath, sObject, f.joinFieldName, isWhere)
+			val.validateQuery(rv.Field(f.index), fieldPath, nil)
+		} else {
+			val.validateWhere(rv.Field(f.index), fieldPath, sObject, isWhere)
+		}
+	}
+}
+
+func (val *validator) validateCondition(path string, sObject *sObjectSchema, name string, isWhere bool) {
+	if indx := strings.Index(name, openBrace); indx >= 0 && isAggregateColumn(name) {
+		name = name[indx+1 : len(name)-1]
+		if name == "" {
+			// COUNT()
+			return
+		}
+		isWhere = false
+	}
+	field := val.resolve(path, sObject, name)
+	if field != nil && isWhere && !field.Filterable {
+		val.report(path, sObject.name, name, ErrNotFilterable)
+	}
+}
+
+// resolve returns the field of sObject at name, which may be a path through parent relationships like
+// Role__r.Name. Mismatches are reported and nil is returned, which is returned as well when the path cannot be
+// checked.
+func (val *validator) resolve(path string, sObject *sObjectSchema, name string) *FieldDescribe {
+	field, err := val.schema.lookup(sObject, name)
+	if err != nil {
+		val.report(path, sObject.name, name, err)
+	}
+	return field
+}
+
+// lookup returns the field of sObject at name following parent relationships. It returns nil without error when
+// the path goes through a polymorphic relationship or an sObject that is not in Schema.
+func (s *Schema) lookup(sObject *sObjectSchema, name string) (*FieldDescribe, error) {
+	parts := strings.Split(name, period)
+	for _, part := range parts[:len(parts)-1] {
+		relationship, ok := sObject.relationships[strings.ToLower(part)]
+		if !ok {
+			return nil, ErrUnknownRelationship
+		}
+		if len(relationship.ReferenceTo) != 1 {
+			return nil, nil
+		}
+		if sObject = s.sObject(relationship.ReferenceTo[0]); sObject == nil {
+			return nil, nil
+		}
+	}
+	field, ok := sObject.fields[strings.ToLower(parts[len(parts)-1])]
+	if !ok {
+		return nil, ErrUnknownField
+	}
+	return field, nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var _ = Describe("Schema", func() {
+	var schema *soql.Schema
+
+	BeforeEach(func() {
+		var err error
+		schema, err = soql.LoadSchema(
+			filepath.Join("testdata", "describe", "SM_Logical_Host__c.json"),
+			filepath.Join("testdata", "describe", "SM_Role__c.json"),
+			filepath.Join("testdata", "describe", "SM_Application_Versions__c.json"),
+		)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("Validate", func() {
+		var (
+			soqlStruct interface{}
+			err        error
+		)
+
+		JustBeforeEach(func() {
+			err = schema.Validate(soqlStruct)
+		})
+
+		Context("when all fields match the schema", func() {
+			BeforeEach(func() {
+				soqlStruct = unmarshalSoqlStruct{}
+			})
+
+			It("returns no error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when fields do not match the schema", func() {
+			BeforeEach(func() {
+				soqlStruct = &schemaSoqlStruct{
+					OrderByClause: []soql.Order{{Field: "Notes"}, {Field: "ID", IsDesc: true}},
+				}
+			})
+
+			It("returns all the mismatches", func() {
+				Expect(err).To(HaveOccurred())
+				schemaErrs, ok := err.(soql.SchemaErrors)
+				Expect(ok).To(BeTrue())
+				type mismatch struct {
+					Field   string
+					SObject string
+					Name    string
+					Err     error
+				}
+				var mismatches []mismatch
+				for _, schemaErr := range schemaErrs {
+					Expect(schemaErr.Type).To(Equal(reflect.TypeOf(schemaSoqlStruct{})))
+					mismatches = append(mismatches, mismatch{schemaErr.Field, schemaErr.SObject, schemaErr.Name, schemaErr.Err})
+				}
+				Expect(mismatches).To(Equal([]mismatch{
+					{"SelectClause.Name", "SM_Logical_Host__c", "Host_Nmae__c", soql.ErrUnknownField},
+					{"SelectClause.RoleName", "SM_Logical_Host__c", "Roles__r.Name", soql.ErrUnknownRelationship},
+					{"SelectClause.Role.Name", "SM_Logical_Host__c", "Role__r.Nmae", soql.ErrUnknownField},
+					{"SelectClause.Versions", "SM_Logical_Host__c", "Versions__r", soql.ErrUnknownRelationship},
+					{"SelectClause.Owner.Queue", "SM_Logical_Host__c", "Queue", soql.ErrUnknownSObject},
+					{"SelectClause.What", "SM_Logical_Host__c", "What", soql.ErrUnknownRelationship},
+					{"WhereClause.Notes", "SM_Logical_Host__c", "Notes__c", soql.ErrNotFilterable},
+					{"WhereClause.Statuses.Statuses", "SM_Logical_Host__c", "Stauts__c", soql.ErrUnknownField},
+					{"WhereClause.Groups.Statuses", "SM_Logical_Host__c", "Stauts__c", soql.ErrUnknownField},
+					{"WhereClause.Hosts.WhereClause.ReleaseNotes", "SM_Application_Versions__c", "Release_Notes__c", soql.ErrNotFilterable},
+					{"OrderByClause", "SM_Logical_Host__c", "Notes__c", soql.ErrNotSortable},
+				}))
+				Expect(errors.Is(schemaErrs[0], soql.ErrUnknownField)).To(BeTrue())
+				Expect(schemaErrs[0].Error()).To(Equal("ErrUnknownField: soql_test.schemaSoqlStruct.SelectClause.Name uses Host_Nmae__c of SM_Logical_Host__c"))
+			})
+		})
+
+		Context("when table is not in the schema", func() {
+			BeforeEach(func() {
+				soqlStruct = soqlSubQueryInTestStruct{}
+			})
+
+			It("returns ErrUnknownSObject error", func() {
+				schemaErrs, ok := err.(soql.SchemaErrors)
+				Expect(ok).To(BeTrue())
+				Expect(schemaErrs).To(HaveLen(1))
+				Expect(schemaErrs[0].Err).To(Equal(soql.ErrUnknownSObject))
+				Expect(schemaErrs[0].Field).To(Equal("SelectClause"))
+			})
+		})
+
+		Context("when table of child query is not the sObject of the relationship", func() {
+			BeforeEach(func() {
+				soqlStruct = schemaChildTableSoqlStruct{}
+			})
+
+			It("returns ErrUnknownSObject error", func() {
+				schemaErrs, ok := err.(soql.SchemaErrors)
+				Expect(ok).To(BeTrue())
+				Expect(schemaErrs).To(HaveLen(1))
+				Expect(schemaErrs[0].Err).To(Equal(soql.ErrUnknownSObject))
+				Expect(schemaErrs[0].Field).To(Equal("SelectClause.Versions.SelectClause"))
+				Expect(schemaErrs[0].Name).To(Equal("SM_Role__c"))
+			})
+		})
+
+		Context("when struct cannot be marshaled", func() {
+			BeforeEach(func() {
+				soqlStruct = InvalidTagInStruct{}
+			})
+
+			It("returns the error of Marshal", func() {
+				Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+			})
+		})
+	})
+
+	Describe("LoadSchema", func() {
+		Context("when file does not exist", func() {
+			It("returns error", func() {
+				_, err := soql.LoadSchema(filepath.Join("testdata", "describe", "Missing.json"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,448 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	findKeyword      = "FIND {"
+	closeCurlyBrace  = "}"
+	fieldsKeyword    = " FIELDS"
+	returningKeyword = " RETURNING "
+	withKeyword      = " WITH "
+
+	// SearchTerm is the tag to be used when marking the string to be searched for in FIND clause of SOSL search
+	SearchTerm = "searchTerm"
+	// SearchGroupClause is the tag to be used when marking the SearchGroup to be considered for IN clause of
+	// SOSL search
+	SearchGroupClause = "searchGroup"
+	// ReturningClause is the tag to be used when marking the struct to be considered for RETURNING clause of
+	// SOSL search. The struct is tagged the same way as the structs passed to Marshal
+	ReturningClause = "returningClause"
+	// WithDivision is the tag to be used when marking the string to be considered for WITH DIVISION clause
+	WithDivision = "withDivision"
+	// WithHighlight is the tag to be used when marking the bool to be considered for WITH HIGHLIGHT clause
+	WithHighlight = "withHighlight"
+	// WithMetadata is the tag to be used when marking the string to be considered for WITH METADATA clause
+	WithMetadata = "withMetadata"
+	// WithNetwork is the tag to be used when marking the string slice to be considered for WITH NETWORK clause
+	WithNetwork = "withNetwork"
+	// WithPricebookID is the tag to be used when marking the string to be considered for WITH PricebookId clause
+	WithPricebookID = "withPricebookId"
+	// WithSnippet is the tag to be used when marking the bool or the target length to be considered for
+	// WITH SNIPPET clause
+	WithSnippet = "withSnippet"
+	// WithSpellCorrection is the tag to be used when marking the *bool to be considered for WITH SPELL_CORRECTION
+	// clause
+	WithSpellCorrection = "withSpellCorrection"
+)
+
+// SearchGroup is the scope of fields to search in SOSL search
+type SearchGroup string
+
+// Search groups supported by SOSL
+const (
+	AllFields     SearchGroup = "ALL"
+	NameFields    SearchGroup = "NAME"
+	EmailFields   SearchGroup = "EMAIL"
+	PhoneFields   SearchGroup = "PHONE"
+	SidebarFields SearchGroup = "SIDEBAR"
+)
+
+var searchGroups = map[SearchGroup]bool{
+	AllFields:     true,
+	NameFields:    true,
+	EmailFields:   true,
+	PhoneFields:   true,
+	SidebarFields: true,
+}
+
+var (
+	// ErrNoSearchTerm error is returned when there is no searchTerm in struct or the search term is empty
+	ErrNoSearchTerm = errors.New("ErrNoSearchTerm")
+
+	// ErrMultipleSearchTerm error is returned when there are multiple searchTerm in struct
+	ErrMultipleSearchTerm = errors.New("ErrMultipleSearchTerm")
+
+	// ErrInvalidSearchGroup error is returned when field with searchGroup tag is not one of the SOSL search groups
+	ErrInvalidSearchGroup = errors.New("ErrInvalidSearchGroup")
+
+	// ErrInvalidReturningClause error is returned when struct tagged with returningClause has clauses not supported
+	// by SOSL or has conditions without any columns to select
+	ErrInvalidReturningClause = errors.New("ErrInvalidReturningClause")
+)
+
+// https://developer.salesforce.com/docs/atlas.en-us.soql_sosl.meta/soql_sosl/sforce_api_calls_sosl_find.htm
+const reservedSearchCharacters = `\?&|!{}[]()^~*:"'+-`
+
+var sanitizeSearchReplacer = newSearchReplacer()
+
+func newSearchReplacer() *strings.Replacer {
+	var oldnew []string
+	for _, c := range reservedSearchCharacters {
+		oldnew = append(oldnew, string(c), backslash+string(c))
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// searchWithClauses lists the tags of WITH clauses in the order they are written in SOSL search
+var searchWithClauses = []string{
+	WithDivision,
+	WithHighlight,
+	WithMetadata,
+	WithNetwork,
+	WithPricebookID,
+	WithSnippet,
+	WithSpellCorrection,
+}
+
+var searchWithBuilderMap = map[string]func(v interface{}) (string, error){
+	WithDivision:        buildWithDivisionClause,
+	WithHighlight:       buildWithHighlightClause,
+	WithMetadata:        buildWithMetadataClause,
+	WithNetwork:         buildWithNetworkClause,
+	WithPricebookID:     buildWithPricebookIDClause,
+	WithSnippet:         buildWithSnippetClause,
+	WithSpellCorrection: buildWithSpellCorrectionClause,
+}
+
+func constructSearchStringClause(v interface{}, name string) (string, error) {
+	value, ok := v.(string)
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	if value == "" {
+		return "", nil
+	}
+	return name + equalsOperator + quoteValue(value, true), nil
+}
+
+func buildWithDivisionClause(v interface{}) (string, error) {
+	return constructSearchStringClause(v, "DIVISION")
+}
+
+func buildWithMetadataClause(v interface{}) (string, error) {
+	return constructSearchStringClause(v, "METADATA")
+}
+
+func buildWithPricebookIDClause(v interface{}) (string, error) {
+	return constructSearchStringClause(v, "PricebookId")
+}
+
+func buildWithHighlightClause(v interface{}) (string, error) {
+	highlight, ok := v.(bool)
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	if !highlight {
+		return "", nil
+	}
+	return "HIGHLIGHT", nil
+}
+
+func buildWithNetworkClause(v interface{}) (string, error) {
+	networks, ok := v.([]string)
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	switch len(networks) {
+	case 0:
+		return "", nil
+	case 1:
+		return "NETWORK" + equalsOperator + quoteValue(networks[0], true), nil
+	}
+	var buff strings.Builder
+	buff.WriteString("NETWORK")
+	buff.WriteString(inOperator)
+	buff.WriteString(openBrace)
+	for indx, network := range networks {
+		if indx > 0 {
+			buff.WriteString(comma)
+		}
+		buff.WriteString(quoteValue(network, true))
+	}
+	buff.WriteString(closeBrace)
+	return buff.String(), nil
+}
+
+// buildWithSnippetClause accepts either bool or the target length of snippets as int or *int
+func buildWithSnippetClause(v interface{}) (string, error) {
+	switch u := v.(type) {
+	case bool:
+		if u {
+			return "SNIPPET", nil
+		}
+		return "", nil
+	case *int:
+		if u == nil {
+			return "", nil
+		}
+		return buildWithSnippetClause(*u)
+	case int:
+		if u < 0 {
+			return "", ErrInvalidTag
+		}
+		if u == 0 {
+			return "", nil
+		}
+		return "SNIPPET(target_length=" + strconv.Itoa(u) + closeBrace, nil
+	default:
+		return "", ErrInvalidTag
+	}
+}
+
+func buildWithSpellCorrectionClause(v interface{}) (string, error) {
+	spellCorrection, ok := v.(*bool)
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	if spellCorrection == nil {
+		return "", nil
+	}
+	return "SPELL_CORRECTION" + equalsOperator + strconv.FormatBool(*spellCorrection), nil
+}
+
+func marshalSearchTerm(v interface{}) (string, error) {
+	term, ok := v.(string)
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	if strings.TrimSpace(term) == "" {
+		return "", ErrNoSearchTerm
+	}
+	return sanitizeSearchReplacer.Replace(term), nil
+}
+
+func marshalSearchGroup(v interface{}) (string, error) {
+	group, ok := v.(SearchGroup)
+	if !ok {
+		return "", ErrInvalidTag
+	}
+	if group == "" {
+		return "", nil
+	}
+	group = SearchGroup(strings.ToUpper(string(group)))
+	if !searchGroups[group] {
+		return "", ErrInvalidSearchGroup
+	}
+	return string(group), nil
+}
+
+// marshalReturningClause returns the object to be returned by SOSL search, e.g.
+// Account(Id,Name WHERE Industry = 'Apparel' ORDER BY Name ASC LIMIT 10). Nil pointers are skipped.
+func marshalReturningClause(reflectedValue reflect.Value) (string, error) {
+	reflectedType := reflectedValue.Type()
+	if reflectedType.Kind() == reflect.Ptr {
+		if reflectedValue.IsNil() {
+			return "", nil
+		}
+		reflectedValue, reflectedType = reflectedValue.Elem(), reflectedType.Elem()
+	}
+	if reflectedType.Kind() != reflect.Struct {
+		return "", ErrInvalidTag
+	}
+	plan := getQueryPlan(reflectedType)
+	var tableName, whereJoiner string
+	for _, clause := range plan.clauses {
+		if clause.err != nil {
+			return "", newMarshalError(clause.err, reflectedType, clause.index)
+		}
+		switch clause.clauseKey {
+		case SelectClause:
+			if clause.fields != "" {
+				return "", newMarshalError(ErrInvalidReturningClause, reflectedType, clause.index)
+			}
+			tableName = clause.tableName
+		case WhereClause:
+			whereJoiner = clause.joiner
+		case GroupByClause, HavingClause, WithClause, ForClause, UsingScope:
+			return "", newMarshalError(ErrInvalidReturningClause, reflectedType, clause.index)
+		}
+	}
+	selectIndex, ok := plan.indexes[SelectClause]
+	if !ok {
+		return "", &MarshalError{Type: reflectedType, Err: ErrNoSelectClause}
+	}
+	selectValue := reflectedValue.Field(selectIndex).Interface()
+	columns, err := MarshalSelectClause(selectValue, "")
+	if err != nil {
+		return "", newMarshalError(err, reflectedType, selectIndex)
+	}
+
+	var buff strings.Builder
+	writeClause := func(clauseKey, keyword string, marshalClause func(v interface{}) (string, error)) error {
+		index, ok := plan.indexes[clauseKey]
+		if !ok {
+			return nil
+		}
+		subStr, err := marshalClause(reflectedValue.Field(index).Interface())
+		if err != nil {
+			return newMarshalError(err, reflectedType, index)
+		}
+		if subStr != "" {
+			buff.WriteString(keyword)
+			buff.WriteString(subStr)
+		}
+		return nil
+	}
+	err = writeClause(WhereClause, whereKeyword, func(v interface{}) (string, error) {
+		return marshalWhereClause(v, "", whereJoiner)
+	})
+	if err != nil {
+		return "", err
+	}
+	err = writeClause(OrderByClause, orderByKeyword, func(v interface{}) (string, error) {
+		return marshalOrderByClause(v, "", selectValue)
+	})
+	if err != nil {
+		return "", err
+	}
+	if err = writeClause(LimitClause, limitKeyword, marshalLimitClause); err != nil {
+		return "", err
+	}
+	if err = writeClause(OffsetClause, offsetKeyword, marshalOffsetClause); err != nil {
+		return "", err
+	}
+
+	if columns == "" {
+		if buff.Len() > 0 {
+			// SOSL requires columns to be listed for any of the other clauses
+			return "", newMarshalError(ErrInvalidReturningClause, reflectedType, selectIndex)
+		}
+		return tableName, nil
+	}
+	return tableName + openBrace + columns + buff.String() + closeBrace, nil
+}
+
+// MarshalSearch constructs the SOSL search based on the golang struct passed to it.
+// The term to search for is specified with searchTerm tag and is escaped, so reserved characters like * and ?
+// are searched for literally. Members tagged with returningClause are structs tagged the same way as the structs
+// passed to Marshal, except that groupByClause and havingClause are not supported by SOSL.
+// Consider following example:
+// type AccountSearch struct {
+// 	Term      string           `soql:"searchTerm"`
+// 	Group     soql.SearchGroup `soql:"searchGroup"`
+// 	Accounts  AccountResult    `soql:"returningClause"`
+// 	Contacts  ContactResult    `soql:"returningClause"`
+// 	Division  string           `soql:"withDivision"`
+// 	Limit     *int             `soql:"limitClause"`
+// }
+// type AccountResult struct {
+// 	SelectClause Account         `soql:"selectClause,tableName=Account"`
+// 	WhereClause  AccountCriteria `soql:"whereClause"`
+// 	Limit        *int            `soql:"limitClause"`
+// }
+// type ContactResult struct {
+// 	SelectClause Contact `soql:"selectClause,tableName=Contact"`
+// }
+// limit := 10
+// search := AccountSearch{
+// 	Term:     "Acme (US)",
+// 	Group:    soql.NameFields,
+// 	Accounts: AccountResult{
+// 		WhereClause: AccountCriteria{Industry: "Apparel"},
+// 		Limit:       &limit,
+// 	},
+// 	Division: "Global",
+// }
+// str, err := MarshalSearch(search)
+// if err != nil {
+//		log.Warn("Error in marshaling sosl")
+// }
+// fmt.Println(str)
+// This will print sosl search as:
+// FIND {Acme \(US\)} IN NAME FIELDS RETURNING Account(Id,Name WHERE Industry = 'Apparel' LIMIT 10),Contact(Name,Email) WITH DIVISION = 'Global'
+func MarshalSearch(v interface{}) (string, error) {
+	reflectedValue, reflectedType, err := getReflectedValueAndType(v)
+	if err != nil {
+		return "", &MarshalError{Type: reflect.TypeOf(v), Err: err}
+	}
+	if reflectedType.Kind() != reflect.Struct {
+		return "", &MarshalError{Type: reflectedType, Err: ErrInvalidTag}
+	}
+	var term, group, limit string
+	var returning []string
+	withClauses := make(map[string]string)
+	present := make(map[string]bool)
+	for i := 0; i < reflectedType.NumField(); i++ {
+		clauseTag := reflectedType.Field(i).Tag.Get(SoqlTag)
+		if clauseTag == "" {
+			continue
+		}
+		clauseKey := getClauseKey(clauseTag)
+		fieldValue := reflectedValue.Field(i)
+		if present[clauseKey] && clauseKey != ReturningClause {
+			switch clauseKey {
+			case SearchTerm:
+				err = ErrMultipleSearchTerm
+			case LimitClause:
+				err = ErrMultipleLimitClause
+			default:
+				err = ErrInvalidTag
+			}
+			return "", newMarshalError(err, reflectedType, i)
+		}
+		present[clauseKey] = true
+		switch clauseKey {
+		case SearchTerm:
+			term, err = marshalSearchTerm(fieldValue.Interface())
+		case SearchGroupClause:
+			group, err = marshalSearchGroup(fieldValue.Interface())
+		case ReturningClause:
+			var partial string
+			partial, err = marshalReturningClause(fieldValue)
+			if partial != "" {
+				returning = append(returning, partial)
+			}
+		case LimitClause:
+			limit, err = marshalLimitClause(fieldValue.Interface())
+		default:
+			builder, ok := searchWithBuilderMap[clauseKey]
+			if !ok {
+				err = ErrInvalidTag
+				break
+			}
+			withClauses[clauseKey], err = builder(fieldValue.Interface())
+		}
+		if err != nil {
+			return "", newMarshalError(err, reflectedType, i)
+		}
+	}
+	if !present[SearchTerm] {
+		return "", &MarshalError{Type: reflectedType, Err: ErrNoSearchTerm}
+	}
+
+	var buff strings.Builder
+	buff.WriteString(findKeyword)
+	buff.WriteString(term)
+	buff.WriteString(closeCurlyBrace)
+	if group != "" {
+		buff.WriteString(inOperator)
+		buff.WriteString(group)
+		buff.WriteString(fieldsKeyword)
+	}
+	if len(returning) > 0 {
+		buff.WriteString(returningKeyword)
+		buff.WriteString(strings.Join(returning, comma))
+	}
+	for _, clauseKey := range searchWithClauses {
+		if withClause := withClauses[clauseKey]; withClause != "" {
+			buff.WriteString(withKeyword)
+			buff.WriteString(withClause)
+		}
+	}
+	if limit != "" {
+		buff.WriteString(limitKeyword)
+		buff.WriteString(limit)
+	}
+	return buff.String(), nil
+}
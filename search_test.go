@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var _ = Describe("MarshalSearch", func() {
+	var (
+		searchStruct interface{}
+		actualSearch string
+		err          error
+	)
+
+	JustBeforeEach(func() {
+		actualSearch, err = soql.MarshalSearch(searchStruct)
+	})
+
+	Context("when all clauses are set", func() {
+		BeforeEach(func() {
+			limit := 20
+			accountLimit := 10
+			accountOffset := 5
+			spellCorrection := false
+			searchStruct = accountSearch{
+				Term:  "Acme (US)",
+				Group: soql.NameFields,
+				Accounts: accountSearchResult{
+					WhereClause:   accountCriteria{Industry: "Apparel"},
+					OrderByClause: []soql.Order{{Field: "Name"}},
+					LimitClause:   &accountLimit,
+					OffsetClause:  &accountOffset,
+				},
+				Contacts:        &contactSearchResult{},
+				Division:        "Global",
+				Highlight:       true,
+				Networks:        []string{"0DB000000000001", "0DB000000000002"},
+				Snippet:         120,
+				SpellCorrection: &spellCorrection,
+				Limit:           &limit,
+			}
+		})
+
+		It("returns properly constructed sosl search", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualSearch).To(Equal("FIND {Acme \\(US\\)} IN NAME FIELDS RETURNING Account(Id,Name WHERE Industry = 'Apparel' ORDER BY Name ASC LIMIT 10 OFFSET 5),Contact(Name,Email,Phone) WITH DIVISION = 'Global' WITH HIGHLIGHT WITH NETWORK IN ('0DB000000000001','0DB000000000002') WITH SNIPPET(target_length=120) WITH SPELL_CORRECTION = false LIMIT 20"))
+		})
+	})
+
+	Context("when only search term is set", func() {
+		BeforeEach(func() {
+			searchStruct = accountSearch{Term: "acme"}
+		})
+
+		It("skips nil returning structs and empty clauses", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualSearch).To(Equal("FIND {acme} RETURNING Account(Id,Name)"))
+		})
+	})
+
+	Context("when search term has reserved characters", func() {
+		BeforeEach(func() {
+			searchStruct = &accountSearch{Term: `it's "big" & {new}? 50%-off! [a|b] ~c^d* e:f+g\h`}
+		})
+
+		It("escapes them", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualSearch).To(Equal(`FIND {it\'s \"big\" \& \{new\}\? 50%\-off\! \[a\|b\] \~c\^d\* e\:f\+g\\h} RETURNING Account(Id,Name)`))
+		})
+	})
+
+	Context("when returning struct is used with Marshal as well", func() {
+		BeforeEach(func() {
+			searchStruct = accountSearch{
+				Term:  "db",
+				Group: "all",
+				Hosts: &TestSoqlStruct{WhereClause: TestQueryCriteria{Roles: []string{"db"}}},
+			}
+		})
+
+		It("reuses its clauses", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualSearch).To(Equal("FIND {db} IN ALL FIELDS RETURNING Account(Id,Name),SM_Logical_Host__c(Id,Name__c,NonNestedStruct__r.Name,NonNestedStruct__r.SomeValue__c WHERE Role__r.Name IN ('db'))"))
+		})
+	})
+
+	Context("when returning struct has no columns", func() {
+		BeforeEach(func() {
+			searchStruct = idOnlySearch{Term: "acme"}
+		})
+
+		It("returns only the object name", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualSearch).To(Equal("FIND {acme} RETURNING Lead"))
+		})
+	})
+
+	Context("when returning struct has conditions but no columns", func() {
+		BeforeEach(func() {
+			searchStruct = conditionsWithoutColumnsSearch{
+				Term:  "acme",
+				Leads: conditionsWithoutColumns{WhereClause: accountCriteria{Industry: "Apparel"}},
+			}
+		})
+
+		It("returns ErrInvalidReturningClause error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrInvalidReturningClause))
+		})
+	})
+
+	Context("when returning struct has group by clause", func() {
+		BeforeEach(func() {
+			searchStruct = aggregateSearch{Term: "acme"}
+		})
+
+		It("returns ErrInvalidReturningClause error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrInvalidReturningClause))
+			Expect(err.(*soql.MarshalError).Field).To(Equal("Cases.GroupByClause"))
+		})
+	})
+
+	Context("when returning struct has with clause", func() {
+		BeforeEach(func() {
+			searchStruct = securedSearch{Term: "acme"}
+		})
+
+		It("returns ErrInvalidReturningClause error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrInvalidReturningClause))
+			Expect(err.(*soql.MarshalError).Field).To(Equal("Cases.WithClause"))
+		})
+	})
+
+	Context("when returning struct has fields parameter", func() {
+		BeforeEach(func() {
+			searchStruct = allFieldsSearch{Term: "acme"}
+		})
+
+		It("returns ErrInvalidReturningClause error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrInvalidReturningClause))
+			Expect(err.(*soql.MarshalError).Field).To(Equal("Accounts.SelectClause"))
+		})
+	})
+
+	Context("when search term is missing", func() {
+		BeforeEach(func() {
+			searchStruct = noSearchTermSearch{}
+		})
+
+		It("returns ErrNoSearchTerm error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrNoSearchTerm))
+		})
+	})
+
+	Context("when search term is empty", func() {
+		BeforeEach(func() {
+			searchStruct = accountSearch{Term: " "}
+		})
+
+		It("returns ErrNoSearchTerm error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrNoSearchTerm))
+		})
+	})
+
+	Context("when there are multiple search terms", func() {
+		BeforeEach(func() {
+			searchStruct = multipleSearchTermSearch{Term: "a", Term2: "b"}
+		})
+
+		It("returns ErrMultipleSearchTerm error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrMultipleSearchTerm))
+		})
+	})
+
+	Context("when search group is unknown", func() {
+		BeforeEach(func() {
+			searchStruct = accountSearch{Term: "acme", Group: "TITLE"}
+		})
+
+		It("returns ErrInvalidSearchGroup error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrInvalidSearchGroup))
+		})
+	})
+
+	Context("when with clause has invalid type", func() {
+		BeforeEach(func() {
+			searchStruct = invalidWithSearch{Term: "acme", Highlight: "yes"}
+		})
+
+		It("returns ErrInvalidTag error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+			Expect(err.(*soql.MarshalError).Field).To(Equal("Highlight"))
+		})
+	})
+
+	Context("when snippet target length is negative", func() {
+		BeforeEach(func() {
+			searchStruct = accountSearch{Term: "acme", Snippet: -1}
+		})
+
+		It("returns ErrInvalidTag error", func() {
+			Expect(err).To(matchMarshalError(soql.ErrInvalidTag))
+		})
+	})
+})
@@ -7,13 +7,17 @@
 package soql_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 
-	. "github.com/forcedotcom/go-soql"
+	"github.com/forcedotcom/go-soql"
 )
 
 func TestSoql(t *testing.T) {
@@ -21,6 +25,15 @@ func TestSoql(t *testing.T) {
 	RunSpecs(t, "Soql Suite")
 }
 
+// matchMarshalError succeeds if the error is a *soql.MarshalError wrapping the expected sentinel error
+func matchMarshalError(expected error) types.GomegaMatcher {
+	return SatisfyAll(
+		BeAssignableToTypeOf(&soql.MarshalError{}),
+		WithTransform(func(err error) error { return err.(*soql.MarshalError).Err }, Equal(expected)),
+		WithTransform(func(err error) bool { return errors.Is(err, expected) }, BeTrue()),
+	)
+}
+
 type TestSoqlStruct struct {
 	SelectClause NestedStruct      `soql:"selectClause,tableName=SM_Logical_Host__c"`
 	WhereClause  TestQueryCriteria `soql:"whereClause"`
@@ -64,8 +77,9 @@ type TestChildStruct struct {
 }
 
 type TestChildWithOrderByStruct struct {
-	SelectClause  ChildStruct `soql:"selectClause,tableName=SM_Application_Versions__c"`
-	OrderByClause []Order     `soql:"orderByClause"`
+	SelectClause  ChildStruct  `soql:"selectClause,tableName=SM_Application_Versions__c"`
+	OrderByClause []soql.Order `soql:"orderByClause"`
+	ForClause     soql.ForMode `soql:"forClause"`
 }
 
 type ChildStruct struct {
@@ -130,8 +144,8 @@ type MultipleWhereClause struct {
 }
 
 type MultipleOrderByClause struct {
-	OrderByClause1 []Order `soql:"orderByClause"`
-	OrderByClause2 []Order `soql:"orderByClause"`
+	OrderByClause1 []soql.Order `soql:"orderByClause"`
+	OrderByClause2 []soql.Order `soql:"orderByClause"`
 }
 
 type OnlyWhereClause struct {
@@ -139,7 +153,7 @@ type OnlyWhereClause struct {
 }
 
 type OnlyOrderByClause struct {
-	OrderByClause []Order `soql:"orderByClause"`
+	OrderByClause []soql.Order `soql:"orderByClause"`
 }
 
 type EmptyStruct struct {
@@ -216,6 +230,127 @@ type QueryCriteriaWithPtrDateTimeType struct {
 	ResolvedDate *time.Time `soql:"equalsOperator,fieldName=ResolvedDate"`
 }
 
+type QueryCriteriaDateLiteralValues struct {
+	CreatedDate      soql.DateLiteral   `soql:"greaterThanOrEqualsToOperator,fieldName=CreatedDate"`
+	LastModifiedDate *soql.DateLiteral  `soql:"lessThanOperator,fieldName=LastModifiedDate"`
+	CloseDate        []soql.DateLiteral `soql:"inOperator,fieldName=CloseDate"`
+	ScheduledDate    []soql.DateLiteral `soql:"notInOperator,fieldName=Scheduled_Date__c"`
+	ResolvedDate     soql.DateLiteral   `soql:"notEqualsOperator,fieldName=Resolved_Date__c"`
+}
+
+type QueryCriteriaMultiSelectPicklist struct {
+	IncludesAny  []string   `soql:"includesOperator,fieldName=Roles__c"`
+	IncludesAll  [][]string `soql:"includesOperator,fieldName=Roles__c"`
+	ExcludesAny  []string   `soql:"excludesOperator,fieldName=Roles__c"`
+	ExcludesNone []string   `soql:"excludesOperator,fieldName=Roles__c"`
+}
+
+// HostID is a typed ID written as its underlying string
+type HostID string
+
+// RoleName is a picklist value written as its underlying string
+type RoleName string
+
+// Priority is an enum written as its name
+type Priority int
+
+func (p Priority) String() string {
+	return [...]string{"Low", "Medium", "High"}[p]
+}
+
+// Amount is a decimal amount in cents written as unquoted number
+type Amount int64
+
+func (a Amount) SOQLValue() (string, bool) {
+	return fmt.Sprintf("%d.%02d", a/100, a%100), false
+}
+
+// Day is a date without time written as unquoted date
+type Day struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func (d Day) SOQLValue() (string, bool) {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day), false
+}
+
+// Region is written as the text it marshals to
+type Region struct {
+	Code string
+}
+
+func (r Region) MarshalText() ([]byte, error) {
+	if r.Code == "" {
+		return nil, errEmptyRegion
+	}
+	return []byte(strings.ToUpper(r.Code)), nil
+}
+
+var errEmptyRegion = errors.New("empty region")
+
+// CivilDate is shaped like civil.Date, whose String and MarshalText return the date, so it implements
+// SOQLValuer to be written unquoted
+type CivilDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func (d CivilDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+func (d CivilDate) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d CivilDate) SOQLValue() (string, bool) {
+	return d.String(), false
+}
+
+// Release is written as quoted string even if its name looks like a date
+type Release struct {
+	Name string
+}
+
+func (r Release) String() string {
+	return r.Name
+}
+
+// Days is a number of days
+type Days uint8
+
+// Weight is a measure written as its underlying number
+type Weight float64
+
+// Enabled is a flag of nullOperator
+type Enabled bool
+
+type QueryCriteriaCustomValues struct {
+	HostID     HostID     `soql:"equalsOperator,fieldName=Id"`
+	HostIDs    []HostID   `soql:"inOperator,fieldName=Host__c"`
+	Priority   Priority   `soql:"notEqualsOperator,fieldName=Priority"`
+	Priorities []Priority `soql:"notInOperator,fieldName=Priority"`
+	MinAmount  *Amount    `soql:"greaterThanOrEqualsToOperator,fieldName=Amount"`
+	MaxAmount  *Amount    `soql:"lessThanOperator,fieldName=Amount"`
+	ClosedOn   Day        `soql:"lessThanOperator,fieldName=CloseDate"`
+	Regions    []Region   `soql:"likeOperator,fieldName=Region__c"`
+	Roles      []RoleName `soql:"includesOperator,fieldName=Roles__c"`
+	AllRoles   [][]Region `soql:"excludesOperator,fieldName=Regions__c"`
+	Recent     Days       `soql:"greaterLastNDaysOperator,fieldName=CreatedDate"`
+	Upcoming   *Days      `soql:"lessNextNDaysOperator,fieldName=CloseDate"`
+	Enabled    *Enabled   `soql:"nullOperator,fieldName=Enabled__c"`
+}
+
+type QueryCriteriaDates struct {
+	ClosedAfter CivilDate   `soql:"greaterThanOperator,fieldName=CloseDate"`
+	ClosedOn    []CivilDate `soql:"inOperator,fieldName=CloseDate"`
+	Release     Release     `soql:"equalsOperator,fieldName=Release__c"`
+	Releases    []Release   `soql:"likeOperator,fieldName=Release__c"`
+}
+
 type QueryCriteriaNumericComparisonOperators struct {
 	NumOfCPUCores                    int `soql:"greaterThanOperator,fieldName=Num_of_CPU_Cores__c"`
 	PhysicalCPUCount                 int `soql:"lessThanOperator,fieldName=Physical_CPU_Count__c"`
@@ -275,12 +410,13 @@ type InvalidSelectClause struct {
 
 type TestSoqlOrderByStruct struct {
 	SelectClause  NestedStruct `soql:"selectClause,tableName=SM_Logical_Host__c"`
-	OrderByClause []Order      `soql:"orderByClause"`
+	OrderByClause []soql.Order `soql:"orderByClause"`
 }
 
 type TestSoqlChildRelationOrderByStruct struct {
 	SelectClause  OrderByParentStruct `soql:"selectClause,tableName=SM_Logical_Host__c"`
-	OrderByClause []Order             `soql:"orderByClause"`
+	OrderByClause []soql.Order        `soql:"orderByClause"`
+	ForClause     soql.ForMode        `soql:"forClause"`
 }
 
 type TestSoqlLimitStruct struct {
@@ -347,6 +483,8 @@ type TestSoqlLimitAndOffsetStruct struct {
 	WhereClause  TestQueryCriteria `soql:"whereClause"`
 	Limit        *int              `soql:"limitClause"`
 	Offset       *int              `soql:"offsetClause"`
+	For          soql.ForMode      `soql:"forClause"`
+	Scope        soql.FilterScope  `soql:"usingScope"`
 }
 
 // setups for OR and subfilter tests
@@ -422,6 +560,20 @@ type soqlSubQueryTestStruct struct {
 	WhereClause  queryCriteria `soql:"whereClause"`
 }
 
+type invalidNestedSoqlStruct struct {
+	SelectClause contact               `soql:"selectClause,tableName=Contact"`
+	WhereClause  invalidNestedCriteria `soql:"whereClause"`
+}
+
+type invalidNestedCriteria struct {
+	Position invalidPositionCriteria `soql:"subquery,joiner=or"`
+}
+
+type invalidPositionCriteria struct {
+	Title string   `soql:"equalsOperator,fieldName=Title"`
+	Roles []string `soql:"inOperator,fieldName="`
+}
+
 type soqlSubQueryInvalidTypeTestStruct struct {
 	SelectClause contact                 `soql:"selectClause,tableName=Contact"`
 	WhereClause  invalidSubqueryCriteria `soql:"whereClause"`
@@ -442,6 +594,27 @@ type ptrSubqueryCriteria struct {
 	Contactable *contactableCriteria `soql:"subquery,joiner=OR"`
 }
 
+type soqlGroupTestStruct struct {
+	SelectClause contact       `soql:"selectClause,tableName=Contact"`
+	WhereClause  groupCriteria `soql:"whereClause"`
+}
+
+type groupCriteria struct {
+	Type         string                 `soql:"equalsOperator,fieldName=Type"`
+	Excluded     *deptManagerCriteria   `soql:"subquery,joiner=or,not"`
+	Positions    []deptManagerCriteria  `soql:"subquery"`
+	NotPositions []*deptManagerCriteria `soql:"subquery,not"`
+}
+
+type stringsGroupCriteria struct {
+	Titles []string `soql:"subquery"`
+}
+
+type invalidGroupCriteria struct {
+	NotInFraud *soqlFraudStruct  `soql:"subquery,joiner=in,fieldName=Name,not"`
+	InFrauds   []soqlFraudStruct `soql:"subquery,joiner=in,fieldName=Name"`
+}
+
 type soqlSubQueryInTestStruct struct {
 	SelectClause contact            `soql:"selectClause,tableName=Contact"`
 	WhereClause  inSubqueryCriteria `soql:"whereClause"`
@@ -463,3 +636,440 @@ type soqlFraudStruct struct {
 	SelectClause contact       `soql:"selectClause,tableName=Fraud"`
 	WhereClause  fraudCriteria `soql:"whereClause"`
 }
+
+// setups for unmarshal tests
+
+type unmarshalSoqlStruct struct {
+	SelectClause []unmarshalHost    `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause  ChildQueryCriteria `soql:"whereClause"`
+}
+
+type unmarshalSingleSoqlStruct struct {
+	SelectClause unmarshalHost `soql:"selectClause,tableName=SM_Logical_Host__c"`
+}
+
+type unmarshalHost struct {
+	ID            string                `soql:"selectColumn,fieldName=Id"`
+	Name          string                `soql:"selectColumn,fieldName=Host_Name__c"`
+	RoleName      string                `soql:"selectColumn,fieldName=Role__r.Name"`
+	NumOfCPUCores *int                  `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
+	CreatedDate   time.Time             `soql:"selectColumn,fieldName=CreatedDate"`
+	LastRestart   *time.Time            `soql:"selectColumn,fieldName=Last_Restart__c"`
+	NUMAEnabled   bool                  `soql:"selectColumn,fieldName=NUMA_Enabled__c"`
+	TechAsset     unmarshalTechAsset    `soql:"selectColumn,fieldName=Tech_Asset__r"`
+	Versions      unmarshalChildVersion `soql:"selectChild,fieldName=Application_Versions__r"`
+	NonSoqlMember string
+}
+
+type unmarshalTechAsset struct {
+	AssetType string              `soql:"selectColumn,fieldName=Asset_Type_Asset_Type__c"`
+	Owner     unmarshalAssetOwner `soql:"selectColumn,fieldName=Owner__r"`
+}
+
+type unmarshalAssetOwner struct {
+	Email string `soql:"selectColumn,fieldName=Email"`
+}
+
+type unmarshalChildVersion struct {
+	SelectClause []ChildStruct      `soql:"selectClause,tableName=SM_Application_Versions__c"`
+	WhereClause  ChildQueryCriteria `soql:"whereClause"`
+}
+
+type unmarshalInvalidTagStruct struct {
+	ID   string `soql:"selectColumn,fieldName=Id"`
+	Name string `soql:"equalsOperator,fieldName=Name"`
+}
+
+// setups for aggregate tests
+
+type aggregateSoqlStruct struct {
+	SelectClause  ownerCaseCount    `soql:"selectClause,tableName=Case"`
+	WhereClause   aggregateFilter   `soql:"whereClause"`
+	GroupByClause []string          `soql:"groupByClause"`
+	HavingClause  aggregateHaving   `soql:"havingClause"`
+	OrderByClause []soql.Order      `soql:"orderByClause"`
+	LimitClause   *int              `soql:"limitClause"`
+	WithClause    soql.SecurityMode `soql:"withClause"`
+}
+
+type ownerCaseCount struct {
+	OwnerName     string    `soql:"selectColumn,fieldName=Owner.Name"`
+	Status        string    `soql:"selectColumn,fieldName=Status"`
+	CaseCount     int       `soql:"selectCount,fieldName=Id,alias=cnt"`
+	LastCreated   time.Time `soql:"selectMax,fieldName=CreatedDate"`
+	AccountCount  int       `soql:"selectCountDistinct,fieldName=AccountId"`
+	OwnerGrouping int       `soql:"selectGrouping,fieldName=Owner.Name,alias=grpOwner"`
+}
+
+// groupedSoqlStruct groups by fields without aggregate columns, like SELECT DISTINCT
+type groupedSoqlStruct struct {
+	SelectClause  []caseOwner `soql:"selectClause,tableName=Case"`
+	GroupByClause []string    `soql:"groupByClause"`
+}
+
+type caseOwner struct {
+	OwnerName string `soql:"selectColumn,fieldName=Owner.Name"`
+}
+
+type aggregateFilter struct {
+	IsClosed *bool `soql:"equalsOperator,fieldName=IsClosed"`
+}
+
+type aggregateHaving struct {
+	MinCaseCount *int `soql:"greaterThanOperator,fieldName=COUNT(Id)"`
+	MaxCaseCount *int `soql:"lessThanOperator,fieldName=COUNT(Id)"`
+}
+
+type rollupSoqlStruct struct {
+	SelectClause  ownerCaseCount `soql:"selectClause,tableName=Case"`
+	GroupByClause []string       `soql:"groupByClause,type=rollup"`
+}
+
+type cubeSoqlStruct struct {
+	SelectClause  ownerCaseCount `soql:"selectClause,tableName=Case"`
+	GroupByClause []string       `soql:"groupByClause,type=cube"`
+}
+
+type invalidGroupByTypeSoqlStruct struct {
+	SelectClause  ownerCaseCount `soql:"selectClause,tableName=Case"`
+	GroupByClause []string       `soql:"groupByClause,type=grouping sets"`
+}
+
+type invalidGroupByValueSoqlStruct struct {
+	SelectClause  ownerCaseCount `soql:"selectClause,tableName=Case"`
+	GroupByClause string         `soql:"groupByClause"`
+}
+
+type multipleGroupBySoqlStruct struct {
+	SelectClause   ownerCaseCount `soql:"selectClause,tableName=Case"`
+	GroupByClause  []string       `soql:"groupByClause"`
+	GroupByClause2 []string       `soql:"groupByClause"`
+}
+
+type multipleHavingSoqlStruct struct {
+	SelectClause  ownerCaseCount  `soql:"selectClause,tableName=Case"`
+	HavingClause  aggregateHaving `soql:"havingClause"`
+	HavingClause2 aggregateHaving `soql:"havingClause"`
+}
+
+type invalidWithSoqlStruct struct {
+	SelectClause ownerCaseCount `soql:"selectClause,tableName=Case"`
+	WithClause   string         `soql:"withClause"`
+}
+
+type multipleWithSoqlStruct struct {
+	SelectClause ownerCaseCount    `soql:"selectClause,tableName=Case"`
+	WithClause   soql.SecurityMode `soql:"withClause"`
+	WithClause2  soql.SecurityMode `soql:"withClause"`
+}
+
+type invalidForSoqlStruct struct {
+	SelectClause ownerCaseCount `soql:"selectClause,tableName=Case"`
+	ForClause    *soql.ForMode  `soql:"forClause"`
+}
+
+type multipleForSoqlStruct struct {
+	SelectClause ownerCaseCount `soql:"selectClause,tableName=Case"`
+	ForClause    soql.ForMode   `soql:"forClause"`
+	ForClause2   soql.ForMode   `soql:"forClause"`
+}
+
+type invalidScopeSoqlStruct struct {
+	SelectClause ownerCaseCount `soql:"selectClause,tableName=Case"`
+	UsingScope   string         `soql:"usingScope"`
+}
+
+type multipleScopeSoqlStruct struct {
+	SelectClause ownerCaseCount   `soql:"selectClause,tableName=Case"`
+	UsingScope   soql.FilterScope `soql:"usingScope"`
+	UsingScope2  soql.FilterScope `soql:"usingScope"`
+}
+
+type allFieldsSoqlStruct struct {
+	SelectClause account `soql:"selectClause,tableName=Account,fields=all"`
+	LimitClause  *int    `soql:"limitClause"`
+}
+
+type noColumns struct{}
+
+type standardFieldsSoqlStruct struct {
+	SelectClause noColumns `soql:"selectClause,tableName=Account,fields=STANDARD"`
+}
+
+type customFieldsSoqlStruct struct {
+	SelectClause account `soql:"selectClause,tableName=Account,fields=custom"`
+}
+
+type invalidFieldsSoqlStruct struct {
+	SelectClause account `soql:"selectClause,tableName=Account,fields=every"`
+}
+
+type accountCaseCountSoqlStruct struct {
+	SelectClause accountCaseCount `soql:"selectClause,tableName=Account"`
+}
+
+type accountCaseCount struct {
+	Name  string            `soql:"selectColumn,fieldName=Name"`
+	Cases caseCountByStatus `soql:"selectChild,fieldName=Cases"`
+}
+
+type caseCountByStatus struct {
+	SelectClause  caseStatusCount   `soql:"selectClause,tableName=Case"`
+	WithClause    soql.SecurityMode `soql:"withClause"`
+	GroupByClause []string          `soql:"groupByClause"`
+	OrderByClause []soql.Order      `soql:"orderByClause"`
+}
+
+type caseStatusCount struct {
+	Status string `soql:"selectColumn,fieldName=Status"`
+	Count  int    `soql:"selectCount,fieldName=Id"`
+}
+
+type accountSearch struct {
+	Term            string               `soql:"searchTerm"`
+	Group           soql.SearchGroup     `soql:"searchGroup"`
+	Accounts        accountSearchResult  `soql:"returningClause"`
+	Contacts        *contactSearchResult `soql:"returningClause"`
+	Hosts           *TestSoqlStruct      `soql:"returningClause"`
+	Division        string               `soql:"withDivision"`
+	Highlight       bool                 `soql:"withHighlight"`
+	Networks        []string             `soql:"withNetwork"`
+	Snippet         int                  `soql:"withSnippet"`
+	SpellCorrection *bool                `soql:"withSpellCorrection"`
+	Limit           *int                 `soql:"limitClause"`
+}
+
+type accountSearchResult struct {
+	SelectClause  account         `soql:"selectClause,tableName=Account"`
+	WhereClause   accountCriteria `soql:"whereClause"`
+	OrderByClause []soql.Order    `soql:"orderByClause"`
+	LimitClause   *int            `soql:"limitClause"`
+	OffsetClause  *int            `soql:"offsetClause"`
+}
+
+type account struct {
+	ID   string `soql:"selectColumn,fieldName=Id"`
+	Name string `soql:"selectColumn,fieldName=Name"`
+}
+
+type accountCriteria struct {
+	Industry string `soql:"equalsOperator,fieldName=Industry"`
+}
+
+type contactSearchResult struct {
+	SelectClause contact `soql:"selectClause,tableName=Contact"`
+}
+
+type idOnlySearchResult struct {
+	SelectClause struct{} `soql:"selectClause,tableName=Lead"`
+}
+
+type idOnlySearch struct {
+	Term  string             `soql:"searchTerm"`
+	Leads idOnlySearchResult `soql:"returningClause"`
+}
+
+type noSearchTermSearch struct {
+	Accounts accountSearchResult `soql:"returningClause"`
+}
+
+type multipleSearchTermSearch struct {
+	Term  string `soql:"searchTerm"`
+	Term2 string `soql:"searchTerm"`
+}
+
+type aggregateSearch struct {
+	Term  string              `soql:"searchTerm"`
+	Cases aggregateSoqlStruct `soql:"returningClause"`
+}
+
+type securedSearch struct {
+	Term  string            `soql:"searchTerm"`
+	Cases caseCountByStatus `soql:"returningClause"`
+}
+
+type allFieldsSearch struct {
+	Term     string              `soql:"searchTerm"`
+	Accounts allFieldsSoqlStruct `soql:"returningClause"`
+}
+
+type conditionsWithoutColumnsSearch struct {
+	Term  string                   `soql:"searchTerm"`
+	Leads conditionsWithoutColumns `soql:"returningClause"`
+}
+
+type conditionsWithoutColumns struct {
+	SelectClause struct{}        `soql:"selectClause,tableName=Lead"`
+	WhereClause  accountCriteria `soql:"whereClause"`
+}
+
+type invalidWithSearch struct {
+	Term      string `soql:"searchTerm"`
+	Highlight string `soql:"withHighlight"`
+}
+
+type chunkedSoqlStruct struct {
+	SelectClause NestedStruct    `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause  chunkedCriteria `soql:"whereClause"`
+}
+
+type chunkedCriteria struct {
+	Status   string               `soql:"equalsOperator,fieldName=Status__c"`
+	Hosts    *chunkedHostCriteria `soql:"subquery,joiner=or"`
+	Excluded *chunkedHostCriteria `soql:"subquery,joiner=or,not"`
+}
+
+type chunkedHostCriteria struct {
+	IDs        []string `soql:"inOperator,fieldName=Id"`
+	Names      []string `soql:"inOperator,fieldName=Name"`
+	ExcludeIDs []string `soql:"notInOperator,fieldName=Id"`
+}
+
+// setups for schema validation tests
+
+type schemaSoqlStruct struct {
+	SelectClause  []schemaHost   `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause   schemaCriteria `soql:"whereClause"`
+	OrderByClause []soql.Order   `soql:"orderByClause"`
+}
+
+type schemaHost struct {
+	ID        string         `soql:"selectColumn,fieldName=Id"`
+	Name      string         `soql:"selectColumn,fieldName=Host_Nmae__c"`
+	RoleName  string         `soql:"selectColumn,fieldName=Roles__r.Name"`
+	Role      schemaRole     `soql:"selectColumn,fieldName=Role__r"`
+	OwnerName string         `soql:"selectColumn,fieldName=Owner.Name"`
+	Notes     string         `soql:"selectColumn,fieldName=Notes__c"`
+	Versions  schemaVersions `soql:"selectChild,fieldName=Versions__r"`
+	Owner     schemaOwner    `soql:"selectTypeOf,fieldName=Owner"`
+	What      schemaOwner    `soql:"selectTypeOf"`
+}
+
+type schemaOwner struct {
+	User  whatName `soql:"selectWhen"`
+	Queue whatName `soql:"selectWhen"`
+	Other whatName `soql:"selectElse"`
+}
+
+type schemaRole struct {
+	Name string `soql:"selectColumn,fieldName=Nmae"`
+}
+
+type schemaVersions struct {
+	SelectClause []ChildStruct `soql:"selectClause,tableName=SM_Application_Versions__c"`
+}
+
+type schemaCriteria struct {
+	Notes    string                 `soql:"equalsOperator,fieldName=Notes__c"`
+	Statuses *schemaStatusCriteria  `soql:"subquery,joiner=or"`
+	Groups   []schemaStatusCriteria `soql:"subquery"`
+	Hosts    *schemaVersionQuery    `soql:"subquery,joiner=IN,fieldName=Id"`
+}
+
+type schemaStatusCriteria struct {
+	Statuses []string `soql:"inOperator,fieldName=Stauts__c"`
+}
+
+type schemaVersionQuery struct {
+	SelectClause schemaVersionHost     `soql:"selectClause,tableName=SM_Application_Versions__c"`
+	WhereClause  schemaVersionCriteria `soql:"whereClause"`
+}
+
+type schemaVersionHost struct {
+	HostID string `soql:"selectColumn,fieldName=Host__c"`
+}
+
+type schemaVersionCriteria struct {
+	ReleaseNotes []string `soql:"likeOperator,fieldName=Release_Notes__c"`
+}
+
+type schemaChildTableSoqlStruct struct {
+	SelectClause schemaChildTableHost `soql:"selectClause,tableName=SM_Logical_Host__c"`
+}
+
+type schemaChildTableHost struct {
+	Versions schemaRoleVersions `soql:"selectChild,fieldName=application_versions__r"`
+}
+
+type schemaRoleVersions struct {
+	SelectClause []ChildStruct `soql:"selectClause,tableName=SM_Role__c"`
+}
+
+// marshalerSoqlStruct constructs its query itself instead of the query of its tags
+type marshalerSoqlStruct struct {
+	SelectClause NestedStruct `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	Name         string
+}
+
+func (s marshalerSoqlStruct) MarshalSOQL() (string, error) {
+	return "SELECT Id FROM SM_Logical_Host__c WHERE Name__c = '" + s.Name + "'", nil
+}
+
+type taskSoqlStruct struct {
+	SelectClause []task `soql:"selectClause,tableName=Task"`
+}
+
+type task struct {
+	ID      string   `soql:"selectColumn,fieldName=Id"`
+	What    taskWhat `soql:"selectTypeOf,fieldName=What"`
+	Subject string   `soql:"selectColumn,fieldName=Subject"`
+}
+
+// taskWhat selects the columns of the sObject Task.What refers to
+type taskWhat struct {
+	Account     *whatAccount    `soql:"selectWhen"`
+	Opportunity whatOpportunity `soql:"selectWhen,tableName=Opportunity"`
+	Other       *whatName       `soql:"selectElse"`
+}
+
+type whatAccount struct {
+	Name     string `soql:"selectColumn,fieldName=Name"`
+	Industry string `soql:"selectColumn,fieldName=Industry"`
+}
+
+type whatOpportunity struct {
+	Amount    float64  `soql:"selectColumn,fieldName=Amount"`
+	OwnerName whatName `soql:"selectColumn,fieldName=Owner"`
+}
+
+type whatName struct {
+	Name string `soql:"selectColumn,fieldName=Name"`
+}
+
+type typeOfWithoutWhen struct {
+	What struct {
+		Other whatName `soql:"selectElse"`
+	} `soql:"selectTypeOf"`
+}
+
+type typeOfWithTwoElses struct {
+	What struct {
+		Account whatAccount `soql:"selectWhen"`
+		Other   whatName    `soql:"selectElse"`
+		Default whatName    `soql:"selectElse"`
+	} `soql:"selectTypeOf"`
+}
+
+type typeOfWithChild struct {
+	What struct {
+		Account struct {
+			Versions TestChildStruct `soql:"selectChild,fieldName=Application_Versions__r"`
+		} `soql:"selectWhen"`
+	} `soql:"selectTypeOf"`
+}
+
+type typeOfWithoutColumns struct {
+	What struct {
+		Account struct{} `soql:"selectWhen"`
+	} `soql:"selectTypeOf"`
+}
+
+type typeOfWithColumn struct {
+	What struct {
+		Account string `soql:"selectWhen"`
+	} `soql:"selectTypeOf"`
+}
+
+type typeOfOnColumn struct {
+	What string `soql:"selectTypeOf"`
+}
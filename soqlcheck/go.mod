@@ -0,0 +1,15 @@
+module github.com/forcedotcom/go-soql/soqlcheck
+
+go 1.22.0
+
+require (
+	github.com/forcedotcom/go-soql v0.0.0
+	golang.org/x/tools v0.30.0
+)
+
+require (
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
+
+replace github.com/forcedotcom/go-soql => ../
@@ -0,0 +1,399 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package soqlcheck defines an analyzer that checks soql struct tags at compile time. Malformed tags are
+// otherwise only found when the struct is marshaled, as ErrInvalidTag.
+package soqlcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+const soqlPackage = "github.com/forcedotcom/go-soql"
+
+// Analyzer checks that soql tags use known clauses and parameters, that the types of tagged members are
+// supported by the clause, e.g. []string for likeOperator, and that structs have at most one of every clause.
+var Analyzer = &analysis.Analyzer{
+	Name:     "soqlcheck",
+	Doc:      "check soql struct tags\n\nReports unknown clauses and parameters, types of members not supported by the clause and duplicate clauses.",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// clause describes what a soql tag supports
+type clause struct {
+	// params are the parameters allowed with the clause
+	params []string
+	// accepts reports whether the type of member is supported. nil accepts any type.
+	accepts func(t types.Type) bool
+	// want describes the supported types in reports
+	want string
+	// unique is set for clauses that can be used once per struct
+	unique bool
+}
+
+var (
+	integerKinds = []types.BasicKind{
+		types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+		types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+	}
+	numberKinds = append([]types.BasicKind{types.Float32, types.Float64}, integerKinds...)
+)
+
+func isBasic(kinds ...types.BasicKind) func(t types.Type) bool {
+	return func(t types.Type) bool {
+		basic, ok := t.(*types.Basic)
+		if !ok {
+			return false
+		}
+		for _, kind := range kinds {
+			if basic.Kind() == kind {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// hasUnderlying is like isBasic for named types too, which are written as their underlying values unless
+// they implement any of the interfaces of isValuer
+func hasUnderlying(kinds ...types.BasicKind) func(t types.Type) bool {
+	accepts := isBasic(kinds...)
+	return func(t types.Type) bool {
+		return accepts(t.Underlying())
+	}
+}
+
+// hasMethod returns a function reporting whether the method set of t has method name without parameters
+// returning results
+func hasMethod(name, results string) func(t types.Type) bool {
+	return func(t types.Type) bool {
+		selection := types.NewMethodSet(t).Lookup(nil, name)
+		if selection == nil {
+			return false
+		}
+		signature := selection.Type().(*types.Signature)
+		return signature.Params().Len() == 0 && types.TypeString(signature.Results(), nil) == results
+	}
+}
+
+func isNamed(pkgPath, name string) func(t types.Type) bool {
+	return func(t types.Type) bool {
+		named, ok := t.(*types.Named)
+		if !ok || named.Obj().Pkg() == nil {
+			return false
+		}
+		return named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+	}
+}
+
+func isStruct(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
+func anyOf(accepts ...func(t types.Type) bool) func(t types.Type) bool {
+	return func(t types.Type) bool {
+		for _, accept := range accepts {
+			if accept(t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func pointerTo(accepts func(t types.Type) bool) func(t types.Type) bool {
+	return func(t types.Type) bool {
+		pointer, ok := t.(*types.Pointer)
+		return ok && accepts(pointer.Elem())
+	}
+}
+
+func sliceOf(accepts func(t types.Type) bool) func(t types.Type) bool {
+	return func(t types.Type) bool {
+		slice, ok := t.(*types.Slice)
+		return ok && accepts(slice.Elem())
+	}
+}
+
+// anySliceOf is like sliceOf for named slices too, unless they format their values themselves
+func anySliceOf(accepts func(t types.Type) bool) func(t types.Type) bool {
+	return func(t types.Type) bool {
+		slice, ok := t.Underlying().(*types.Slice)
+		return ok && !isValuer(t) && accepts(slice.Elem())
+	}
+}
+
+var (
+	isString      = isBasic(types.String)
+	isBool        = isBasic(types.Bool)
+	isInt         = isBasic(types.Int)
+	isTime        = isNamed("time", "Time")
+	isDateLiteral = isNamed(soqlPackage, "DateLiteral")
+	isStructOrPtr = anyOf(isStruct, pointerTo(isStruct))
+
+	isSOQLValuer = hasMethod("SOQLValue", "(string, bool)")
+	// isValuer reports whether t implements soql.SOQLValuer, encoding.TextMarshaler or fmt.Stringer
+	isValuer = anyOf(isSOQLValuer, hasMethod("MarshalText", "([]byte, error)"), hasMethod("String", "(string)"))
+	// isValue reports whether t is supported by comparison operators and IN lists
+	isValue = anyOf(
+		hasUnderlying(append([]types.BasicKind{types.String, types.Bool}, numberKinds...)...), isTime, isValuer,
+		pointerTo(anyOf(hasUnderlying(append([]types.BasicKind{types.Bool}, numberKinds...)...), isTime, isDateLiteral, isValuer)),
+	)
+	// isText reports whether t is written as quoted string, which LIKE and INCLUDES clauses require
+	isText = func(t types.Type) bool {
+		_, isPointer := t.Underlying().(*types.Pointer)
+		return !isTime(t) && !isDateLiteral(t) && !isPointer && (hasUnderlying(types.String)(t) || isValuer(t))
+	}
+	isDays = anyOf(hasUnderlying(integerKinds...), isSOQLValuer)
+
+	likeClause = clause{
+		params:  []string{soql.FieldName},
+		accepts: anySliceOf(isText),
+		want:    "slice of strings or of soql.SOQLValuer, encoding.TextMarshaler or fmt.Stringer",
+	}
+	containsClause = clause{
+		params:  []string{soql.FieldName, soql.Format},
+		accepts: anySliceOf(isValue),
+		want:    "slice of strings, numbers, bools, time.Time, soql.DateLiteral or soql.SOQLValuer, encoding.TextMarshaler or fmt.Stringer",
+	}
+	multiSelectClause = clause{
+		params:  []string{soql.FieldName},
+		accepts: anyOf(anySliceOf(isText), anySliceOf(anySliceOf(isText))),
+		want:    "[]string or [][]string",
+	}
+	comparisonClause = clause{
+		params:  []string{soql.FieldName, soql.Format},
+		accepts: isValue,
+		want: "string, number, bool, time.Time, soql.DateLiteral or soql.SOQLValuer, encoding.TextMarshaler or " +
+			"fmt.Stringer, or pointer to any of them but string",
+	}
+	dateLiteralClause = clause{
+		params:  []string{soql.FieldName},
+		accepts: anyOf(isDays, pointerTo(isDays)),
+		want:    "integer or soql.SOQLValuer, or pointer to them",
+	}
+	aggregateClause = clause{
+		params: []string{soql.FieldName, soql.Alias, soql.Format},
+	}
+)
+
+var clauses = map[string]clause{
+	soql.SelectClause:  {params: []string{soql.TableName, soql.Fields}, accepts: anyOf(isStructOrPtr, sliceOf(isStructOrPtr)), want: "struct or slice of structs", unique: true},
+	soql.WhereClause:   {params: []string{soql.Joiner}, accepts: isStructOrPtr, want: "struct", unique: true},
+	soql.OrderByClause: {accepts: sliceOf(isNamed(soqlPackage, "Order")), want: "[]soql.Order", unique: true},
+	soql.LimitClause:   {accepts: pointerTo(isInt), want: "*int", unique: true},
+	soql.OffsetClause:  {accepts: pointerTo(isInt), want: "*int", unique: true},
+	soql.GroupByClause: {params: []string{soql.GroupByType}, accepts: sliceOf(isString), want: "[]string", unique: true},
+	soql.HavingClause:  {params: []string{soql.Joiner}, accepts: isStructOrPtr, want: "struct", unique: true},
+	soql.WithClause:    {accepts: isNamed(soqlPackage, "SecurityMode"), want: "soql.SecurityMode", unique: true},
+	soql.ForClause:     {accepts: isNamed(soqlPackage, "ForMode"), want: "soql.ForMode", unique: true},
+	soql.UsingScope:    {accepts: isNamed(soqlPackage, "FilterScope"), want: "soql.FilterScope", unique: true},
+
+	soql.SelectColumn:        {params: []string{soql.FieldName, soql.Format}},
+	soql.SelectChild:         {params: []string{soql.FieldName}, accepts: isStruct, want: "struct"},
+	soql.SelectTypeOf:        {params: []string{soql.FieldName}, accepts: isStruct, want: "struct"},
+	soql.SelectWhen:          {params: []string{soql.TableName}, accepts: isStructOrPtr, want: "struct"},
+	soql.SelectElse:          {accepts: isStructOrPtr, want: "struct", unique: true},
+	soql.SelectCount:         aggregateClause,
+	soql.SelectCountDistinct: aggregateClause,
+	soql.SelectSum:           aggregateClause,
+	soql.SelectAvg:           aggregateClause,
+	soql.SelectMin:           aggregateClause,
+	soql.SelectMax:           aggregateClause,
+	soql.SelectGrouping:      aggregateClause,
+
+	soql.LikeOperator:                    likeClause,
+	soql.NotLikeOperator:                 likeClause,
+	soql.InOperator:                      containsClause,
+	soql.NotInOperator:                   containsClause,
+	soql.IncludesOperator:                multiSelectClause,
+	soql.ExcludesOperator:                multiSelectClause,
+	soql.EqualsOperator:                  comparisonClause,
+	soql.NotEqualsOperator:               comparisonClause,
+	soql.GreaterThanOperator:             comparisonClause,
+	soql.GreaterThanOrEqualsToOperator:   comparisonClause,
+	soql.LessThanOperator:                comparisonClause,
+	soql.LessThanOrEqualsToOperator:      comparisonClause,
+	soql.NullOperator:                    {params: []string{soql.FieldName}, accepts: anyOf(hasUnderlying(types.Bool), pointerTo(hasUnderlying(types.Bool))), want: "bool or *bool"},
+	soql.GreaterNextNDaysOperator:        dateLiteralClause,
+	soql.GreaterOrEqualNextNDaysOperator: dateLiteralClause,
+	soql.EqualsNextNDaysOperator:         dateLiteralClause,
+	soql.LessNextNDaysOperator:           dateLiteralClause,
+	soql.LessOrEqualNextNDaysOperator:    dateLiteralClause,
+	soql.GreaterLastNDaysOperator:        dateLiteralClause,
+	soql.GreaterOrEqualLastNDaysOperator: dateLiteralClause,
+	soql.EqualsLastNDaysOperator:         dateLiteralClause,
+	soql.LessLastNDaysOperator:           dateLiteralClause,
+	soql.LessOrEqualLastNDaysOperator:    dateLiteralClause,
+	soql.Subquery:                        {params: []string{soql.Joiner, soql.FieldName, soql.NotOption}, accepts: anyOf(isStructOrPtr, sliceOf(isStructOrPtr)), want: "struct or slice of structs"},
+
+	soql.SearchTerm:          {accepts: isString, want: "string", unique: true},
+	soql.SearchGroupClause:   {accepts: isNamed(soqlPackage, "SearchGroup"), want: "soql.SearchGroup", unique: true},
+	soql.ReturningClause:     {accepts: isStructOrPtr, want: "struct"},
+	soql.WithDivision:        {accepts: isString, want: "string", unique: true},
+	soql.WithHighlight:       {accepts: isBool, want: "bool", unique: true},
+	soql.WithMetadata:        {accepts: isString, want: "string", unique: true},
+	soql.WithNetwork:         {accepts: sliceOf(isString), want: "[]string", unique: true},
+	soql.WithPricebookID:     {accepts: isString, want: "string", unique: true},
+	soql.WithSnippet:         {accepts: anyOf(isBool, isInt, pointerTo(isInt)), want: "bool, int or *int", unique: true},
+	soql.WithSpellCorrection: {accepts: pointerTo(isBool), want: "*bool", unique: true},
+}
+
+var clauseKeys = func() []string {
+	var keys []string
+	for key := range clauses {
+		keys = append(keys, key)
+	}
+	return keys
+}()
+
+var params = []string{soql.FieldName, soql.TableName, soql.Joiner, soql.Format, soql.Alias, soql.GroupByType, soql.NotOption, soql.Fields}
+
+// joiners are the values of joiner parameter in lower case. whereClause and havingClause only support the
+// first two.
+var joiners = []string{"and", "or", "in", "not in"}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		used := make(map[string]bool)
+		for _, field := range n.(*ast.StructType).Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			soqlTag, ok := reflect.StructTag(tag).Lookup(soql.SoqlTag)
+			if !ok {
+				continue
+			}
+			checkTag(pass, field, soqlTag, pass.TypesInfo.TypeOf(field.Type), used)
+		}
+	})
+	return nil, nil
+}
+
+func checkTag(pass *analysis.Pass, field *ast.Field, soqlTag string, fieldType types.Type, used map[string]bool) {
+	items := strings.Split(soqlTag, ",")
+	clauseKey := items[0]
+	c, ok := clauses[clauseKey]
+	if !ok {
+		pass.Reportf(field.Tag.Pos(), "unknown soql clause %q%s", clauseKey, suggest(clauseKey, clauseKeys))
+		return
+	}
+	if c.unique {
+		// Members declared together like A, B []string share the tag
+		if used[clauseKey] || len(field.Names) > 1 {
+			pass.Reportf(field.Tag.Pos(), "multiple %s in struct", clauseKey)
+		}
+		used[clauseKey] = true
+	}
+	if c.accepts != nil && fieldType != nil && !c.accepts(fieldType) {
+		pass.Reportf(field.Type.Pos(), "%s does not support %s, want %s", clauseKey, fieldType, c.want)
+	}
+
+	values := make(map[string]string)
+	for _, item := range items[1:] {
+		key, value := item, ""
+		if indx := strings.Index(item, "="); indx >= 0 {
+			key, value = item[:indx], item[indx+1:]
+		}
+		if key == "" {
+			continue
+		}
+		if !contains(params, key) {
+			pass.Reportf(field.Tag.Pos(), "unknown soql parameter %q%s", key, suggest(key, params))
+			continue
+		}
+		if !contains(c.params, key) {
+			pass.Reportf(field.Tag.Pos(), "%s does not support %s parameter", clauseKey, key)
+			continue
+		}
+		values[key] = value
+	}
+
+	if value, ok := values[soql.FieldName]; ok && value == "" {
+		pass.Reportf(field.Tag.Pos(), "%s has empty fieldName", clauseKey)
+	}
+	if value, ok := values[soql.TableName]; ok && value == "" {
+		pass.Reportf(field.Tag.Pos(), "%s has empty tableName", clauseKey)
+	}
+	if _, ok := values[soql.Format]; ok && fieldType != nil && !hasTime(fieldType) {
+		pass.Reportf(field.Tag.Pos(), "format is only supported for time.Time members")
+	}
+	if value, ok := values[soql.GroupByType]; ok {
+		if groupByType := strings.ToLower(value); groupByType != soql.Rollup && groupByType != soql.Cube {
+			pass.Reportf(field.Tag.Pos(), "invalid groupByClause type %q, want rollup or cube", value)
+		}
+	}
+	if value, ok := values[soql.Fields]; ok {
+		if fields := strings.ToLower(value); fields != soql.FieldsStandard && fields != soql.FieldsCustom && fields != soql.FieldsAll {
+			pass.Reportf(field.Tag.Pos(), "invalid fields %q, want standard, custom or all", value)
+		}
+	}
+	if value, ok := values[soql.Joiner]; ok {
+		allowed := joiners
+		if clauseKey != soql.Subquery {
+			allowed = joiners[:2]
+		}
+		joiner := strings.ToLower(value)
+		if !contains(allowed, joiner) {
+			pass.Reportf(field.Tag.Pos(), "invalid joiner %q for %s", value, clauseKey)
+		} else if joiner == "in" || joiner == "not in" {
+			if values[soql.FieldName] == "" {
+				pass.Reportf(field.Tag.Pos(), "subquery with joiner=%s requires fieldName", value)
+			}
+			if _, ok := values[soql.NotOption]; ok {
+				pass.Reportf(field.Tag.Pos(), "subquery with joiner=%s does not support not option", value)
+			}
+			if _, ok := fieldType.(*types.Slice); ok {
+				pass.Reportf(field.Type.Pos(), "subquery with joiner=%s does not support slices", value)
+			}
+		}
+	}
+	if value, ok := values[soql.NotOption]; ok && value != "" {
+		pass.Reportf(field.Tag.Pos(), "not option does not take a value")
+	}
+}
+
+// hasTime reports whether t is time.Time, a pointer to it or a slice of it
+func hasTime(t types.Type) bool {
+	return anyOf(isTime, pointerTo(isTime), sliceOf(isTime))(t)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the hint for misspelled name that differs only in case from one of the known names
+func suggest(name string, names []string) string {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return ", did you mean " + strconv.Quote(n) + "?"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package a
+
+import (
+	"time"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+type query struct {
+	SelectClause  []host            `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	UsingScope    soql.FilterScope  `soql:"usingScope"`
+	WhereClause   criteria          `soql:"whereClause,joiner=or"`
+	WithClause    soql.SecurityMode `soql:"withClause"`
+	GroupByClause []string          `soql:"groupByClause,type=ROLLUP"`
+	OrderByClause []soql.Order      `soql:"orderByClause"`
+	LimitClause   *int              `soql:"limitClause"`
+	OffsetClause  *int              `soql:"offsetClause"`
+	ForClause     soql.ForMode      `soql:"forClause"`
+}
+
+type host struct {
+	ID          string    `soql:"selectColumn,fieldName=Id"`
+	CreatedDate time.Time `soql:"selectColumn,fieldName=CreatedDate,format=2006-01-02"`
+	Role        role      `soql:"selectColumn,fieldName=Role__r"`
+	Count       int       `soql:"selectCount,fieldName=Id,alias=cnt"`
+	Versions    versions  `soql:"selectChild,fieldName=Application_Versions__r"`
+	Owner       owner     `soql:"selectTypeOf"`
+	NonSoql     string    `json:"nonSoql"`
+}
+
+type owner struct {
+	User  *role `soql:"selectWhen"`
+	Queue role  `soql:"selectWhen,tableName=Group"`
+	Other role  `soql:"selectElse"`
+}
+
+type invalidOwner struct {
+	User   role   `soql:"selectWhen,fieldName=User"` // want `selectWhen does not support fieldName parameter`
+	Queue  string `soql:"selectWhen,tableName="`     // want `selectWhen does not support string, want struct` `selectWhen has empty tableName`
+	Other  role   `soql:"selectElse"`
+	Other2 role   `soql:"selectElse"` // want `multiple selectElse in struct`
+}
+
+type role struct {
+	Name string `soql:"selectColumn,fieldName=Name"`
+}
+
+type versions struct {
+	SelectClause *version `soql:"selectClause,tableName=SM_Application_Versions__c,fields=STANDARD"`
+}
+
+type version struct {
+	Version string `soql:"selectColumn,fieldName=Version__c"`
+}
+
+type criteria struct {
+	Names       []string           `soql:"likeOperator,fieldName=Name"`
+	IDs         []string           `soql:"inOperator,fieldName=Id"`
+	Cores       []int              `soql:"notInOperator,fieldName=Cores__c"`
+	Dates       []time.Time        `soql:"inOperator,fieldName=Date__c,format=2006-01-02"`
+	Literals    []soql.DateLiteral `soql:"inOperator,fieldName=CreatedDate"`
+	Roles       [][]string         `soql:"includesOperator,fieldName=Roles__c"`
+	Status      string             `soql:"equalsOperator,fieldName=Status__c"`
+	Size        *float64           `soql:"greaterThanOperator,fieldName=Size__c"`
+	Since       *time.Time         `soql:"greaterThanOrEqualsToOperator,fieldName=CreatedDate"`
+	Today       soql.DateLiteral   `soql:"equalsOperator,fieldName=CloseDate"`
+	Days        *uint              `soql:"greaterNextNDaysOperator,fieldName=CloseDate"`
+	AllowNull   *bool              `soql:"nullOperator,fieldName=Value__c"`
+	Hosts       *query             `soql:"subquery,joiner=NOT IN,fieldName=Host__c"`
+	Contactable criteria2          `soql:"subquery,joiner=or"`
+	Unlisted    *criteria2         `soql:"subquery,joiner=or,not"`
+	AnyOf       []criteria2        `soql:"subquery"`
+}
+
+type status string
+
+type priority int
+
+func (p priority) String() string {
+	return "High"
+}
+
+type amount int64
+
+func (a *amount) SOQLValue() (string, bool) {
+	return "1.00", false
+}
+
+type days uint8
+
+type flag bool
+
+type customCriteria struct {
+	Status     status     `soql:"equalsOperator,fieldName=Status__c"`
+	Statuses   []status   `soql:"likeOperator,fieldName=Status__c"`
+	Priority   *priority  `soql:"equalsOperator,fieldName=Priority"`
+	Priorities []priority `soql:"inOperator,fieldName=Priority"`
+	Amount     *amount    `soql:"greaterThanOperator,fieldName=Amount"`
+	Amounts    []*amount  `soql:"notInOperator,fieldName=Amount"`
+	Roles      [][]status `soql:"includesOperator,fieldName=Roles__c"`
+	Days       *days      `soql:"lessNextNDaysOperator,fieldName=CloseDate"`
+	Recent     priority   `soql:"lessLastNDaysOperator,fieldName=CloseDate"`
+	Since      *amount    `soql:"greaterLastNDaysOperator,fieldName=CreatedDate"`
+	Flag       *flag      `soql:"nullOperator,fieldName=Value__c"`
+}
+
+type criteria2 struct {
+	Email string `soql:"equalsOperator"`
+}
+
+type search struct {
+	Term            string           `soql:"searchTerm"`
+	Group           soql.SearchGroup `soql:"searchGroup"`
+	Hosts           *query           `soql:"returningClause"`
+	Highlight       bool             `soql:"withHighlight"`
+	Networks        []string         `soql:"withNetwork"`
+	Snippet         *int             `soql:"withSnippet"`
+	SpellCorrection *bool            `soql:"withSpellCorrection"`
+}
+
+type invalidTags struct {
+	Name    string `soql:"equalsoperator,fieldName=Name"`                          // want `unknown soql clause "equalsoperator", did you mean "equalsOperator"\?`
+	Status  string `soql:"equalsOperator,fieldname=Status__c"`                     // want `unknown soql parameter "fieldname", did you mean "fieldName"\?`
+	Role    string `soql:"equalsOperator,fieldName=Role__c,tableName=Role"`        // want `equalsOperator does not support tableName parameter`
+	Email   string `soql:"equalsOperator,fieldName="`                              // want `equalsOperator has empty fieldName`
+	Created string `soql:"equalsOperator,fieldName=CreatedDate,format=2006-01-02"` // want `format is only supported for time.Time members`
+}
+
+type invalidTypes struct {
+	Names   int        `soql:"likeOperator,fieldName=Name"`               // want `likeOperator does not support int, want slice of strings or of soql.SOQLValuer, encoding.TextMarshaler or fmt.Stringer`
+	Status  []status   `soql:"equalsOperator,fieldName=Status__c"`        // want `equalsOperator does not support \[\]a.status, want string, number, bool, time.Time, soql.DateLiteral or soql.SOQLValuer, encoding.TextMarshaler or fmt.Stringer, or pointer to any of them but string`
+	Name    *string    `soql:"equalsOperator,fieldName=Name"`             // want `equalsOperator does not support \*string`
+	StatusP *status    `soql:"equalsOperator,fieldName=Status__c"`        // want `equalsOperator does not support \*a.status`
+	Days    float64    `soql:"lessLastNDaysOperator,fieldName=CloseDate"` // want `lessLastNDaysOperator does not support float64, want integer or soql.SOQLValuer, or pointer to them`
+	IDs     [][]status `soql:"inOperator,fieldName=Id"`                   // want `inOperator does not support \[\]\[\]a.status`
+	Dates   []days     `soql:"likeOperator,fieldName=Name"`               // want `likeOperator does not support \[\]a.days`
+	Null    string     `soql:"nullOperator,fieldName=Value__c"`           // want `nullOperator does not support string, want bool or \*bool`
+	Hosts   []query    `soql:"subquery,joiner=in,fieldName=Host__c"`      // want `subquery with joiner=in does not support slices`
+	Group   []string   `soql:"subquery"`                                  // want `subquery does not support \[\]string, want struct or slice of structs`
+	Owner   *owner     `soql:"selectTypeOf,fieldName=What"`               // want `selectTypeOf does not support \*a.owner, want struct`
+}
+
+type invalidQuery struct {
+	SelectClause  host     `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause   criteria `soql:"whereClause,joiner=in"`               // want `invalid joiner "in" for whereClause`
+	WhereClause2  criteria `soql:"whereClause"`                         // want `multiple whereClause in struct`
+	GroupByClause []string `soql:"groupByClause,type=grouping sets"`    // want `invalid groupByClause type "grouping sets", want rollup or cube`
+	OrderByClause []string `soql:"orderByClause"`                       // want `orderByClause does not support \[\]string, want \[\]soql.Order`
+	LimitClause   int      `soql:"limitClause"`                         // want `limitClause does not support int, want \*int`
+	WithClause    string   `soql:"withClause"`                          // want `withClause does not support string, want soql.SecurityMode`
+	ForClause     []string `soql:"forClause"`                           // want `forClause does not support \[\]string, want soql.ForMode`
+	UsingScope    string   `soql:"usingScope"`                          // want `usingScope does not support string, want soql.FilterScope`
+	Hosts         *query   `soql:"subquery,joiner=IN"`                  // want `subquery with joiner=IN requires fieldName`
+	NotHosts      *query   `soql:"subquery,joiner=in,fieldName=Id,not"` // want `subquery with joiner=in does not support not option`
+	Negated       criteria `soql:"subquery,not=true"`                   // want `not option does not take a value`
+}
+
+type invalidFieldsQuery struct {
+	SelectClause host `soql:"selectClause,tableName=SM_Logical_Host__c,fields=every"` // want `invalid fields "every", want standard, custom or all`
+	LimitClause  *int `soql:"limitClause"`
+}
+
+type invalidSearch struct {
+	Term, Term2 string `soql:"searchTerm"`  // want `multiple searchTerm in struct`
+	Group       string `soql:"searchGroup"` // want `searchGroup does not support string, want soql.SearchGroup`
+	Snippet     uint   `soql:"withSnippet"` // want `withSnippet does not support uint, want bool, int or \*int`
+}
@@ -0,0 +1,17 @@
+// Package soql declares the types of github.com/forcedotcom/go-soql used in testdata
+package soql
+
+type Order struct {
+	Field  string
+	IsDesc bool
+}
+
+type DateLiteral string
+
+type SearchGroup string
+
+type SecurityMode string
+
+type ForMode string
+
+type FilterScope string
@@ -0,0 +1,844 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soqltest
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+// scope is a query, or a child subquery of it, being evaluated
+type scope struct {
+	db    *DB
+	query *soql.Query
+	// child is set for the subqueries of child relationships
+	child bool
+	// joins caches the values selected by the subqueries of IN and NOT IN conditions
+	joins map[*soql.SubqueryValue][]interface{}
+}
+
+// run returns the selected fields of records matching the query, in the order and the range the query
+// specifies
+func (s *scope) run(records []Record) ([]Record, error) {
+	q := s.query
+	if q.GroupBy != nil || q.Having != nil {
+		return nil, ErrUnsupported
+	}
+	if q.Scope != "" && !strings.EqualFold(q.Scope, string(soql.ScopeEverything)) {
+		// Records have no owners, teams or territories to filter them by
+		return nil, ErrUnsupported
+	}
+	var matched []Record
+	for _, r := range records {
+		ok := true
+		if q.Where != nil {
+			var err error
+			if ok, err = s.match(r, q.Where); err != nil {
+				return nil, err
+			}
+		}
+		if ok {
+			matched = append(matched, r)
+		}
+	}
+	if err := s.sort(matched); err != nil {
+		return nil, err
+	}
+	if q.Offset != nil {
+		if *q.Offset >= len(matched) {
+			matched = nil
+		} else {
+			matched = matched[*q.Offset:]
+		}
+	}
+	if q.Limit != nil && *q.Limit < len(matched) {
+		matched = matched[:*q.Limit]
+	}
+	selected := make([]Record, 0, len(matched))
+	for _, r := range matched {
+		out, err := s.project(r)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, out)
+	}
+	return selected, nil
+}
+
+// project returns the fields of r selected by the query
+func (s *scope) project(r Record) (Record, error) {
+	out := Record{}
+	for _, item := range s.query.Fields {
+		switch f := item.(type) {
+		case *soql.FieldRef:
+			if err := selectPath(out, r, s.path(r, f.Name)); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+		case *soql.SubqueryItem:
+			name := f.Query.From.Name
+			key, value := get(r, name)
+			if key == "" {
+				key = name
+			}
+			children, ok := value.([]Record)
+			if !ok && value != nil {
+				return nil, fmt.Errorf("%s: %w", name, ErrTypeMismatch)
+			}
+			child := &scope{db: s.db, query: f.Query, child: true, joins: s.joins}
+			selected, err := child.run(children)
+			if err != nil {
+				return nil, err
+			}
+			if len(selected) == 0 {
+				out[key] = nil
+			} else {
+				out[key] = selected
+			}
+		case *soql.TypeOfItem:
+			if err := selectTypeOf(out, r, f); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Field.Name, err)
+			}
+		case *soql.FunctionCall:
+			if err := selectFields(out, r, f); err != nil {
+				return nil, fmt.Errorf("%s: %w", item, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s: %w", item, ErrUnsupported)
+		}
+	}
+	return out, nil
+}
+
+// selectFields copies the fields of src selected by FIELDS(ALL), FIELDS(CUSTOM) or FIELDS(STANDARD) to dst.
+// Custom fields are the ones ending with __c. Relationships and attributes are not copied.
+func selectFields(dst, src Record, function *soql.FunctionCall) error {
+	if !strings.EqualFold(function.Name, "FIELDS") || len(function.Args) != 1 {
+		return ErrUnsupported
+	}
+	mode, ok := function.Args[0].(*soql.FieldRef)
+	if !ok {
+		return ErrUnsupported
+	}
+	all := strings.EqualFold(mode.Name, "ALL")
+	custom := strings.EqualFold(mode.Name, "CUSTOM")
+	if !all && !custom && !strings.EqualFold(mode.Name, "STANDARD") {
+		return ErrUnsupported
+	}
+	for key, value := range src {
+		switch value.(type) {
+		case Record, []Record:
+			continue
+		}
+		if strings.EqualFold(key, "attributes") {
+			continue
+		}
+		if all || custom == strings.HasSuffix(strings.ToLower(key), "__c") {
+			dst[key] = value
+		}
+	}
+	return nil
+}
+
+// selectTypeOf copies the fields of the related record of polymorphic relationship of src to dst,
+// which are the fields of the WHEN of TYPEOF item for the sObject of the record or the fields of its ELSE.
+// The sObject of the related record is the type of its attributes.
+func selectTypeOf(dst, src Record, item *soql.TypeOfItem) error {
+	key, value := get(src, item.Field.Name)
+	if key == "" {
+		key = item.Field.Name
+	}
+	if isNil(value) {
+		dst[key] = nil
+		return nil
+	}
+	related, ok := value.(Record)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	sObject := recordType(related)
+	fields := item.Else
+	for _, when := range item.Whens {
+		if strings.EqualFold(when.Type, sObject) {
+			fields = when.Fields
+			break
+		}
+	}
+	selected := Record{}
+	if sObject != "" {
+		selected["attributes"] = Record{"type": sObject}
+	}
+	for _, field := range fields {
+		if err := selectPath(selected, related, strings.Split(field.Name, ".")); err != nil {
+			return err
+		}
+	}
+	dst[key] = selected
+	return nil
+}
+
+// selectPath copies the field of src at path to dst, creating the records of parent relationships
+func selectPath(dst, src Record, path []string) error {
+	key, value := get(src, path[0])
+	if key == "" {
+		key = path[0]
+	}
+	if len(path) == 1 {
+		dst[key] = value
+		return nil
+	}
+	if isNil(value) {
+		if _, ok := dst[key]; !ok {
+			dst[key] = nil
+		}
+		return nil
+	}
+	parent, ok := value.(Record)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	selected, ok := dst[key].(Record)
+	if !ok {
+		selected = Record{}
+		dst[key] = selected
+	}
+	return selectPath(selected, parent, path[1:])
+}
+
+// path returns the names of relationships leading to the field name of r, without the name of the sObject
+// qualifying it
+func (s *scope) path(r Record, name string) []string {
+	path := strings.Split(name, ".")
+	if len(path) == 1 {
+		return path
+	}
+	if key, _ := get(r, path[0]); key != "" {
+		return path
+	}
+	sObject := recordType(r)
+	if strings.EqualFold(path[0], s.query.From.Name) || strings.EqualFold(path[0], sObject) ||
+		(s.child && sObject == "") {
+		return path[1:]
+	}
+	return path
+}
+
+// lookup returns the value of field name of r, converted to one of the types values are compared as
+func (s *scope) lookup(r Record, name string) (interface{}, error) {
+	var value interface{} = r
+	for _, segment := range s.path(r, name) {
+		if isNil(value) {
+			return nil, nil
+		}
+		parent, ok := value.(Record)
+		if !ok {
+			return nil, ErrTypeMismatch
+		}
+		_, value = get(parent, segment)
+	}
+	return normalize(value)
+}
+
+// get returns the value of field name of r along with the name of the field as it is in r. The name is
+// empty if r does not have the field.
+func get(r Record, name string) (string, interface{}) {
+	if value, ok := r[name]; ok {
+		return name, value
+	}
+	for key, value := range r {
+		if strings.EqualFold(key, name) {
+			return key, value
+		}
+	}
+	return "", nil
+}
+
+// recordType returns the name of the sObject given in the attributes of r
+func recordType(r Record) string {
+	_, attributes := get(r, "attributes")
+	switch a := attributes.(type) {
+	case Record:
+		sObject, _ := a["type"].(string)
+		return sObject
+	case map[string]interface{}:
+		sObject, _ := a["type"].(string)
+		return sObject
+	case map[string]string:
+		return a["type"]
+	}
+	return ""
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	reflectedValue := reflect.ValueOf(value)
+	return reflectedValue.Kind() == reflect.Ptr && reflectedValue.IsNil()
+}
+
+// normalize returns value as nil, string, float64, bool or time.Time, or as []string for multi-select
+// picklists, Record or []Record
+func normalize(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, string, float64, bool, time.Time, []string, Record, []Record:
+		return value, nil
+	}
+	reflectedValue := reflect.ValueOf(value)
+	switch reflectedValue.Kind() {
+	case reflect.Ptr:
+		if reflectedValue.IsNil() {
+			return nil, nil
+		}
+		return normalize(reflectedValue.Elem().Interface())
+	case reflect.String:
+		return reflectedValue.String(), nil
+	case reflect.Bool:
+		return reflectedValue.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(reflectedValue.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(reflectedValue.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return reflectedValue.Float(), nil
+	}
+	return nil, ErrTypeMismatch
+}
+
+// match reports whether r matches the condition expr
+func (s *scope) match(r Record, expr soql.Expr) (bool, error) {
+	switch e := expr.(type) {
+	case *soql.LogicalExpr:
+		for _, operand := range e.Operands {
+			ok, err := s.match(r, operand)
+			if err != nil {
+				return false, err
+			}
+			if ok != (e.Operator == "AND") {
+				return ok, nil
+			}
+		}
+		return e.Operator == "AND", nil
+	case *soql.NotExpr:
+		ok, err := s.match(r, e.Operand)
+		return !ok, err
+	case *soql.ParenExpr:
+		return s.match(r, e.Inner)
+	case *soql.ComparisonExpr:
+		field, ok := e.Field.(*soql.FieldRef)
+		if !ok {
+			return false, fmt.Errorf("%s: %w", e.Field, ErrUnsupported)
+		}
+		ok, err := s.compare(r, field.Name, e)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", field.Name, err)
+		}
+		return ok, nil
+	}
+	return false, ErrUnsupported
+}
+
+// compare evaluates the comparison e of field name of r
+func (s *scope) compare(r Record, name string, e *soql.ComparisonExpr) (bool, error) {
+	value, err := s.lookup(r, name)
+	if err != nil {
+		return false, err
+	}
+	switch e.Operator {
+	case "LIKE":
+		return like(value, e.Value.(*soql.StringLiteral).Raw)
+	case "IN", "NOT IN":
+		values, err := s.values(e.Value)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range values {
+			equal, err := compareOperator("=", value, item)
+			if err != nil {
+				return false, err
+			}
+			if equal {
+				return e.Operator == "IN", nil
+			}
+		}
+		return e.Operator == "NOT IN", nil
+	case "INCLUDES", "EXCLUDES":
+		ok, err := includes(value, e.Value)
+		return ok == (e.Operator == "INCLUDES"), err
+	}
+	literal, err := s.literal(e.Value)
+	if err != nil {
+		return false, err
+	}
+	return compareOperator(e.Operator, value, literal)
+}
+
+// values returns the values of the list or the subquery of IN and NOT IN conditions
+func (s *scope) values(v soql.Value) ([]interface{}, error) {
+	switch list := v.(type) {
+	case *soql.ListValue:
+		values := make([]interface{}, 0, len(list.Values))
+		for _, item := range list.Values {
+			value, err := s.literal(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case *soql.SubqueryValue:
+		if values, ok := s.joins[list]; ok {
+			return values, nil
+		}
+		if len(list.Query.Fields) != 1 {
+			return nil, ErrUnsupported
+		}
+		field, ok := list.Query.Fields[0].(*soql.FieldRef)
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		t, ok := s.db.objects[strings.ToLower(list.Query.From.Name)]
+		if !ok {
+			return nil, &sObjectError{name: list.Query.From.Name}
+		}
+		join := &scope{db: s.db, query: list.Query, joins: s.joins}
+		records, err := join.run(t.records)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, len(records))
+		for _, r := range records {
+			value, err := join.lookup(r, field.Name)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		s.joins[list] = values
+		return values, nil
+	}
+	literal, err := s.literal(v)
+	return []interface{}{literal}, err
+}
+
+// period is the range of times between start, inclusive, and end, exclusive, that dates and date literals
+// stand for
+type period struct {
+	start time.Time
+	end   time.Time
+}
+
+// literal returns v as nil, string, float64, bool, time.Time or period
+func (s *scope) literal(v soql.Value) (interface{}, error) {
+	switch l := v.(type) {
+	case *soql.NullLiteral:
+		return nil, nil
+	case *soql.StringLiteral:
+		return l.Value(), nil
+	case *soql.NumberLiteral:
+		return strconv.ParseFloat(l.Raw, 64)
+	case *soql.BooleanLiteral:
+		return l.Value, nil
+	case *soql.DateTimeLiteral:
+		t, err := l.Time()
+		if err != nil {
+			return nil, err
+		}
+		if len(l.Raw) == len(dateFormat) {
+			return period{start: t, end: t.AddDate(0, 0, 1)}, nil
+		}
+		return t, nil
+	case *soql.RelativeDateLiteral:
+		return relativePeriod(l, s.db.now())
+	}
+	return nil, ErrUnsupported
+}
+
+// compareOperator evaluates value operator literal. Null is equal only to null, so it is not equal to any
+// other value and neither less nor greater than any value.
+func compareOperator(operator string, value, literal interface{}) (bool, error) {
+	if value == nil || literal == nil {
+		switch operator {
+		case "=":
+			return value == literal, nil
+		case "!=", "<>":
+			return value != literal, nil
+		}
+		return false, nil
+	}
+	c, err := compareValues(value, literal)
+	if err != nil {
+		return false, err
+	}
+	switch operator {
+	case "=":
+		return c == 0, nil
+	case "!=", "<>":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return false, ErrUnsupported
+}
+
+const dateFormat = "2006-01-02"
+
+// timeFormats lists the formats of strings compared with dates, same as the ones soql.Unmarshal accepts
+var timeFormats = []string{soql.DateTimeFormat, time.RFC3339Nano, dateFormat}
+
+// compareValues returns -1, 0 or 1 as value is less than, equal to or greater than literal, which is not nil.
+// Strings are compared case insensitively. A time is equal to a period it falls in.
+func compareValues(value, literal interface{}) (int, error) {
+	switch l := literal.(type) {
+	case string:
+		if v, ok := value.(string); ok {
+			return strings.Compare(strings.ToLower(v), strings.ToLower(l)), nil
+		}
+	case float64:
+		if v, ok := value.(float64); ok {
+			return compareOrdered(v, l), nil
+		}
+	case bool:
+		if v, ok := value.(bool); ok {
+			return compareOrdered(boolRank(v), boolRank(l)), nil
+		}
+	case time.Time:
+		if v, ok := toTime(value); ok {
+			if v.Before(l) {
+				return -1, nil
+			}
+			if v.After(l) {
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case period:
+		if v, ok := toTime(value); ok {
+			if v.Before(l.start) {
+				return -1, nil
+			}
+			if v.Before(l.end) {
+				return 0, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, ErrTypeMismatch
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolRank(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// toTime returns value as time. Strings are parsed in the formats of dates and dateTimes.
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, format := range timeFormats {
+			if t, err := time.Parse(format, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// like reports whether value matches the LIKE pattern raw, which is the pattern as it is written in the query.
+// % matches any number of characters and _ a single one unless they are escaped with backslash. Matching is
+// case insensitive and null does not match any pattern.
+func like(value interface{}, raw string) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return false, ErrTypeMismatch
+	}
+	var buff strings.Builder
+	buff.WriteString("(?is)^")
+	for indx := 0; indx < len(raw); indx++ {
+		switch c := raw[indx]; {
+		case c == '\\' && indx+1 < len(raw):
+			indx++
+			buff.WriteString(regexp.QuoteMeta(unescape(raw[indx])))
+		case c == '%':
+			buff.WriteString(".*")
+		case c == '_':
+			buff.WriteString(".")
+		default:
+			buff.WriteString(regexp.QuoteMeta(raw[indx : indx+1]))
+		}
+	}
+	buff.WriteString("$")
+	pattern, err := regexp.Compile(buff.String())
+	if err != nil {
+		return false, err
+	}
+	return pattern.MatchString(text), nil
+}
+
+// unescape returns the character escaped with backslash as c
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case 'b':
+		return "\b"
+	case 'f':
+		return "\f"
+	}
+	return string(c)
+}
+
+// includes reports whether multi-select picklist value includes any of the values of list. A value of the
+// list selects several values when they are separated by semicolons, in which case all of them have to be
+// included. Null does not include any value.
+func includes(value interface{}, list soql.Value) (bool, error) {
+	var selected []string
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case string:
+		selected = strings.Split(v, ";")
+	case []string:
+		selected = v
+	default:
+		return false, ErrTypeMismatch
+	}
+	set := make(map[string]bool, len(selected))
+	for _, item := range selected {
+		set[strings.ToLower(strings.TrimSpace(item))] = true
+	}
+	items, ok := list.(*soql.ListValue)
+	if !ok {
+		return false, ErrUnsupported
+	}
+	for _, item := range items.Values {
+		literal, ok := item.(*soql.StringLiteral)
+		if !ok {
+			return false, ErrTypeMismatch
+		}
+		all := true
+		for _, part := range strings.Split(literal.Value(), ";") {
+			all = all && set[strings.ToLower(strings.TrimSpace(part))]
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sort sorts records by the fields of ORDER BY. Nulls are first unless NULLS LAST is given.
+func (s *scope) sort(records []Record) error {
+	items := s.query.OrderBy
+	if len(items) == 0 {
+		return nil
+	}
+	type sortable struct {
+		record Record
+		values []interface{}
+	}
+	sorted := make([]sortable, 0, len(records))
+	for _, r := range records {
+		values := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			field, ok := item.Field.(*soql.FieldRef)
+			if !ok {
+				return fmt.Errorf("%s: %w", item.Field, ErrUnsupported)
+			}
+			value, err := s.lookup(r, field.Name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", field.Name, err)
+			}
+			values = append(values, value)
+		}
+		sorted = append(sorted, sortable{record: r, values: values})
+	}
+	var err error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for indx, item := range items {
+			a, b := sorted[i].values[indx], sorted[j].values[indx]
+			switch {
+			case a == nil && b == nil:
+				continue
+			case a == nil || b == nil:
+				// nulls are placed regardless of the direction
+				return (a == nil) != (item.Nulls == "LAST")
+			}
+			c, cmpErr := compareValues(a, b)
+			if cmpErr != nil {
+				err = fmt.Errorf("%s: %w", item.Field, cmpErr)
+				return false
+			}
+			if item.Direction == "DESC" {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	for indx := range sorted {
+		records[indx] = sorted[indx].record
+	}
+	return err
+}
+
+func (db *DB) now() time.Time {
+	if db.Now != nil {
+		return db.Now()
+	}
+	return time.Now()
+}
+
+// unit is the unit of time of date literals
+type unit int
+
+const (
+	day unit = iota
+	week
+	month
+	quarter
+	year
+)
+
+// units maps the names of units in date literals to the units
+var units = map[string]unit{
+	"DAY":             day,
+	"DAYS":            day,
+	"WEEK":            week,
+	"WEEKS":           week,
+	"MONTH":           month,
+	"MONTHS":          month,
+	"QUARTER":         quarter,
+	"QUARTERS":        quarter,
+	"FISCAL_QUARTER":  quarter,
+	"FISCAL_QUARTERS": quarter,
+	"YEAR":            year,
+	"YEARS":           year,
+	"FISCAL_YEAR":     year,
+	"FISCAL_YEARS":    year,
+}
+
+// start returns the start of the unit now is in
+func (u unit) start(now time.Time) time.Time {
+	y, m, d := now.Date()
+	switch u {
+	case week:
+		return time.Date(y, m, d-int(now.Weekday()), 0, 0, 0, 0, now.Location())
+	case month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	case quarter:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, now.Location())
+	case year:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+// add returns t moved by n units
+func (u unit) add(t time.Time, n int) time.Time {
+	switch u {
+	case week:
+		return t.AddDate(0, 0, 7*n)
+	case month:
+		return t.AddDate(0, n, 0)
+	case quarter:
+		return t.AddDate(0, 3*n, 0)
+	case year:
+		return t.AddDate(n, 0, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// relativePeriod returns the period date literal l stands for at now. The periods are counted in units from
+// the start of the current one, e.g. LAST_N_WEEKS:2 is from -2 to 0 weeks and LAST_N_DAYS:2 and LAST_90_DAYS
+// include the current day.
+func relativePeriod(l *soql.RelativeDateLiteral, now time.Time) (period, error) {
+	name := strings.ToUpper(l.Name)
+	n := 0
+	if l.N != nil {
+		n = *l.N
+	}
+	var u unit
+	var from, to int
+	switch name {
+	case "YESTERDAY":
+		u, from, to = day, -1, 0
+	case "TODAY":
+		u, from, to = day, 0, 1
+	case "TOMORROW":
+		u, from, to = day, 1, 2
+	case "LAST_90_DAYS":
+		u, from, to = day, -90, 1
+	case "NEXT_90_DAYS":
+		u, from, to = day, 1, 91
+	default:
+		var ok bool
+		switch {
+		case strings.HasPrefix(name, "LAST_N_"):
+			u, ok = units[strings.TrimPrefix(name, "LAST_N_")]
+			from, to = -n, 0
+			if u == day {
+				to = 1
+			}
+		case strings.HasPrefix(name, "NEXT_N_"):
+			u, ok = units[strings.TrimPrefix(name, "NEXT_N_")]
+			from, to = 1, n+1
+		case strings.HasPrefix(name, "N_") && strings.HasSuffix(name, "_AGO"):
+			u, ok = units[strings.TrimSuffix(strings.TrimPrefix(name, "N_"), "_AGO")]
+			from, to = -n, -n+1
+		case strings.HasPrefix(name, "LAST_"):
+			u, ok = units[strings.TrimPrefix(name, "LAST_")]
+			from, to = -1, 0
+		case strings.HasPrefix(name, "THIS_"):
+			u, ok = units[strings.TrimPrefix(name, "THIS_")]
+			from, to = 0, 1
+		case strings.HasPrefix(name, "NEXT_"):
+			u, ok = units[strings.TrimPrefix(name, "NEXT_")]
+			from, to = 1, 2
+		}
+		if !ok {
+			return period{}, fmt.Errorf("%s: %w", l.Name, ErrUnsupported)
+		}
+	}
+	start := u.start(now)
+	return period{start: u.add(start, from), end: u.add(start, to)}, nil
+}
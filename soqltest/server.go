@@ -0,0 +1,277 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soqltest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+// DefaultBatchSize is the number of records in a page of the response of Server unless BatchSize is set,
+// same as Salesforce uses by default
+const DefaultBatchSize = 2000
+
+// Error is the error Server responds with, written in the format of Salesforce REST API errors, i.e.
+// [{"errorCode":"INVALID_FIELD","message":"..."}]
+type Error struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int `json:"-"`
+	// ErrorCode is the Salesforce error code, e.g. MALFORMED_QUERY
+	ErrorCode string `json:"errorCode"`
+	// Message is the error message
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.ErrorCode, e.Message)
+}
+
+// MalformedQuery returns the error Salesforce responds with to queries that are not valid SOQL
+func MalformedQuery(message string) *Error {
+	return &Error{StatusCode: http.StatusBadRequest, ErrorCode: "MALFORMED_QUERY", Message: message}
+}
+
+// InvalidField returns the error Salesforce responds with to queries using field that sObject does not have
+func InvalidField(field, sObject string) *Error {
+	return &Error{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "INVALID_FIELD",
+		Message: fmt.Sprintf("No such column '%s' on entity '%s'. If you are attempting to use a custom field, "+
+			"be sure to append the '__c' after the custom field name. Please reference your WSDL or the describe "+
+			"call for the appropriate names.", field, sObject),
+	}
+}
+
+// QueryTimeout returns the error Salesforce responds with to queries that run for too long
+func QueryTimeout() *Error {
+	return &Error{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "QUERY_TIMEOUT",
+		Message:    "Your query request was running for too long.",
+	}
+}
+
+// invalidType returns the error Salesforce responds with to queries of sObjects that do not exist
+func invalidType(sObject string) *Error {
+	return &Error{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  "INVALID_TYPE",
+		Message: fmt.Sprintf("sObject type '%s' is not supported. If you are attempting to use a custom object, "+
+			"be sure to append the '__c' after the entity name. Please reference your WSDL or the describe call "+
+			"for the appropriate names.", sObject),
+	}
+}
+
+// Server is a fake of the query and queryAll resources of Salesforce REST API that evaluates the queries it
+// receives against the records of DB, e.g. to test code executing queries with the client package without
+// network access:
+//
+//	server := soqltest.NewServer(db)
+//	defer server.Close()
+//	c := client.New(server.Client(), server.URL, client.StaticToken("token"))
+//
+// Responses are split into pages of BatchSize records, or of the batchSize given in Sforce-Query-Options
+// header, which are read by following nextRecordsUrl. Queries that can not be evaluated are responded with
+// the errors Salesforce returns, e.g. MALFORMED_QUERY for syntax errors or INVALID_TYPE for unknown sObjects.
+// Other errors, e.g. INVALID_FIELD or QUERY_TIMEOUT, are responded with after calling Fail.
+// Requests without access token in Authorization header are responded with INVALID_SESSION_ID.
+type Server struct {
+	*httptest.Server
+	db *DB
+	// BatchSize is the maximum number of records in a page. DefaultBatchSize is used if it is not positive.
+	// It should be set before sending requests.
+	BatchSize int
+
+	mutex   sync.Mutex
+	queries []string
+	fails   []*Error
+	cursors map[string][]interface{}
+	next    int
+}
+
+// NewServer starts and returns a Server querying db. The caller should call Close when finished.
+func NewServer(db *DB) *Server {
+	s := &Server{db: db, cursors: map[string][]interface{}{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Queries returns the SOQL queries received, in the order they were received
+func (s *Server) Queries() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+// Fail makes the server respond to the next requests with errs, one error per request, before it returns
+// records again. Requests for the next pages of responses fail too. It panics if any of errs is nil.
+func (s *Server) Fail(errs ...*Error) {
+	for _, err := range errs {
+		if err == nil {
+			panic("soqltest: Fail called with nil error")
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fails = append(s.fails, errs...)
+}
+
+// resourcePath matches the paths of query and queryAll resources and of the next pages of their responses
+var resourcePath = regexp.MustCompile(`^/services/data/(v\d+\.\d+)/(query|queryAll)(?:/([^/]+)-(\d+))?$`)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	matches := resourcePath.FindStringSubmatch(r.URL.Path)
+	switch {
+	case matches == nil:
+		writeError(w, &Error{
+			StatusCode: http.StatusNotFound,
+			ErrorCode:  "NOT_FOUND",
+			Message:    "The requested resource does not exist",
+		})
+		return
+	case r.Method != http.MethodGet:
+		writeError(w, &Error{
+			StatusCode: http.StatusMethodNotAllowed,
+			ErrorCode:  "METHOD_NOT_ALLOWED",
+			Message:    fmt.Sprintf("HTTP Method '%s' not allowed. Allowed are GET,HEAD", r.Method),
+		})
+		return
+	case !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") ||
+		strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") == "":
+		writeError(w, &Error{
+			StatusCode: http.StatusUnauthorized,
+			ErrorCode:  "INVALID_SESSION_ID",
+			Message:    "Session expired or invalid",
+		})
+		return
+	}
+	version, resource, cursor, offset := matches[1], matches[2], matches[3], matches[4]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.fails) > 0 {
+		err := s.fails[0]
+		s.fails = s.fails[1:]
+		writeError(w, err)
+		return
+	}
+	var records []interface{}
+	start := 0
+	if cursor == "" {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			writeError(w, MalformedQuery("A query string has to be specified"))
+			return
+		}
+		s.queries = append(s.queries, query)
+		var err error
+		if records, err = s.evaluate(query); err != nil {
+			writeError(w, toError(err))
+			return
+		}
+	} else {
+		var ok bool
+		records, ok = s.cursors[cursor]
+		start, _ = strconv.Atoi(offset)
+		if !ok || start > len(records) {
+			writeError(w, &Error{
+				StatusCode: http.StatusBadRequest,
+				ErrorCode:  "INVALID_QUERY_LOCATOR",
+				Message:    "invalid query locator",
+			})
+			return
+		}
+	}
+	end := start + s.batchSize(r)
+	page := map[string]interface{}{
+		"totalSize": len(records),
+		"done":      end >= len(records),
+	}
+	if end >= len(records) {
+		end = len(records)
+		delete(s.cursors, cursor)
+	} else {
+		if cursor == "" {
+			s.next++
+			cursor = fmt.Sprintf("01gD0000002HU%05dAAA", s.next)
+			s.cursors[cursor] = records
+		}
+		page["nextRecordsUrl"] = fmt.Sprintf("/services/data/%s/%s/%s-%d", version, resource, cursor, end)
+	}
+	page["records"] = records[start:end]
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// evaluate returns the records matching query as they are written in responses
+func (s *Server) evaluate(query string) ([]interface{}, error) {
+	q, err := soql.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	s.db.mutex.RLock()
+	defer s.db.mutex.RUnlock()
+	sObject, records, err := s.db.execute(q)
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		encoded = append(encoded, encodeRecord(sObject, r))
+	}
+	return encoded, nil
+}
+
+// batchSize returns the number of records in a page of the response to r
+func (s *Server) batchSize(r *http.Request) int {
+	for _, option := range strings.Split(r.Header.Get("Sforce-Query-Options"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(option), "=", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "batchSize") {
+			continue
+		}
+		if size, err := strconv.Atoi(parts[1]); err == nil && size > 0 {
+			return size
+		}
+	}
+	if s.BatchSize > 0 {
+		return s.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// toError returns the error Salesforce responds with when it fails to evaluate a query with err
+func toError(err error) *Error {
+	var syntaxErr *soql.SyntaxError
+	var sObjectErr *sObjectError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return MalformedQuery(syntaxErr.Error())
+	case errors.As(err, &sObjectErr):
+		return invalidType(sObjectErr.name)
+	case errors.Is(err, ErrTypeMismatch):
+		return &Error{StatusCode: http.StatusBadRequest, ErrorCode: "INVALID_QUERY_FILTER_OPERATOR", Message: err.Error()}
+	case errors.Is(err, ErrUnsupported):
+		return &Error{StatusCode: http.StatusNotImplemented, ErrorCode: "NOT_IMPLEMENTED", Message: err.Error()}
+	}
+	return MalformedQuery(err.Error())
+}
+
+func writeError(w http.ResponseWriter, err *Error) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(err.StatusCode)
+	json.NewEncoder(w).Encode([]*Error{err})
+}
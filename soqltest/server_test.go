@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soqltest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+	"github.com/forcedotcom/go-soql/client"
+	"github.com/forcedotcom/go-soql/soqltest"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		server *soqltest.Server
+		c      *client.Client
+		query  hostQuery
+		err    error
+	)
+
+	BeforeEach(func() {
+		server = soqltest.NewServer(newDB())
+		c = client.New(server.Client(), server.URL, client.StaticToken("token"))
+		query = hostQuery{WhereClause: hostCriteria{Patterns: []string{"db-1", "db-2", "app_1"}}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// get sends request for path with header and returns the response
+	get := func(path string, header http.Header) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header = header
+		resp, err := server.Client().Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return resp, body
+	}
+
+	Describe("query resource", func() {
+		It("responds with the matching records and records the query", func() {
+			err = c.Query(context.Background(), &query, &query)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query.SelectClause).To(HaveLen(3))
+			Expect(query.SelectClause[0].Name).To(Equal("db-1"))
+			Expect(query.SelectClause[0].RoleName).To(Equal("db"))
+			Expect(query.SelectClause[0].Versions.SelectClause).To(HaveLen(2))
+			Expect(server.Queries()).To(Equal([]string{
+				"SELECT Id,Name,Role__r.Name,Num_of_CPU_Cores__c,Last_Discovered_Date__c," +
+					"(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r) " +
+					"FROM SM_Logical_Host__c WHERE (Name LIKE '%db-1%' OR Name LIKE '%db-2%' OR Name LIKE '%app\\_1%')",
+			}))
+		})
+
+		Context("when there are more records than BatchSize", func() {
+			BeforeEach(func() {
+				server.BatchSize = 2
+			})
+
+			It("responds with pages linked by nextRecordsUrl", func() {
+				it, err := c.Iterate(context.Background(), "SELECT Id FROM SM_Logical_Host__c")
+				Expect(err).ToNot(HaveOccurred())
+				var pages [][]string
+				for it.Next() {
+					var page struct {
+						NextRecordsURL string `json:"nextRecordsUrl"`
+					}
+					Expect(json.Unmarshal(it.Page(), &page)).To(Succeed())
+					var hosts []host
+					Expect(it.Decode(&hosts)).To(Succeed())
+					ids := []string{page.NextRecordsURL}
+					for _, h := range hosts {
+						ids = append(ids, h.ID)
+					}
+					pages = append(pages, ids)
+				}
+				Expect(it.Err()).ToNot(HaveOccurred())
+				Expect(it.TotalSize).To(Equal(5))
+				Expect(pages).To(Equal([][]string{
+					{"/services/data/v58.0/query/01gD0000002HU00001AAA-2", "a01", "a02"},
+					{"/services/data/v58.0/query/01gD0000002HU00001AAA-4", "a03", "a04"},
+					{"", "a05"},
+				}))
+				Expect(server.Queries()).To(HaveLen(1))
+			})
+
+			It("uses batchSize of Sforce-Query-Options header", func() {
+				resp, body := get("/services/data/v58.0/queryAll?q="+url.QueryEscape("SELECT Id FROM SM_Logical_Host__c"),
+					http.Header{"Authorization": {"Bearer token"}, "Sforce-Query-Options": {"batchSize=3"}})
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				var hosts []host
+				Expect(soql.Unmarshal(body, &hosts)).To(Succeed())
+				Expect(hosts).To(HaveLen(3))
+				Expect(string(body)).To(ContainSubstring(`"nextRecordsUrl":"/services/data/v58.0/queryAll/01gD0000002HU00001AAA-3"`))
+				Expect(string(body)).To(ContainSubstring(`"attributes":{"type":"SM_Logical_Host__c"}`))
+			})
+		})
+
+		Context("when query cannot be evaluated", func() {
+			It("responds with MALFORMED_QUERY for syntax errors", func() {
+				err = c.Query(context.Background(), "SELECT FROM SM_Logical_Host__c", &query)
+				var sfErr *client.Error
+				Expect(errors.As(err, &sfErr)).To(BeTrue())
+				Expect(sfErr.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(sfErr.ErrorCode).To(Equal("MALFORMED_QUERY"))
+				Expect(sfErr.Message).To(HavePrefix("syntax error at line 1, column 13: expected FROM"))
+				Expect(server.Queries()).To(Equal([]string{"SELECT FROM SM_Logical_Host__c"}))
+			})
+
+			It("responds with INVALID_TYPE for unknown sObjects", func() {
+				err = c.Query(context.Background(), "SELECT Id FROM SM_Host__c", &query)
+				var sfErr *client.Error
+				Expect(errors.As(err, &sfErr)).To(BeTrue())
+				Expect(sfErr.ErrorCode).To(Equal("INVALID_TYPE"))
+				Expect(sfErr.Message).To(HavePrefix("sObject type 'SM_Host__c' is not supported."))
+			})
+		})
+
+		Context("when Fail is called", func() {
+			BeforeEach(func() {
+				server.Fail(soqltest.InvalidField("Nmae", "SM_Logical_Host__c"), soqltest.QueryTimeout())
+			})
+
+			It("responds with the errors before records", func() {
+				err = c.Query(context.Background(), &query, &query)
+				var sfErr *client.Error
+				Expect(errors.As(err, &sfErr)).To(BeTrue())
+				Expect(sfErr.ErrorCode).To(Equal("INVALID_FIELD"))
+				Expect(sfErr.Message).To(HavePrefix("No such column 'Nmae' on entity 'SM_Logical_Host__c'."))
+
+				err = c.Query(context.Background(), &query, &query)
+				Expect(errors.As(err, &sfErr)).To(BeTrue())
+				Expect(sfErr.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(sfErr.ErrorCode).To(Equal("QUERY_TIMEOUT"))
+
+				err = c.Query(context.Background(), &query, &query)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(query.SelectClause).To(HaveLen(3))
+			})
+		})
+
+		Context("when Fail is called with nil error", func() {
+			It("panics without failing the next requests", func() {
+				Expect(func() { server.Fail(soqltest.QueryTimeout(), nil) }).To(Panic())
+				err = c.Query(context.Background(), &query, &query)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(query.SelectClause).To(HaveLen(3))
+			})
+		})
+
+		It("responds with INVALID_SESSION_ID without access token", func() {
+			resp, body := get("/services/data/v58.0/query?q=SELECT+Id+FROM+SM_Logical_Host__c", http.Header{})
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(string(body)).To(MatchJSON(`[{"errorCode":"INVALID_SESSION_ID","message":"Session expired or invalid"}]`))
+			Expect(server.Queries()).To(BeEmpty())
+		})
+
+		It("responds with NOT_FOUND for other resources", func() {
+			resp, body := get("/services/data/v58.0/sobjects", http.Header{"Authorization": {"Bearer token"}})
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(string(body)).To(MatchJSON(`[{"errorCode":"NOT_FOUND","message":"The requested resource does not exist"}]`))
+		})
+	})
+})
@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+
+// Package soqltest evaluates SOQL queries, either generated by soql.Marshal or written by hand, against records
+// held in memory, so tests can assert the records a query returns without a Salesforce org.
+package soqltest
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+var (
+	// ErrUnsupported is returned for queries using features that are not evaluated, i.e. GROUP BY, HAVING,
+	// USING SCOPE other than everything and functions other than FIELDS() like COUNT()
+	ErrUnsupported = errors.New("ErrUnsupported")
+	// ErrTypeMismatch is returned when the value of a field can not be compared with the value in the query,
+	// e.g. a string field with a number, or when a field is used as relationship but is not a Record
+	ErrTypeMismatch = errors.New("ErrTypeMismatch")
+)
+
+// sObjectError is returned for queries of sObjects no records were inserted for
+type sObjectError struct {
+	name string
+}
+
+func (e *sObjectError) Error() string {
+	return e.name + ": " + soql.ErrUnknownSObject.Error()
+}
+
+func (e *sObjectError) Unwrap() error {
+	return soql.ErrUnknownSObject
+}
+
+// Record is a record of an sObject. Keys are the names of the fields, which are matched case insensitively
+// like Salesforce does. Values are nil, strings, bools, numbers or time.Time, or pointers to them. Values of
+// multi-select picklists are strings separated by semicolons or []string.
+// Parent relationships, e.g. Role__r, are Record values and child relationships, e.g. Application_Versions__r,
+// are []Record values:
+//
+//	soqltest.Record{
+//		"Id":      "a01",
+//		"Name":    "db-1",
+//		"Role__r": soqltest.Record{"Name": "db"},
+//		"Application_Versions__r": []soqltest.Record{
+//			{"Version__c": "1.0"},
+//		},
+//	}
+//
+// Fields missing in a record are null. Fields in queries may be qualified with the name of the sObject, e.g.
+// SM_Application_Versions__c.Version__c, the way Marshal writes the columns of child relationships. The
+// sObject of a record inserted with Insert is known. Records of relationships may give theirs in the type
+// of attributes, as in the responses of Salesforce REST API, e.g. "attributes": soqltest.Record{"type": "X"}.
+// Any name that is not a field qualifies the fields of child records without type, so a null parent
+// relationship of such records should be set to nil rather than left out.
+type Record map[string]interface{}
+
+// DB holds the records of sObjects queried with Records and Query. It is safe for concurrent use.
+type DB struct {
+	mutex   sync.RWMutex
+	objects map[string]*table
+	// Now returns the time at which date literals like TODAY or LAST_N_DAYS:7 are evaluated, in the time
+	// zone the days start in. time.Now is used if it is nil. Weeks start on Sunday and fiscal years are the
+	// calendar years.
+	Now func() time.Time
+}
+
+// table holds the records of an sObject
+type table struct {
+	name    string
+	records []Record
+}
+
+// New returns an empty DB
+func New() *DB {
+	return &DB{objects: map[string]*table{}}
+}
+
+// Insert adds records of sObject. Querying an sObject no records were inserted for fails with
+// soql.ErrUnknownSObject, so Insert can be called without records to declare an empty sObject.
+func (db *DB) Insert(sObject string, records ...Record) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	key := strings.ToLower(sObject)
+	t, ok := db.objects[key]
+	if !ok {
+		t = &table{name: sObject}
+		db.objects[key] = t
+	}
+	t.records = append(t.records, records...)
+}
+
+// Records evaluates query and returns the matching records with the selected fields. query is either the
+// soql struct passed to soql.Marshal, a SOQL query as string or a *soql.Query returned by soql.Parse.
+// Selected fields of parent relationships are returned as Record values, e.g. Role__r.Name as
+// "Role__r": soqltest.Record{"Name": "db"}, and child subqueries as []Record values. A null parent
+// relationship or a subquery without records is nil, as Salesforce returns them. TYPEOF selects the fields of
+// the WHEN for the sObject in the attributes of the related record, or of ELSE if there is none. FIELDS(ALL)
+// selects all fields of the records but relationships, FIELDS(CUSTOM) the ones ending with __c and
+// FIELDS(STANDARD) the others.
+// WITH SECURITY_ENFORCED and other security modes are ignored, since records have no sharing or field level
+// security, and so is the FOR clause, since records are neither locked nor tracked as viewed.
+// hosts, err := db.Records("SELECT Name FROM SM_Logical_Host__c WHERE Name LIKE 'db%' ORDER BY Name LIMIT 2")
+func (db *DB) Records(query interface{}) ([]Record, error) {
+	_, records, err := db.evaluate(query)
+	return records, err
+}
+
+// Query evaluates query and decodes the matching records into records with soql.Unmarshal. records can be
+// anything soql.Unmarshal decodes into, including the soql struct itself:
+// err := db.Query(&soqlStruct, &soqlStruct)
+func (db *DB) Query(query interface{}, records interface{}) error {
+	data, err := db.response(query)
+	if err != nil {
+		return err
+	}
+	return soql.Unmarshal(data, records)
+}
+
+// response returns the response of Salesforce REST query resource for the records matching query
+func (db *DB) response(query interface{}) ([]byte, error) {
+	sObject, records, err := db.evaluate(query)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encodeResult(sObject, records))
+}
+
+// evaluate returns the name of the sObject query selects from along with the matching records
+func (db *DB) evaluate(query interface{}) (string, []Record, error) {
+	q, err := parse(query)
+	if err != nil {
+		return "", nil, err
+	}
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	return db.execute(q)
+}
+
+// parse returns the syntax tree of query, which is a soql struct, a string or a *soql.Query
+func parse(query interface{}) (*soql.Query, error) {
+	switch q := query.(type) {
+	case *soql.Query:
+		return q, nil
+	case string:
+		return soql.Parse(q)
+	}
+	s, err := soql.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	return soql.Parse(s)
+}
+
+// execute evaluates q against the records of the sObject it selects from. db must be locked.
+func (db *DB) execute(q *soql.Query) (string, []Record, error) {
+	t, ok := db.objects[strings.ToLower(q.From.Name)]
+	if !ok {
+		return "", nil, &sObjectError{name: q.From.Name}
+	}
+	s := &scope{db: db, query: q, joins: map[*soql.SubqueryValue][]interface{}{}}
+	records, err := s.run(t.records)
+	return t.name, records, err
+}
+
+// encodeResult returns records as the response of Salesforce REST query resource
+func encodeResult(sObject string, records []Record) map[string]interface{} {
+	encoded := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		encoded = append(encoded, encodeRecord(sObject, r))
+	}
+	return map[string]interface{}{
+		"totalSize": len(records),
+		"done":      true,
+		"records":   encoded,
+	}
+}
+
+// encodeRecord returns r as it is returned by Salesforce REST API. Times are written in soql.DateTimeFormat,
+// multi-select picklists as strings separated by semicolons and child relationships as query results.
+func encodeRecord(sObject string, r Record) map[string]interface{} {
+	encoded := make(map[string]interface{}, len(r)+1)
+	if sObject != "" {
+		encoded["attributes"] = map[string]string{"type": sObject}
+	}
+	for key, value := range r {
+		switch v := value.(type) {
+		case Record:
+			encoded[key] = encodeRecord(recordType(v), v)
+		case []Record:
+			encoded[key] = encodeResult("", v)
+		case time.Time:
+			encoded[key] = v.Format(soql.DateTimeFormat)
+		case *time.Time:
+			if v != nil {
+				encoded[key] = v.Format(soql.DateTimeFormat)
+			} else {
+				encoded[key] = nil
+			}
+		case []string:
+			encoded[key] = strings.Join(v, ";")
+		default:
+			encoded[key] = value
+		}
+	}
+	return encoded
+}
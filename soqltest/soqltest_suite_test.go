@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soqltest_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+	"github.com/forcedotcom/go-soql/soqltest"
+)
+
+// now is Wednesday
+var now = time.Date(2024, time.March, 13, 10, 0, 0, 0, time.UTC)
+
+// ids returns the Ids of records
+func ids(records []soqltest.Record) []interface{} {
+	result := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		result = append(result, r["Id"])
+	}
+	return result
+}
+
+// newDB returns DB with hosts and the applications running on them
+func newDB() *soqltest.DB {
+	db := soqltest.New()
+	db.Now = func() time.Time { return now }
+	db.Insert("SM_Logical_Host__c",
+		soqltest.Record{
+			"Id":                      "a01",
+			"Name":                    "db-1",
+			"Role__r":                 soqltest.Record{"Name": "db"},
+			"Num_of_CPU_Cores__c":     8,
+			"Last_Discovered_Date__c": now.Add(-time.Hour),
+			"Tags__c":                 "linux;ssd",
+			"Application_Versions__r": []soqltest.Record{
+				{"Version__c": "1.0", "Current__c": false},
+				{"Version__c": "2.0", "Current__c": true},
+			},
+		},
+		soqltest.Record{
+			"Id":                      "a02",
+			"Name":                    "db-2",
+			"Role__r":                 soqltest.Record{"Name": "db"},
+			"Num_of_CPU_Cores__c":     16,
+			"Last_Discovered_Date__c": now.AddDate(0, 0, -1),
+			"Tags__c":                 "linux",
+		},
+		soqltest.Record{
+			"Id":                      "a03",
+			"Name":                    "app_1",
+			"Role__r":                 soqltest.Record{"Name": "app"},
+			"Num_of_CPU_Cores__c":     4,
+			"Last_Discovered_Date__c": nil,
+		},
+		soqltest.Record{
+			"Id":                      "a04",
+			"Name":                    "App%2",
+			"Num_of_CPU_Cores__c":     2.0,
+			"Last_Discovered_Date__c": "2024-03-03T10:00:00.000+0000",
+			"Tags__c":                 []string{"windows", "ssd"},
+		},
+		soqltest.Record{
+			"Id":                      "a05",
+			"Name":                    "appx1",
+			"Role__r":                 soqltest.Record{"Name": "app"},
+			"Num_of_CPU_Cores__c":     4,
+			"Last_Discovered_Date__c": time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			"Tags__c":                 "windows",
+		},
+	)
+	db.Insert("SM_Application_Host__c",
+		soqltest.Record{"Host__c": "a01", "Application__r": soqltest.Record{"Name": "billing"}},
+		soqltest.Record{"Host__c": "a03", "Application__r": soqltest.Record{"Name": "search"}},
+		soqltest.Record{"Host__c": "a03", "Application__r": soqltest.Record{"Name": "billing"}},
+	)
+	return db
+}
+
+func TestSoqltest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Soqltest Suite")
+}
+
+type hostQuery struct {
+	SelectClause  []host       `soql:"selectClause,tableName=SM_Logical_Host__c"`
+	WhereClause   hostCriteria `soql:"whereClause"`
+	OrderByClause []soql.Order `soql:"orderByClause"`
+	LimitClause   *int         `soql:"limitClause"`
+	OffsetClause  *int         `soql:"offsetClause"`
+}
+
+type host struct {
+	ID         string    `soql:"selectColumn,fieldName=Id"`
+	Name       string    `soql:"selectColumn,fieldName=Name"`
+	RoleName   string    `soql:"selectColumn,fieldName=Role__r.Name"`
+	Cores      int       `soql:"selectColumn,fieldName=Num_of_CPU_Cores__c"`
+	Discovered time.Time `soql:"selectColumn,fieldName=Last_Discovered_Date__c"`
+	Versions   versions  `soql:"selectChild,fieldName=Application_Versions__r"`
+}
+
+type versions struct {
+	SelectClause  []version       `soql:"selectClause,tableName=SM_Application_Versions__c"`
+	WhereClause   versionCriteria `soql:"whereClause"`
+	OrderByClause []soql.Order    `soql:"orderByClause"`
+}
+
+type version struct {
+	Version string `soql:"selectColumn,fieldName=Version__c"`
+}
+
+type versionCriteria struct {
+	Current *bool `soql:"equalsOperator,fieldName=Current__c"`
+}
+
+type hostCriteria struct {
+	Patterns   []string           `soql:"likeOperator,fieldName=Name"`
+	Excluded   []string           `soql:"notLikeOperator,fieldName=Name"`
+	Roles      []string           `soql:"inOperator,fieldName=Role__r.Name"`
+	NotRoles   []string           `soql:"notInOperator,fieldName=Role__r.Name"`
+	Tags       []string           `soql:"includesOperator,fieldName=Tags__c"`
+	NotTags    []string           `soql:"excludesOperator,fieldName=Tags__c"`
+	MinCores   *int               `soql:"greaterThanOrEqualsToOperator,fieldName=Num_of_CPU_Cores__c"`
+	Discovered soql.DateLiteral   `soql:"equalsOperator,fieldName=Last_Discovered_Date__c"`
+	Apps       *appQuery          `soql:"subquery,joiner=in,fieldName=Id"`
+	Either     *hostCriteriaGroup `soql:"subquery,joiner=or"`
+}
+
+type hostCriteriaGroup struct {
+	Names      []string `soql:"inOperator,fieldName=Name"`
+	Undetected *bool    `soql:"nullOperator,fieldName=Last_Discovered_Date__c"`
+}
+
+type appQuery struct {
+	SelectClause appHost         `soql:"selectClause,tableName=SM_Application_Host__c"`
+	WhereClause  appHostCriteria `soql:"whereClause"`
+}
+
+type appHost struct {
+	HostID string `soql:"selectColumn,fieldName=Host__c"`
+}
+
+type appHostCriteria struct {
+	Names []string `soql:"inOperator,fieldName=Application__r.Name"`
+}
+
+type taskQuery struct {
+	SelectClause []task `soql:"selectClause,tableName=Task"`
+}
+
+type task struct {
+	ID   string   `soql:"selectColumn,fieldName=Id"`
+	What taskWhat `soql:"selectTypeOf,fieldName=What"`
+}
+
+type taskWhat struct {
+	Account *taskAccount `soql:"selectWhen"`
+	Other   taskName     `soql:"selectElse"`
+}
+
+type taskAccount struct {
+	Industry string `soql:"selectColumn,fieldName=Industry"`
+}
+
+type taskName struct {
+	Name string `soql:"selectColumn,fieldName=Name"`
+}
@@ -0,0 +1,350 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soqltest_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+	"github.com/forcedotcom/go-soql/soqltest"
+)
+
+var _ = Describe("DB", func() {
+	var (
+		db      *soqltest.DB
+		records []soqltest.Record
+		err     error
+	)
+
+	BeforeEach(func() {
+		db = newDB()
+	})
+
+	Describe("Records", func() {
+		Context("when query is SOQL string", func() {
+			It("filters case insensitively and sorts", func() {
+				records, err = db.Records("SELECT Id FROM sm_logical_host__c WHERE role__r.name = 'DB' ORDER BY Num_of_CPU_Cores__c DESC")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]soqltest.Record{{"Id": "a02"}, {"Id": "a01"}}))
+			})
+
+			It("returns parent relationships as records and null parents as nil", func() {
+				records, err = db.Records("SELECT Name,Role__r.Name,Owner__c FROM SM_Logical_Host__c WHERE Id IN ('a01','a04')")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]soqltest.Record{
+					{"Name": "db-1", "Role__r": soqltest.Record{"Name": "db"}, "Owner__c": nil},
+					{"Name": "App%2", "Role__r": nil, "Owner__c": nil},
+				}))
+			})
+
+			It("compares null only with null", func() {
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Role__r.Name != 'db'")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a03", "a04", "a05"}))
+
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Role__r.Name < 'db'")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a03", "a05"}))
+
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Role__r.Name = null OR Last_Discovered_Date__c = null")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a03", "a04"}))
+			})
+
+			It("compares numbers of any type", func() {
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Num_of_CPU_Cores__c > 2 AND Num_of_CPU_Cores__c <= 8.5")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a01", "a03", "a05"}))
+			})
+
+			It("matches LIKE wildcards unless they are escaped", func() {
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Name LIKE 'APP_1'")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a03", "a05"}))
+
+				records, err = db.Records(`SELECT Id FROM SM_Logical_Host__c WHERE Name LIKE 'app\_%' OR Name LIKE '%\%%'`)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a03", "a04"}))
+			})
+
+			It("evaluates date literals at Now", func() {
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Last_Discovered_Date__c = LAST_N_DAYS:7")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a01", "a02"}))
+
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Last_Discovered_Date__c < THIS_WEEK")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a04", "a05"}))
+
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Last_Discovered_Date__c IN (YESTERDAY, LAST_YEAR, 2024-01-01)")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a02", "a05"}))
+
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Last_Discovered_Date__c > 2024-03-03T09:00:00Z")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a01", "a02", "a04"}))
+			})
+
+			It("sorts nulls first unless NULLS LAST is given", func() {
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c ORDER BY Role__r.Name, Name DESC")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a04", "a05", "a03", "a02", "a01"}))
+
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c ORDER BY Role__r.Name DESC NULLS LAST, Id LIMIT 10 OFFSET 2")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a03", "a05", "a04"}))
+			})
+
+			It("evaluates child subqueries", func() {
+				records, err = db.Records("SELECT Id,(SELECT Version__c FROM Application_Versions__r ORDER BY Version__c DESC LIMIT 1) " +
+					"FROM SM_Logical_Host__c WHERE Id IN ('a01','a02')")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]soqltest.Record{
+					{"Id": "a01", "Application_Versions__r": []soqltest.Record{{"Version__c": "2.0"}}},
+					{"Id": "a02", "Application_Versions__r": nil},
+				}))
+
+				records, err = db.Records("SELECT Id,(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r " +
+					"WHERE SM_Application_Versions__c.Current__c = false) FROM SM_Logical_Host__c WHERE Id = 'a01'")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]soqltest.Record{
+					{"Id": "a01", "Application_Versions__r": []soqltest.Record{{"Version__c": "1.0"}}},
+				}))
+			})
+
+			It("selects fields of polymorphic relationships by the sObject of related records", func() {
+				db.Insert("Task",
+					soqltest.Record{"Id": "t1", "What": soqltest.Record{
+						"attributes": soqltest.Record{"type": "Account"}, "Name": "Acme", "Industry": "Retail",
+					}},
+					soqltest.Record{"Id": "t2", "What": soqltest.Record{
+						"attributes": soqltest.Record{"type": "Campaign"}, "Name": "Spring", "Status": "Planned",
+					}},
+					soqltest.Record{"Id": "t3", "What": nil},
+				)
+				records, err = db.Records("SELECT Id,TYPEOF What WHEN Account THEN Industry ELSE Name END FROM Task")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]soqltest.Record{
+					{"Id": "t1", "What": soqltest.Record{"attributes": soqltest.Record{"type": "Account"}, "Industry": "Retail"}},
+					{"Id": "t2", "What": soqltest.Record{"attributes": soqltest.Record{"type": "Campaign"}, "Name": "Spring"}},
+					{"Id": "t3", "What": nil},
+				}))
+
+				var tasks []task
+				Expect(db.Query(taskQuery{}, &tasks)).To(Succeed())
+				Expect(tasks).To(Equal([]task{
+					{ID: "t1", What: taskWhat{Account: &taskAccount{Industry: "Retail"}}},
+					{ID: "t2", What: taskWhat{Other: taskName{Name: "Spring"}}},
+					{ID: "t3"},
+				}))
+			})
+
+			It("selects standard, custom or all fields with FIELDS()", func() {
+				records, err = db.Records("SELECT FIELDS(STANDARD) FROM SM_Logical_Host__c WHERE Name = 'db-2' LIMIT 1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]soqltest.Record{{"Id": "a02", "Name": "db-2"}}))
+
+				records, err = db.Records("SELECT Name,FIELDS(CUSTOM) FROM SM_Logical_Host__c WHERE Name = 'db-2' LIMIT 200")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(Equal([]soqltest.Record{{
+					"Name":                    "db-2",
+					"Num_of_CPU_Cores__c":     16,
+					"Last_Discovered_Date__c": now.AddDate(0, 0, -1),
+					"Tags__c":                 "linux",
+				}}))
+
+				records, err = db.Records("SELECT FIELDS(ALL) FROM SM_Logical_Host__c WHERE Name = 'db-2' LIMIT 200")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records[0]).To(HaveLen(5))
+				Expect(records[0]).ToNot(HaveKey("Role__r"))
+			})
+
+			It("evaluates semi-join and anti-join subqueries", func() {
+				records, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Id NOT IN " +
+					"(SELECT Host__c FROM SM_Application_Host__c WHERE Application__r.Name = 'billing')")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a02", "a04", "a05"}))
+			})
+		})
+
+		Context("when query is soql struct", func() {
+			var query hostQuery
+
+			BeforeEach(func() {
+				query = hostQuery{OrderByClause: []soql.Order{{Field: "ID"}}}
+			})
+
+			JustBeforeEach(func() {
+				records, err = db.Records(query)
+			})
+
+			Context("when like patterns have wildcard characters", func() {
+				BeforeEach(func() {
+					query.WhereClause.Patterns = []string{"APP_1", "app%2"}
+				})
+
+				It("matches them literally", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ids(records)).To(Equal([]interface{}{"a03", "a04"}))
+				})
+			})
+
+			Context("when not like and not in operators are used", func() {
+				BeforeEach(func() {
+					query.WhereClause.Excluded = []string{"db-1"}
+					query.WhereClause.NotRoles = []string{"app"}
+				})
+
+				It("returns records with null values", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ids(records)).To(Equal([]interface{}{"a02", "a04"}))
+				})
+			})
+
+			Context("when includes and excludes operators are used", func() {
+				BeforeEach(func() {
+					query.WhereClause.Tags = []string{"ssd;linux", "windows"}
+					query.WhereClause.NotTags = []string{"ssd"}
+				})
+
+				It("matches multi-select picklist values", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ids(records)).To(Equal([]interface{}{"a05"}))
+				})
+			})
+
+			Context("when date literal and comparison operators are used", func() {
+				BeforeEach(func() {
+					minCores := 8
+					query.WhereClause.Discovered = soql.Today
+					query.WhereClause.MinCores = &minCores
+				})
+
+				It("returns matching records", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ids(records)).To(Equal([]interface{}{"a01"}))
+				})
+			})
+
+			Context("when subqueries are used", func() {
+				BeforeEach(func() {
+					undetected := true
+					query.WhereClause.Roles = []string{"app"}
+					query.WhereClause.Apps = &appQuery{WhereClause: appHostCriteria{Names: []string{"search"}}}
+					query.WhereClause.Either = &hostCriteriaGroup{Names: []string{"appx1"}, Undetected: &undetected}
+				})
+
+				It("returns matching records", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ids(records)).To(Equal([]interface{}{"a03"}))
+				})
+			})
+
+			Context("when limit and offset are given", func() {
+				BeforeEach(func() {
+					limit, offset := 2, 1
+					query.OrderByClause = []soql.Order{{Field: "Name", IsDesc: true}}
+					query.LimitClause = &limit
+					query.OffsetClause = &offset
+				})
+
+				It("returns the range of sorted records", func() {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ids(records)).To(Equal([]interface{}{"a01", "a05"}))
+				})
+			})
+		})
+
+		Context("when query is parsed", func() {
+			It("evaluates the syntax tree", func() {
+				q, err := soql.Parse("SELECT Id FROM SM_Logical_Host__c WHERE NOT (Name LIKE 'db%')")
+				Expect(err).ToNot(HaveOccurred())
+				records, err = db.Records(q)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ids(records)).To(Equal([]interface{}{"a03", "a04", "a05"}))
+			})
+		})
+
+		Context("when query cannot be evaluated", func() {
+			It("returns ErrUnknownSObject for sObjects without records", func() {
+				_, err = db.Records("SELECT Id FROM Account")
+				Expect(errors.Is(err, soql.ErrUnknownSObject)).To(BeTrue())
+
+				db.Insert("Account")
+				records, err = db.Records("SELECT Id FROM Account")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(BeEmpty())
+			})
+
+			It("returns ErrTypeMismatch for values of other types", func() {
+				_, err = db.Records("SELECT Id FROM SM_Logical_Host__c WHERE Name = 5")
+				Expect(errors.Is(err, soqltest.ErrTypeMismatch)).To(BeTrue())
+				Expect(err).To(MatchError("Name: ErrTypeMismatch"))
+			})
+
+			It("returns ErrUnsupported for aggregate queries", func() {
+				_, err = db.Records("SELECT Role__r.Name, COUNT(Id) FROM SM_Logical_Host__c GROUP BY Role__r.Name")
+				Expect(errors.Is(err, soqltest.ErrUnsupported)).To(BeTrue())
+			})
+
+			It("returns ErrUnsupported for scopes other than everything", func() {
+				_, err = db.Records("SELECT Id FROM SM_Logical_Host__c USING SCOPE mine")
+				Expect(errors.Is(err, soqltest.ErrUnsupported)).To(BeTrue())
+				records, err := db.Records("SELECT Id FROM SM_Logical_Host__c USING SCOPE Everything")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(5))
+			})
+
+			It("returns syntax errors", func() {
+				_, err = db.Records("SELECT FROM SM_Logical_Host__c")
+				var syntaxErr *soql.SyntaxError
+				Expect(errors.As(err, &syntaxErr)).To(BeTrue())
+			})
+
+			It("returns marshal errors", func() {
+				_, err = db.Records(hostQuery{WhereClause: hostCriteria{Discovered: "SOMEDAY"}})
+				var marshalErr *soql.MarshalError
+				Expect(errors.As(err, &marshalErr)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("Query", func() {
+		It("decodes records into soql struct", func() {
+			query := hostQuery{
+				WhereClause:   hostCriteria{Roles: []string{"db"}},
+				OrderByClause: []soql.Order{{Field: "Name"}},
+			}
+			Expect(db.Query(query, &query)).To(Succeed())
+			// times are decoded in the zone of their offset
+			for indx := range query.SelectClause {
+				query.SelectClause[indx].Discovered = query.SelectClause[indx].Discovered.UTC()
+			}
+			Expect(query.SelectClause).To(Equal([]host{
+				{
+					ID:         "a01",
+					Name:       "db-1",
+					RoleName:   "db",
+					Cores:      8,
+					Discovered: now.Add(-time.Hour),
+					Versions:   versions{SelectClause: []version{{Version: "1.0"}, {Version: "2.0"}}},
+				},
+				{
+					ID:         "a02",
+					Name:       "db-2",
+					RoleName:   "db",
+					Cores:      16,
+					Discovered: now.AddDate(0, 0, -1),
+				},
+			}))
+		})
+	})
+})
@@ -0,0 +1,404 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat = "2006-01-02"
+	timeFormat = "15:04:05.000Z"
+	// aggregateExpr is the prefix of the names of aggregate columns without alias in query response
+	aggregateExpr = "expr"
+	// attributesKey is the name of the object in query response with the sObject of the record as type
+	attributesKey = "attributes"
+)
+
+var (
+	// ErrInvalidUnmarshalTarget error is returned when value passed to Unmarshal is not a non nil pointer
+	// to a soql struct or to a slice of structs tagged with selectColumn
+	ErrInvalidUnmarshalTarget = errors.New("ErrInvalidUnmarshalTarget")
+)
+
+// queryResult is the response body of the Salesforce REST query resource. It is also the shape
+// of child relationship values within a record.
+type queryResult struct {
+	TotalSize      int               `json:"totalSize"`
+	Done           bool              `json:"done"`
+	NextRecordsURL string            `json:"nextRecordsUrl"`
+	Records        []json.RawMessage `json:"records"`
+}
+
+// timeFormats lists the formats in which Salesforce returns date, dateTime and time fields
+var timeFormats = []string{DateTimeFormat, time.RFC3339Nano, dateFormat, timeFormat}
+
+// Unmarshal decodes the JSON response of Salesforce REST query resource into v. It uses the same soql tags
+// that Marshal uses to construct the query, so the response can be decoded into the struct that produced it.
+// v must be a non nil pointer to one of the following:
+// 1. A soql struct, i.e. the struct passed to Marshal. Records are decoded into the member tagged with
+// selectClause. Declare that member as a slice of the select struct to receive all the records. If it
+// is declared as a struct then only the first record is decoded into it.
+// 2. A slice of structs tagged with selectColumn. Each record is decoded into one element of the slice.
+// 3. A struct tagged with selectColumn. The first record is decoded into it.
+//
+// Columns are matched using fieldName parameter of selectColumn tag. Names are matched case insensitively,
+// similar to how Salesforce treats them. Dotted field names like Role__r.Name and nested structs are
+// resolved by walking the parent relationship objects in the record. Child relationships marked with
+// selectChild are decoded into the selectClause member of the child struct.
+// Consider following structs:
+// type TestSoqlStruct struct {
+// 	SelectClause []ParentStruct `soql:"selectClause,tableName=SM_Logical_Host__c"`
+// }
+// type ParentStruct struct {
+// 	ID          string          `soql:"selectColumn,fieldName=Id"`
+// 	RoleName    string          `soql:"selectColumn,fieldName=Role__r.Name"`
+// 	ChildStruct TestChildStruct `soql:"selectChild,fieldName=Application_Versions__r"`
+// }
+// type TestChildStruct struct {
+// 	SelectClause []ChildStruct `soql:"selectClause,tableName=SM_Application_Versions__c"`
+// }
+// type ChildStruct struct {
+// 	Version string `soql:"selectColumn,fieldName=Version__c"`
+// }
+// soqlStruct := TestSoqlStruct{}
+// query, err := Marshal(soqlStruct)
+// // query is SELECT Id,Role__r.Name,(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r) FROM SM_Logical_Host__c
+// // execute the query and read response body into data
+// err = Unmarshal(data, &soqlStruct)
+// soqlStruct.SelectClause now contains one ParentStruct for every record in the response along with
+// the versions of each record in ChildStruct.SelectClause.
+func Unmarshal(data []byte, v interface{}) error {
+	reflectedValue := reflect.ValueOf(v)
+	if reflectedValue.Kind() != reflect.Ptr {
+		return ErrInvalidUnmarshalTarget
+	}
+	if reflectedValue.IsNil() {
+		return ErrNilValue
+	}
+	var result queryResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	return unmarshalQueryResult(result, reflectedValue.Elem())
+}
+
+// unmarshalQueryResult decodes records in result into target, which is either a soql struct, a slice of
+// select structs or a select struct
+func unmarshalQueryResult(result queryResult, target reflect.Value) error {
+	switch target.Kind() {
+	case reflect.Slice:
+		return unmarshalRecords(result.Records, target, false)
+	case reflect.Struct:
+		index, err := getSelectClauseIndex(target.Type())
+		if err != nil {
+			return err
+		}
+		if index < 0 {
+			// target is the select struct itself
+			return unmarshalRecords(result.Records, target, false)
+		}
+		return unmarshalRecords(result.Records, target.Field(index), hasGroupByClause(target.Type()))
+	default:
+		return ErrInvalidUnmarshalTarget
+	}
+}
+
+// getSelectClauseIndex returns index of the member tagged with selectClause or -1 if there is none
+func getSelectClauseIndex(reflectedType reflect.Type) (int, error) {
+	index := -1
+	for i := 0; i < reflectedType.NumField(); i++ {
+		clauseTag := reflectedType.Field(i).Tag.Get(SoqlTag)
+		if clauseTag == "" || getClauseKey(clauseTag) != SelectClause {
+			continue
+		}
+		if index >= 0 {
+			return -1, ErrMultipleSelectClause
+		}
+		index = i
+	}
+	return index, nil
+}
+
+// unmarshalRecords decodes records into target, which is a select struct or a slice of them. grouped is set
+// when records are the result of a query with groupByClause.
+func unmarshalRecords(records []json.RawMessage, target reflect.Value, grouped bool) error {
+	if target.Kind() == reflect.Struct {
+		if len(records) == 0 {
+			return nil
+		}
+		return unmarshalRecord(records[0], target, grouped)
+	}
+	if target.Kind() != reflect.Slice {
+		return ErrInvalidUnmarshalTarget
+	}
+	elemType := target.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return ErrInvalidUnmarshalTarget
+	}
+	slice := reflect.MakeSlice(target.Type(), 0, len(records))
+	for _, record := range records {
+		elem := reflect.New(elemType)
+		if err := unmarshalRecord(record, elem.Elem(), grouped); err != nil {
+			return err
+		}
+		if isPtr {
+			slice = reflect.Append(slice, elem)
+		} else {
+			slice = reflect.Append(slice, elem.Elem())
+		}
+	}
+	target.Set(slice)
+	return nil
+}
+
+func unmarshalRecord(data json.RawMessage, target reflect.Value, grouped bool) error {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	return unmarshalSelectStruct(record, target, grouped)
+}
+
+// unmarshalSelectStruct decodes record into target, which is a struct with members tagged with selectColumn
+// and selectChild. grouped is set when record is the result of a query with groupByClause.
+func unmarshalSelectStruct(record map[string]json.RawMessage, target reflect.Value, grouped bool) error {
+	reflectedType := target.Type()
+	aggregated := grouped || hasAggregateColumns(reflectedType)
+	// Aggregate columns without alias are returned as expr0, expr1 and so on
+	exprIndex := 0
+	for i := 0; i < reflectedType.NumField(); i++ {
+		field := reflectedType.Field(i)
+		clauseTag := field.Tag.Get(SoqlTag)
+		if clauseTag == "" {
+			continue
+		}
+		clauseKey := getClauseKey(clauseTag)
+		_, isAggregate := aggregateFunctions[clauseKey]
+		if clauseKey != SelectColumn && clauseKey != SelectChild && clauseKey != SelectTypeOf && !isAggregate {
+			return ErrInvalidTag
+		}
+		fieldName := getFieldName(clauseTag, field.Name)
+		if fieldName == "" {
+			return ErrInvalidTag
+		}
+		if isAggregate {
+			fieldName = getTagValue(clauseTag, Alias, "")
+			if fieldName == "" {
+				fieldName = aggregateExpr + strconv.Itoa(exprIndex)
+				exprIndex++
+			}
+		}
+		fieldValue := target.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		raw, ok, err := lookupFieldValue(record, fieldName, aggregated)
+		if err != nil {
+			return err
+		}
+		if !ok || isNull(raw) {
+			fieldValue.Set(reflect.Zero(field.Type))
+			continue
+		}
+		if clauseKey == SelectChild {
+			if field.Type.Kind() != reflect.Struct {
+				return ErrInvalidTag
+			}
+			var result queryResult
+			if err := json.Unmarshal(raw, &result); err != nil {
+				return err
+			}
+			if err := unmarshalQueryResult(result, fieldValue); err != nil {
+				return err
+			}
+			continue
+		}
+		if clauseKey == SelectTypeOf {
+			if field.Type.Kind() != reflect.Struct {
+				return ErrInvalidTag
+			}
+			if err := unmarshalTypeOf(raw, fieldValue); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			// Nested struct for parent relationship
+			var parent map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &parent); err != nil {
+				return err
+			}
+			if err := unmarshalSelectStruct(parent, fieldValue, false); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := unmarshalFieldValue(raw, fieldValue, getTagParameterMap(clauseTag)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalTypeOf decodes the record of polymorphic relationship in raw into the member of target, which is
+// the struct tagged with selectTypeOf, that is tagged with selectWhen for the sObject of the record, or into
+// the member tagged with selectElse if there is none. The other members are set to their zero values.
+func unmarshalTypeOf(raw json.RawMessage, target reflect.Value) error {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return err
+	}
+	var attributes struct {
+		Type string `json:"type"`
+	}
+	if rawAttributes, ok := lookupKey(record, attributesKey); ok && !isNull(rawAttributes) {
+		if err := json.Unmarshal(rawAttributes, &attributes); err != nil {
+			return err
+		}
+	}
+	plan := getTypeOfPlan(target.Type())
+	if plan.err != nil {
+		return plan.err
+	}
+	selected := -1
+	for _, branch := range plan.branches {
+		if branch.err != nil {
+			return branch.err
+		}
+		if branch.clauseKey == SelectWhen && strings.EqualFold(branch.sObject, attributes.Type) {
+			selected = branch.index
+			break
+		}
+		if branch.clauseKey == SelectElse {
+			selected = branch.index
+		}
+	}
+	for _, branch := range plan.branches {
+		fieldValue := target.Field(branch.index)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		if branch.index != selected {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Ptr {
+			fieldValue.Set(reflect.New(branch.structType))
+			fieldValue = fieldValue.Elem()
+		}
+		if err := unmarshalSelectStruct(record, fieldValue, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasGroupByClause reports whether the soql struct of reflectedType has a member tagged with groupByClause
+func hasGroupByClause(reflectedType reflect.Type) bool {
+	for i := 0; i < reflectedType.NumField(); i++ {
+		if getClauseKey(reflectedType.Field(i).Tag.Get(SoqlTag)) == GroupByClause {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAggregateColumns reports whether the select struct of reflectedType has aggregate columns
+func hasAggregateColumns(reflectedType reflect.Type) bool {
+	for i := 0; i < reflectedType.NumField(); i++ {
+		if _, isAggregate := aggregateFunctions[getClauseKey(reflectedType.Field(i).Tag.Get(SoqlTag))]; isAggregate {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupFieldValue returns the raw value of fieldName in record. Dotted field names are resolved
+// by walking through the parent relationship objects. Records of aggregate queries contain grouped
+// relationship fields without the relationship, so if aggregated is set the last part of fieldName
+// is used when the relationship is missing from record.
+func lookupFieldValue(record map[string]json.RawMessage, fieldName string, aggregated bool) (json.RawMessage, bool, error) {
+	path := strings.Split(fieldName, period)
+	for indx, name := range path {
+		raw, ok := lookupKey(record, name)
+		if !ok && aggregated && indx == 0 && len(path) > 1 {
+			raw, ok = lookupKey(record, path[len(path)-1])
+			return raw, ok, nil
+		}
+		if !ok || isNull(raw) || indx == len(path)-1 {
+			return raw, ok, nil
+		}
+		record = nil
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, false, err
+		}
+	}
+	return nil, false, nil
+}
+
+func lookupKey(record map[string]json.RawMessage, key string) (json.RawMessage, bool) {
+	if raw, ok := record[key]; ok {
+		return raw, true
+	}
+	for k, raw := range record {
+		if strings.EqualFold(k, key) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == null
+}
+
+func unmarshalFieldValue(raw json.RawMessage, fieldValue reflect.Value, tags map[string]string) error {
+	fieldType := fieldValue.Type()
+	if fieldType == timeType || (fieldType.Kind() == reflect.Ptr && fieldType.Elem() == timeType) {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		t, err := parseTime(value, tags)
+		if err != nil {
+			return err
+		}
+		if fieldType.Kind() == reflect.Ptr {
+			fieldValue.Set(reflect.ValueOf(&t))
+		} else {
+			fieldValue.Set(reflect.ValueOf(t))
+		}
+		return nil
+	}
+	return json.Unmarshal(raw, fieldValue.Addr().Interface())
+}
+
+func parseTime(value string, tags map[string]string) (time.Time, error) {
+	formats := timeFormats
+	if customFormat, ok := tags[Format]; ok {
+		formats = append([]string{customFormat}, timeFormats...)
+	}
+	var err error
+	for _, format := range formats {
+		var t time.Time
+		t, err = time.Parse(format, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
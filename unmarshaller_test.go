@@ -0,0 +1,337 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/forcedotcom/go-soql"
+)
+
+const unmarshalResponse = `{
+	"totalSize": 2,
+	"done": true,
+	"records": [
+		{
+			"attributes": {"type": "SM_Logical_Host__c", "url": "/services/data/v48.0/sobjects/SM_Logical_Host__c/a001"},
+			"Id": "a001",
+			"Host_Name__c": "host-db1",
+			"Role__r": {"attributes": {"type": "Role__c"}, "Name": "db"},
+			"num_of_cpu_cores__c": 16,
+			"CreatedDate": "2019-03-01T17:43:53.000+0000",
+			"Last_Restart__c": "2019-03-02T10:00:00.000+0000",
+			"NUMA_Enabled__c": true,
+			"Tech_Asset__r": {
+				"attributes": {"type": "Tech_Asset__c"},
+				"Asset_Type_Asset_Type__c": "SERVER",
+				"Owner__r": {"attributes": {"type": "User"}, "Email": "owner@example.com"}
+			},
+			"Application_Versions__r": {
+				"totalSize": 2,
+				"done": true,
+				"records": [
+					{"attributes": {"type": "SM_Application_Versions__c"}, "Version__c": "1.0"},
+					{"attributes": {"type": "SM_Application_Versions__c"}, "Version__c": "2.0"}
+				]
+			}
+		},
+		{
+			"attributes": {"type": "SM_Logical_Host__c", "url": "/services/data/v48.0/sobjects/SM_Logical_Host__c/a002"},
+			"Id": "a002",
+			"Host_Name__c": "host-app1",
+			"Role__r": null,
+			"Num_of_CPU_Cores__c": null,
+			"CreatedDate": "2019-03-04T08:00:00.000+0000",
+			"Last_Restart__c": null,
+			"NUMA_Enabled__c": false,
+			"Tech_Asset__r": null,
+			"Application_Versions__r": null
+		}
+	]
+}`
+
+var _ = Describe("Unmarshal", func() {
+	var (
+		data []byte
+		err  error
+	)
+
+	BeforeEach(func() {
+		data = []byte(unmarshalResponse)
+	})
+
+	Context("when pointer to soql struct is passed", func() {
+		var soqlStruct unmarshalSoqlStruct
+
+		JustBeforeEach(func() {
+			soqlStruct = unmarshalSoqlStruct{}
+			err = soql.Unmarshal(data, &soqlStruct)
+		})
+
+		It("decodes all records into selectClause member", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(soqlStruct.SelectClause).To(HaveLen(2))
+		})
+
+		It("decodes columns, parent relationships and child relationships", func() {
+			host := soqlStruct.SelectClause[0]
+			Expect(host.ID).To(Equal("a001"))
+			Expect(host.Name).To(Equal("host-db1"))
+			Expect(host.RoleName).To(Equal("db"))
+			Expect(host.NumOfCPUCores).ToNot(BeNil())
+			Expect(*host.NumOfCPUCores).To(Equal(16))
+			Expect(host.CreatedDate.Equal(time.Date(2019, 3, 1, 17, 43, 53, 0, time.UTC))).To(BeTrue())
+			Expect(host.LastRestart).ToNot(BeNil())
+			Expect(host.LastRestart.Equal(time.Date(2019, 3, 2, 10, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(host.NUMAEnabled).To(BeTrue())
+			Expect(host.TechAsset.AssetType).To(Equal("SERVER"))
+			Expect(host.TechAsset.Owner.Email).To(Equal("owner@example.com"))
+			Expect(host.Versions.SelectClause).To(Equal([]ChildStruct{{Version: "1.0"}, {Version: "2.0"}}))
+		})
+
+		It("leaves zero values for null fields", func() {
+			host := soqlStruct.SelectClause[1]
+			Expect(host.ID).To(Equal("a002"))
+			Expect(host.RoleName).To(BeEmpty())
+			Expect(host.NumOfCPUCores).To(BeNil())
+			Expect(host.LastRestart).To(BeNil())
+			Expect(host.TechAsset).To(Equal(unmarshalTechAsset{}))
+			Expect(host.Versions.SelectClause).To(BeEmpty())
+		})
+
+		It("still marshals into the query that produced the response", func() {
+			query, err := soql.Marshal(soqlStruct)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(query).To(Equal("SELECT Id,Host_Name__c,Role__r.Name,Num_of_CPU_Cores__c,CreatedDate,Last_Restart__c,NUMA_Enabled__c,Tech_Asset__r.Asset_Type_Asset_Type__c,Tech_Asset__r.Owner__r.Email,(SELECT SM_Application_Versions__c.Version__c FROM Application_Versions__r) FROM SM_Logical_Host__c"))
+		})
+	})
+
+	Context("when pointer to soql struct with struct selectClause is passed", func() {
+		var soqlStruct unmarshalSingleSoqlStruct
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &soqlStruct)
+		})
+
+		It("decodes first record into selectClause member", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(soqlStruct.SelectClause.ID).To(Equal("a001"))
+		})
+	})
+
+	Context("when pointer to slice of select structs is passed", func() {
+		var hosts []unmarshalHost
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &hosts)
+		})
+
+		It("decodes all records into the slice", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hosts).To(HaveLen(2))
+			Expect(hosts[0].ID).To(Equal("a001"))
+			Expect(hosts[1].ID).To(Equal("a002"))
+		})
+	})
+
+	Context("when pointer to slice of pointers to select structs is passed", func() {
+		var hosts []*unmarshalHost
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &hosts)
+		})
+
+		It("decodes all records into the slice", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hosts).To(HaveLen(2))
+			Expect(hosts[0].TechAsset.AssetType).To(Equal("SERVER"))
+		})
+	})
+
+	Context("when response has no records", func() {
+		var hosts []unmarshalHost
+
+		BeforeEach(func() {
+			data = []byte(`{"totalSize": 0, "done": true, "records": []}`)
+		})
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &hosts)
+		})
+
+		It("returns empty slice", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hosts).To(BeEmpty())
+		})
+	})
+
+	Context("when response is the result of aggregate query", func() {
+		var counts []ownerCaseCount
+
+		BeforeEach(func() {
+			data = []byte(`{
+				"totalSize": 1,
+				"done": true,
+				"records": [
+					{
+						"attributes": {"type": "AggregateResult"},
+						"Name": "Jane Doe",
+						"Status": "New",
+						"cnt": 7,
+						"expr0": "2019-03-01T17:43:53.000+0000",
+						"expr1": 3,
+						"grpOwner": 0
+					}
+				]
+			}`)
+		})
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &counts)
+		})
+
+		It("decodes aggregate columns by alias or by position and grouped fields by name", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(HaveLen(1))
+			Expect(counts[0].OwnerName).To(Equal("Jane Doe"))
+			Expect(counts[0].Status).To(Equal("New"))
+			Expect(counts[0].CaseCount).To(Equal(7))
+			Expect(counts[0].LastCreated.Equal(time.Date(2019, 3, 1, 17, 43, 53, 0, time.UTC))).To(BeTrue())
+			Expect(counts[0].AccountCount).To(Equal(3))
+			Expect(counts[0].OwnerGrouping).To(Equal(0))
+		})
+	})
+
+	Context("when response is the result of query grouped without aggregate columns", func() {
+		var grouped groupedSoqlStruct
+
+		BeforeEach(func() {
+			data = []byte(`{"totalSize": 1, "done": true, "records": [{"attributes": {"type": "AggregateResult"}, "Name": "Jane Doe"}]}`)
+		})
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &grouped)
+		})
+
+		It("decodes grouped fields by name", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(grouped.SelectClause).To(HaveLen(1))
+			Expect(grouped.SelectClause[0].OwnerName).To(Equal("Jane Doe"))
+		})
+	})
+
+	Context("when relationship is missing from record of query that is not aggregate", func() {
+		var hosts []unmarshalHost
+
+		BeforeEach(func() {
+			data = []byte(`{"totalSize": 1, "done": true, "records": [{"attributes": {"type": "SM_Logical_Host__c"}, "Id": "a003", "Name": "host-db3"}]}`)
+		})
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &hosts)
+		})
+
+		It("does not decode relationship fields from the fields of record", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hosts).To(HaveLen(1))
+			Expect(hosts[0].ID).To(Equal("a003"))
+			Expect(hosts[0].RoleName).To(BeEmpty())
+		})
+	})
+
+	Context("when response has polymorphic relationship selected with TYPEOF", func() {
+		var tasks []task
+
+		BeforeEach(func() {
+			data = []byte(`{
+				"totalSize": 4,
+				"done": true,
+				"records": [
+					{
+						"attributes": {"type": "Task"},
+						"Id": "t1",
+						"What": {"attributes": {"type": "Account"}, "Name": "Acme", "Industry": "Retail"}
+					},
+					{
+						"attributes": {"type": "Task"},
+						"Id": "t2",
+						"What": {"attributes": {"type": "Opportunity"}, "Amount": 1500, "Owner": {"Name": "Jane Doe"}}
+					},
+					{
+						"attributes": {"type": "Task"},
+						"Id": "t3",
+						"What": {"attributes": {"type": "Campaign"}, "Name": "Spring"}
+					},
+					{"attributes": {"type": "Task"}, "Id": "t4", "What": null}
+				]
+			}`)
+		})
+
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, &tasks)
+		})
+
+		It("decodes the related record into the member of its sObject", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tasks).To(HaveLen(4))
+			Expect(tasks[0].What).To(Equal(taskWhat{Account: &whatAccount{Name: "Acme", Industry: "Retail"}}))
+			Expect(tasks[1].What).To(Equal(taskWhat{Opportunity: whatOpportunity{Amount: 1500, OwnerName: whatName{Name: "Jane Doe"}}}))
+			Expect(tasks[2].What).To(Equal(taskWhat{Other: &whatName{Name: "Spring"}}))
+			Expect(tasks[3].What).To(Equal(taskWhat{}))
+		})
+	})
+
+	Context("when non pointer value is passed", func() {
+		JustBeforeEach(func() {
+			err = soql.Unmarshal(data, unmarshalSoqlStruct{})
+		})
+
+		It("returns ErrInvalidUnmarshalTarget error", func() {
+			Expect(err).To(Equal(soql.ErrInvalidUnmarshalTarget))
+		})
+	})
+
+	Context("when nil pointer is passed", func() {
+		JustBeforeEach(func() {
+			var soqlStruct *unmarshalSoqlStruct
+			err = soql.Unmarshal(data, soqlStruct)
+		})
+
+		It("returns ErrNilValue error", func() {
+			Expect(err).To(Equal(soql.ErrNilValue))
+		})
+	})
+
+	Context("when select struct has invalid tag", func() {
+		JustBeforeEach(func() {
+			var records []unmarshalInvalidTagStruct
+			err = soql.Unmarshal(data, &records)
+		})
+
+		It("returns ErrInvalidTag error", func() {
+			Expect(err).To(Equal(soql.ErrInvalidTag))
+		})
+	})
+
+	Context("when response is not valid json", func() {
+		BeforeEach(func() {
+			data = []byte(`{"records": [`)
+		})
+
+		JustBeforeEach(func() {
+			var hosts []unmarshalHost
+			err = soql.Unmarshal(data, &hosts)
+		})
+
+		It("returns error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2018, salesforce.com, inc.
+ * All rights reserved.
+ * SPDX-License-Identifier: BSD-3-Clause
+ * For full license text, see the LICENSE file in the repo root or https://opensource.org/licenses/BSD-3-Clause
+ */
+package soql
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// SOQLValuer is the interface implemented by types of values used in conditions that write their own SOQL
+// literal, e.g. decimal amounts or dates of a custom type. SOQLValue returns the literal and whether it is a
+// string that needs to be quoted, in which case it is also escaped.
+//
+// Values that do not implement SOQLValuer but encoding.TextMarshaler or fmt.Stringer are always written as
+// quoted strings of their text, e.g. typed IDs or enums, so types written as unquoted literals like civil.Date
+// need to implement SOQLValuer. Values of named types whose underlying type is a string,
+// number or bool are written as the underlying value. SOQLValuer takes precedence over encoding.TextMarshaler,
+// which takes precedence over fmt.Stringer and the underlying type. Values are passed to the methods as they
+// are, so the methods of members that are not pointers need to have value receivers.
+type SOQLValuer interface {
+	SOQLValue() (literal string, quoted bool)
+}
+
+var (
+	soqlValuerType    = reflect.TypeOf((*SOQLValuer)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	dateLiteralType   = reflect.TypeOf(DateLiteral(""))
+)
+
+// implementsValuer reports whether t formats its values itself
+func implementsValuer(t reflect.Type) bool {
+	return t.Implements(soqlValuerType) || t.Implements(textMarshalerType) || t.Implements(stringerType)
+}
+
+// isValueKind reports whether values of kind k can be written as SOQL literals
+func isValueKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// isIntegerKind reports whether k is a kind of integers supported by the N days operators
+func isIntegerKind(k reflect.Kind) bool {
+	return isValueKind(k) && k != reflect.String && k != reflect.Bool && k != reflect.Float32 && k != reflect.Float64
+}
+
+// isValueType reports whether values of t are supported by comparison operators. Pointers are supported
+// except the ones to strings, same as for the predeclared types.
+func isValueType(t reflect.Type) bool {
+	if implementsValuer(t) {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		elem := t.Elem()
+		return elem == timeType || elem == dateLiteralType || implementsValuer(elem) ||
+			(isValueKind(elem.Kind()) && elem.Kind() != reflect.String)
+	}
+	return t == timeType || isValueKind(t.Kind())
+}
+
+// formatCustomValue returns v, which is of a type other than the ones handled by formatComparisonValue, as it
+// is written in comparison clauses along with whether it needs to be quoted. Empty string is returned for nil
+// pointers.
+func formatCustomValue(v interface{}, tags map[string]string) (string, bool, error) {
+	reflectedValue := reflect.ValueOf(v)
+	if !reflectedValue.IsValid() || !isValueType(reflectedValue.Type()) {
+		return "", false, ErrInvalidTag
+	}
+	if reflectedValue.Kind() == reflect.Ptr {
+		if reflectedValue.IsNil() {
+			return "", false, nil
+		}
+		if !implementsValuer(reflectedValue.Type()) {
+			return formatComparisonValue(reflectedValue.Elem().Interface(), tags)
+		}
+	}
+
+	switch u := v.(type) {
+	case SOQLValuer:
+		literal, quoted := u.SOQLValue()
+		return literal, quoted, nil
+	case encoding.TextMarshaler:
+		text, err := u.MarshalText()
+		if err != nil {
+			return "", false, err
+		}
+		return string(text), true, nil
+	case fmt.Stringer:
+		return u.String(), true, nil
+	}
+
+	switch reflectedValue.Kind() {
+	case reflect.String:
+		return reflectedValue.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(reflectedValue.Bool()), false, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(reflectedValue.Int(), 10), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(reflectedValue.Uint(), 10), false, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(reflectedValue.Float(), 'g', -1, 32), false, nil
+	default:
+		return strconv.FormatFloat(reflectedValue.Float(), 'g', -1, 64), false, nil
+	}
+}
+
+// formatCustomValues returns the items of slice v, whose type is not handled by formatContainsValues, as they
+// are written in IN and NOT IN clauses. The items are already quoted where needed. Types that format their
+// values themselves are not slices even if their underlying type is.
+func formatCustomValues(v interface{}, tags map[string]string) ([]string, error) {
+	reflectedValue := reflect.ValueOf(v)
+	if reflectedValue.Kind() != reflect.Slice || implementsValuer(reflectedValue.Type()) ||
+		!isValueType(reflectedValue.Type().Elem()) {
+		return nil, ErrInvalidTag
+	}
+	items := make([]string, 0, reflectedValue.Len())
+	for indx := 0; indx < reflectedValue.Len(); indx++ {
+		item, useSingleQuotes, err := formatComparisonValue(reflectedValue.Index(indx).Interface(), tags)
+		if err != nil {
+			return nil, err
+		}
+		if item == "" && !useSingleQuotes {
+			return nil, ErrNilValue
+		}
+		items = append(items, quoteValue(item, useSingleQuotes))
+	}
+	return items, nil
+}
+
+// isTextType reports whether values of t are written as quoted strings, which LIKE and INCLUDES clauses
+// require
+func isTextType(t reflect.Type) bool {
+	if t == timeType || t == dateLiteralType || t.Kind() == reflect.Ptr {
+		return false
+	}
+	return t.Kind() == reflect.String || implementsValuer(t)
+}
+
+// formatTextValues returns the text of the items of slice v of a type other than []string, unquoted and
+// unescaped, for LIKE and INCLUDES clauses
+func formatTextValues(v interface{}) ([]string, error) {
+	reflectedValue := reflect.ValueOf(v)
+	if reflectedValue.Kind() != reflect.Slice || implementsValuer(reflectedValue.Type()) ||
+		!isTextType(reflectedValue.Type().Elem()) {
+		return nil, ErrInvalidTag
+	}
+	items := make([]string, 0, reflectedValue.Len())
+	for indx := 0; indx < reflectedValue.Len(); indx++ {
+		item, useSingleQuotes, err := formatCustomValue(reflectedValue.Index(indx).Interface(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if !useSingleQuotes {
+			return nil, ErrInvalidTag
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// formatDaysValue returns v, which is of a type other than the predeclared integers and pointers to them, as
+// the number of days of N days operators. v is either a SOQLValuer returning unquoted literal or of a named
+// integer type, or a pointer to them. Empty string is returned for nil pointers.
+func formatDaysValue(v interface{}) (string, error) {
+	reflectedValue := reflect.ValueOf(v)
+	if !reflectedValue.IsValid() {
+		return "", ErrInvalidTag
+	}
+	if valuer, ok := v.(SOQLValuer); ok && (reflectedValue.Kind() != reflect.Ptr || !reflectedValue.IsNil()) {
+		literal, quoted := valuer.SOQLValue()
+		if quoted {
+			return "", ErrInvalidTag
+		}
+		return literal, nil
+	}
+	if reflectedValue.Kind() == reflect.Ptr {
+		elem := reflectedValue.Type().Elem()
+		if !isIntegerKind(elem.Kind()) && !reflectedValue.Type().Implements(soqlValuerType) {
+			return "", ErrInvalidTag
+		}
+		if reflectedValue.IsNil() {
+			return "", nil
+		}
+		return formatDaysValue(reflectedValue.Elem().Interface())
+	}
+	switch reflectedValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(reflectedValue.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(reflectedValue.Uint(), 10), nil
+	}
+	return "", ErrInvalidTag
+}